@@ -13,12 +13,17 @@ import (
 	"chat_service/internal/room/repository/db"
 	rService "chat_service/internal/room/service"
 	"chat_service/internal/websocket"
+	wsConfig "chat_service/internal/websocket/config"
 	"chat_service/internal/websocket/dto"
 	"chat_service/internal/websocket/handler"
+	"chat_service/internal/websocket/ratelimit"
+	"chat_service/internal/websocket/session"
+	"chat_service/internal/websocket/ticket"
 	"chat_service/middleware_chat"
 	"chat_service/pkg/grpc_client"
 	"chat_service/pkg/grpc_generated/chat"
 	"chat_service/pkg/grpc_server"
+	"chat_service/pkg/jwtverify"
 	"context"
 	"errors"
 	"net"
@@ -116,15 +121,21 @@ func main() {
 	defer rdb.Close()
 
 	// Инициализация presence-репозитория
-	presenceRepo := pRepo.NewPresenceRepo(rdb, redisCfg.IdleThreshold)
+	presenceRepo := pRepo.NewPresenceRepo(rdb, redisCfg.IdleThreshold, redisCfg.LastSeenTTL)
 
 	// Инициализация сервисов с использованием redis
 	bus := service.NewPresenceEventBus()
 	presenceService := service.NewPresenceService(presenceRepo, bus, redisCfg)
 	pb := pubsub.NewRedisPubSub(rdb)
 
+	// События presence других инстансов тоже должны доходить до Hub и WatchPresence
+	instance, _ := os.Hostname()
+	if err := bus.Relay(ctx, pb, instance); err != nil {
+		log.Fatalf("failed to relay presence events: %v", err)
+	}
+
 	// Инициализация gRPC-сервера
-	presenceServer := grpc_server.NewGRPCServer(presenceService)
+	presenceServer := grpc_server.NewGRPCServer(presenceService, bus, websocket.NewControlPublisher(pb))
 
 	// Запуск gRPC-сервера presence
 	presencePort := os.Getenv("CHAT_GRPC_PRESENCE_PORT")
@@ -149,8 +160,18 @@ func main() {
 	log.Info("Waiting for gRPC servers to start...")
 	time.Sleep(5 * time.Second)
 
+	// Локальная проверка access-токенов по JWKS profile_service
+	jwksURL := os.Getenv("JWKS_URL")
+	if jwksURL == "" {
+		jwksURL = "http://profile-service:8083/.well-known/jwks.json"
+	}
+	tokenVerifier := jwtverify.NewVerifier(jwksURL, jwtverify.NewRedisRevocations(rdb))
+	go tokenVerifier.Run(ctx)
+
 	// Инициализация хэндлера
 	roomHandler := transport.NewRoomHandler(log, roomService, roomMemberService)
+	ticketStore := ticket.NewRedisStore(rdb)
+	wsTicketHandler := transport.NewWSTicketHandler(log, ticketStore)
 
 	// Создание gin-роутера
 	router := gin.Default()
@@ -166,22 +187,41 @@ func main() {
 		middleware_chat.ErrorMiddleware(log),
 	)
 
+	// Загрузка конфигурации WebSocket
+	wsCfg, err := wsConfig.WebSocketCfgLoad()
+	if err != nil {
+		log.Fatal("Ошибка получения конфигурации (websocket) %w", err)
+	}
+
 	// Подписка Hub к Presence
-	instance, _ := os.Hostname()
-	hub := websocket.NewHub(bus.Subscribe(), pb, instance)
+	limiter := ratelimit.NewLimiter(rdb, ratelimit.DefaultBudgets)
+	hub := websocket.NewHub(bus.Subscribe(), pb, instance, websocket.DisconnectSlowConsumer, wsCfg, limiter, authzService)
 	go hub.Run(ctx)
 
 	// Инициализация ws-роутера, регистрация хэндлеров и апгрейд соединения
 	wsRouter := websocket.NewRouter()
+	wsMetrics := websocket.NewMessageMetrics(wsRouter.Handles)
+	wsRouter.Use(
+		websocket.Recover,
+		websocket.Logging,
+		wsMetrics.Middleware,
+		websocket.RequireAuth,
+		websocket.RateLimit,
+		websocket.ValidatePayload(handler.Validators),
+	)
 	wsRouter.Register(dto.MessagePresence, handler.PresenceHandler)
 	wsRouter.Register(dto.MessageChat, handler.ChatHandler)
-	wsHandler := handler.NewWSHandler(ctx, wsRouter, hub, presenceService, authzService, profileClient)
+	wsRouter.Register(dto.MessageActivity, handler.ActivityHandler)
+	wsRouter.Register(dto.MessageResend, handler.ResendHandler)
+	wsRouter.Register(dto.MessageReauth, handler.NewReauthHandler(tokenVerifier))
+	sessionStore := session.NewRedisStore(rdb)
+	wsHandler := handler.NewWSHandler(ctx, wsCfg, wsRouter, hub, presenceService, sessionStore, ticketStore, authzService, tokenVerifier)
 	router.GET("/ws", gin.WrapF(wsHandler))
 
 	// Регистрация методов API
 	api := router.Group("/api/v1")
 	{
-		api.Use(middleware_chat.NewAuthMiddleware(profileClient, log))
+		api.Use(middleware_chat.NewAuthMiddleware(tokenVerifier, log))
 		room := api.Group("/room")
 		{
 			room.POST("", roomHandler.CreateRoom)
@@ -197,6 +237,10 @@ func main() {
 			roomMember.PUT("/rooms/:room_id/members/:user_id/admin", roomHandler.SetAdminMember)
 			roomMember.DELETE("/rooms/:room_id/members/:user_id", roomHandler.DeleteRoomMember)
 		}
+		ws := api.Group("/ws")
+		{
+			ws.POST("/ticket", wsTicketHandler.IssueTicket)
+		}
 	}
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -209,15 +253,42 @@ func main() {
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
-	serverErr := make(chan error, 1)
+	serverErr := make(chan error, 2)
+
+	// Счётчики WebSocket для мониторинга — на отдельном внутреннем порту,
+	// наружу он не публикуется
+	adminPort := os.Getenv("CHAT_ADMIN_PORT")
+	if adminPort == "" {
+		adminPort = "9084"
+	}
+	adminRouter := gin.New()
+	adminRouter.Use(gin.Recovery())
+	adminRouter.GET("/metrics/ws", func(c *gin.Context) {
+		c.JSON(http.StatusOK, hub.Stats())
+	})
+	adminRouter.GET("/metrics/ws/messages", func(c *gin.Context) {
+		c.JSON(http.StatusOK, wsMetrics.Snapshot())
+	})
+	adminSrv := &http.Server{
+		Addr:         ":" + adminPort,
+		Handler:      adminRouter,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 5 * time.Second,
+	}
 
-	// Запуск сервера в горутине
+	// Запуск серверов в горутинах
 	go func() {
 		log.Info("Starting server on :8084")
 		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			serverErr <- err
 		}
 	}()
+	go func() {
+		log.Infof("Starting admin server on :%s", adminPort)
+		if err := adminSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
 
 	// Отправка сигнала об остановке сервера в канал
 	quit := make(chan os.Signal, 1)
@@ -234,11 +305,27 @@ func main() {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// Drain WebSocket-соединений: новые апгрейды отклоняются, клиенты получают reconnect
+	log.Info("Draining websocket connections...")
+	handedOff := hub.Drain(shutdownCtx)
+
+	// Пользователи, не переподключившиеся к другому инстансу, уходят в offline
+	if len(handedOff) > 0 {
+		select {
+		case <-time.After(service.HandoffGrace):
+		case <-shutdownCtx.Done():
+		}
+		presenceService.FinishHandoff(shutdownCtx, handedOff)
+	}
+
 	// Остановка сервера
 	log.Info("Shutting down server...")
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		log.Errorf("Server forced to shutdown: %v", err)
 	}
+	if err := adminSrv.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("Admin server forced to shutdown: %v", err)
+	}
 
 	cancelMain()
 	presenceGrpcServer.GracefulStop()
@@ -541,6 +541,43 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/ws/ticket": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выдает одноразовый билет для /ws?ticket=..., действует 30 секунд. Нужен браузерам, которые не могут передать заголовок Authorization при открытии WebSocket",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "WebSocket"
+                ],
+                "summary": "Билет для подключения к WebSocket",
+                "responses": {
+                    "201": {
+                        "description": "Билет",
+                        "schema": {
+                            "$ref": "#/definitions/chat_service_http_api_dto.WSTicketResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные учетные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_chat.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_chat.ErrorResponse"
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
@@ -619,6 +656,17 @@ const docTemplate = `{
                 }
             }
         },
+        "chat_service_http_api_dto.WSTicketResponse": {
+            "type": "object",
+            "properties": {
+                "expiresIn": {
+                    "type": "integer"
+                },
+                "ticket": {
+                    "type": "string"
+                }
+            }
+        },
         "middleware_chat.ErrorResponse": {
             "type": "object",
             "properties": {
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"chat_service/http/api_dto"
+	"chat_service/internal/helpers"
+	"chat_service/internal/websocket/ticket"
+	"chat_service/middleware_chat"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type WSTicketHandler struct {
+	log     *logrus.Logger
+	tickets ticket.Store
+}
+
+func NewWSTicketHandler(log *logrus.Logger, tickets ticket.Store) *WSTicketHandler {
+	if log == nil {
+		log = logrus.New()
+		log.SetFormatter(&logrus.JSONFormatter{})
+		log.SetOutput(os.Stdout)
+		log.SetLevel(logrus.DebugLevel)
+	}
+	return &WSTicketHandler{
+		log:     log,
+		tickets: tickets,
+	}
+}
+
+// IssueTicket
+// @Summary Билет для подключения к WebSocket
+// @Description Выдает одноразовый билет для /ws?ticket=..., действует 30 секунд. Нужен браузерам, которые не могут передать заголовок Authorization при открытии WebSocket
+// @Tags WebSocket
+// @Security BearerAuth
+// @Produce json
+// @Success 201 {object} api_dto.WSTicketResponse "Билет"
+// @Failure 401 {object} middleware_chat.ErrorResponse "Неверные учетные данные"
+// @Failure 500 {object} middleware_chat.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /ws/ticket [post]
+func (h *WSTicketHandler) IssueTicket(ctx *gin.Context) {
+	userId, err := helpers.GetUserIdFromContext(ctx)
+	if err != nil {
+		middleware_chat.HandleError(ctx, middleware_chat.NewCustomError(http.StatusUnauthorized, "Unauthorized", err), h.log)
+		return
+	}
+
+	t, err := h.tickets.Issue(ctx, ticket.Claims{
+		UserId:    userId,
+		ExpiresAt: ctx.GetInt64("token_expires_at"),
+		SessionId: ctx.GetInt64("session_id"),
+	})
+	if err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn("Error issuing ws ticket")
+		middleware_chat.HandleError(ctx, err, h.log)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, api_dto.WSTicketResponse{
+		Ticket:    t,
+		ExpiresIn: int(ticket.TTL.Seconds()),
+	})
+}
@@ -23,3 +23,13 @@ func (a *GrpcAuthz) CanSendDirect(ctx context.Context, fromUserId, toUserId int6
 
 	return resp.Allowed, nil
 }
+
+// CanViewLastSeen возвращает пользователей, чей last seen разрешено показать viewerId.
+func (a *GrpcAuthz) CanViewLastSeen(ctx context.Context, viewerId int64, userIds []int64) ([]int64, error) {
+	resp, err := a.profileClient.CanViewLastSeen(ctx, viewerId, userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.AllowedUserIds, nil
+}
@@ -4,4 +4,5 @@ import "context"
 
 type AuthServiceInterface interface {
 	CanSendDirect(ctx context.Context, fromUserId, toUserId int64) (bool, error)
+	CanViewLastSeen(ctx context.Context, viewerId int64, userIds []int64) ([]int64, error)
 }
@@ -11,6 +11,8 @@ type RedisConfig struct {
 	Password      string
 	RedisDb       int
 	IdleThreshold time.Duration
+	// LastSeenTTL ограничивает хранение last_seen и истории сессий; 0 — бессрочно.
+	LastSeenTTL time.Duration
 }
 
 func RedisCfgLoad() (*RedisConfig, error) {
@@ -29,6 +31,7 @@ func RedisCfgLoad() (*RedisConfig, error) {
 		Password:      os.Getenv("REDIS_PASSWORD"),
 		RedisDb:       toInt("REDIS_DB"),
 		IdleThreshold: toDuration("IDLE_THRESHOLD"),
+		LastSeenTTL:   toDuration("LAST_SEEN_TTL"),
 	}
 	return config, nil
 }
@@ -4,6 +4,7 @@ import (
 	"chat_service/internal/presence/repository/repo_dto"
 	"context"
 	_ "embed"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"time"
@@ -17,38 +18,58 @@ var addConnectionLua string
 //go:embed scripts/touchConnection.lua
 var touchConnectionLua string
 
+//go:embed scripts/touchActivity.lua
+var touchActivityLua string
+
 //go:embed scripts/removeConnection.lua
 var removeConnectionLua string
 
 //go:embed scripts/cleanupDanglingConnections.lua
 var cleanupDanglingConnectionsLua string
 
+//go:embed scripts/getPresences.lua
+var getPresencesLua string
+
+// presenceBatchSize ограничивает число пользователей на один вызов скрипта,
+// чтобы не блокировать Redis на длинных списках.
+const presenceBatchSize = 500
+
+// sessionHistoryLimit — сколько последних сессий хранится на пользователя.
+const sessionHistoryLimit = 50
+
 type redisPresenceRepo struct {
-	rdb *redis.Client
-	ttl time.Duration
+	rdb         *redis.Client
+	ttl         time.Duration
+	lastSeenTTL time.Duration
 
 	addConnScript     *redis.Script
 	touchConnScript   *redis.Script
+	activityScript    *redis.Script
 	removeConnScript  *redis.Script
 	cleanupConnScript *redis.Script
+	presencesScript   *redis.Script
 }
 
 func NewPresenceRepo(
 	rdb *redis.Client,
 	connectionTTL time.Duration,
+	lastSeenTTL time.Duration,
 ) PresenceRepo {
 	return &redisPresenceRepo{
-		rdb: rdb,
-		ttl: connectionTTL,
+		rdb:         rdb,
+		ttl:         connectionTTL,
+		lastSeenTTL: lastSeenTTL,
 
 		addConnScript:     redis.NewScript(addConnectionLua),
 		touchConnScript:   redis.NewScript(touchConnectionLua),
+		activityScript:    redis.NewScript(touchActivityLua),
 		removeConnScript:  redis.NewScript(removeConnectionLua),
 		cleanupConnScript: redis.NewScript(cleanupDanglingConnectionsLua),
+		presencesScript:   redis.NewScript(getPresencesLua),
 	}
 }
 
-func (r *redisPresenceRepo) AddConnection(ctx context.Context, userId, connId int64, device string) error {
+func (r *redisPresenceRepo) AddConnection(ctx context.Context, userId, connId int64, device, appVersion string) error {
 	now := time.Now().UnixMilli()
 
 	_, err := r.addConnScript.Run(ctx, r.rdb,
@@ -56,27 +77,29 @@ func (r *redisPresenceRepo) AddConnection(ctx context.Context, userId, connId in
 			connKey(connId),
 			userConnSetKey(userId),
 		}, userId, connId, device, now,
-		int(r.ttl.Seconds()),
+		int(r.ttl.Seconds()), appVersion,
 	).Result()
 
 	return err
 }
 
 func (r *redisPresenceRepo) RemoveConnection(ctx context.Context, userId, connId int64) error {
+	now := time.Now().UnixMilli()
+
 	_, err := r.removeConnScript.Run(ctx, r.rdb,
 		[]string{
 			connKey(connId),
 			userConnSetKey(userId),
-		}, connId,
+			sessionHistoryKey(userId),
+		}, connId, now, sessionHistoryLimit,
+		int(r.lastSeenTTL.Seconds()),
 	).Result()
 
 	return err
 }
 
 func (r *redisPresenceRepo) TouchConnection(ctx context.Context, connId int64) error {
-	now := time.Now().UnixMilli()
-
-	res, err := r.touchConnScript.Run(ctx, r.rdb, []string{connKey(connId)}, now, int(r.ttl.Seconds())).Int()
+	res, err := r.touchConnScript.Run(ctx, r.rdb, []string{connKey(connId)}, int(r.ttl.Seconds())).Int()
 
 	if err != nil {
 		return err
@@ -89,40 +112,111 @@ func (r *redisPresenceRepo) TouchConnection(ctx context.Context, connId int64) e
 	return nil
 }
 
+func (r *redisPresenceRepo) TouchActivity(ctx context.Context, userId, connId int64) (bool, error) {
+	now := time.Now().UnixMilli()
+
+	res, err := r.activityScript.Run(ctx, r.rdb,
+		[]string{
+			connKey(connId),
+			idleKey(userId),
+		}, now, int(r.ttl.Seconds()),
+	).Int()
+
+	if err != nil {
+		return false, err
+	}
+
+	if res < 0 {
+		return false, redis.Nil
+	}
+
+	return res == 1, nil
+}
+
+// SetIdle ставит флаг idle с TTL соединения: повторные проверки продлевают его,
+// а после падения инстанса флаг истекает вместе с соединениями.
+func (r *redisPresenceRepo) SetIdle(ctx context.Context, userId int64) (bool, error) {
+	changed, err := r.rdb.SetNX(ctx, idleKey(userId), 1, r.ttl).Result()
+	if err != nil || changed {
+		return changed, err
+	}
+	return false, r.rdb.Expire(ctx, idleKey(userId), r.ttl).Err()
+}
+
+func (r *redisPresenceRepo) ClearIdle(ctx context.Context, userId int64) (bool, error) {
+	n, err := r.rdb.Del(ctx, idleKey(userId)).Result()
+	return n > 0, err
+}
+
+func (r *redisPresenceRepo) SetHandoff(ctx context.Context, userId int64, ttl time.Duration) error {
+	return r.rdb.Set(ctx, handoffKey(userId), 1, ttl).Err()
+}
+
+func (r *redisPresenceRepo) ClearHandoff(ctx context.Context, userId int64) (bool, error) {
+	n, err := r.rdb.Del(ctx, handoffKey(userId)).Result()
+	return n > 0, err
+}
+
 func (r *redisPresenceRepo) GetUserConnections(ctx context.Context, userId int64) ([]repo_dto.Connection, error) {
-	connIds, err := r.rdb.SMembers(ctx, userConnSetKey(userId)).Result()
+	presences, err := r.GetPresences(ctx, []int64{userId})
 	if err != nil {
 		return nil, err
 	}
 
-	if len(connIds) == 0 {
-		return nil, nil
-	}
+	return presences[0].Connections, nil
+}
 
-	var result []repo_dto.Connection
+func (r *redisPresenceRepo) GetPresences(ctx context.Context, userIds []int64) ([]repo_dto.UserPresence, error) {
+	result := make([]repo_dto.UserPresence, 0, len(userIds))
 
-	for _, connIdStr := range connIds {
-		connId, err := strconv.ParseInt(connIdStr, 10, 64)
+	for start := 0; start < len(userIds); start += presenceBatchSize {
+		end := min(start+presenceBatchSize, len(userIds))
+
+		batch, err := r.getPresencesBatch(ctx, userIds[start:end])
 		if err != nil {
-			continue
+			return nil, err
 		}
+		result = append(result, batch...)
+	}
+
+	return result, nil
+}
+
+func (r *redisPresenceRepo) getPresencesBatch(ctx context.Context, userIds []int64) ([]repo_dto.UserPresence, error) {
+	keys := make([]string, 0, len(userIds)*2)
+	for _, userId := range userIds {
+		keys = append(keys, userConnSetKey(userId), lastSeenKey(userId))
+	}
 
-		data, err := r.rdb.HGetAll(ctx, connKey(connId)).Result()
-		if err != nil || len(data) == 0 {
+	raw, err := r.presencesScript.Run(ctx, r.rdb, keys).Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) != len(userIds) {
+		return nil, fmt.Errorf("presence script returned %d entries for %d users", len(raw), len(userIds))
+	}
+
+	result := make([]repo_dto.UserPresence, len(userIds))
+
+	for i, userId := range userIds {
+		result[i] = repo_dto.UserPresence{UserId: userId}
+
+		entry, ok := raw[i].([]interface{})
+		if !ok || len(entry) != 2 {
 			continue
 		}
 
-		connectedAtMs, _ := strconv.ParseInt(data["connected_at"], 10, 64)
-		lastActivityMs, _ := strconv.ParseInt(data["last_activity"], 10, 64)
-		userIdParsed, _ := strconv.ParseInt(data["user_id"], 10, 64)
+		if lastSeen, _ := strconv.ParseInt(toString(entry[0]), 10, 64); lastSeen > 0 {
+			result[i].LastSeen = time.Unix(lastSeen, 0)
+		}
 
-		result = append(result, repo_dto.Connection{
-			ConnId:       connId,
-			UserId:       userIdParsed,
-			Device:       data["device"],
-			ConnectedAt:  time.UnixMilli(connectedAtMs),
-			LastActivity: time.UnixMilli(lastActivityMs),
-		})
+		conns, _ := entry[1].([]interface{})
+		for _, c := range conns {
+			if conn, ok := parseConnection(c); ok {
+				result[i].Connections = append(result[i].Connections, conn)
+			}
+		}
 	}
 
 	return result, nil
@@ -135,11 +229,11 @@ func (r *redisPresenceRepo) CleanupDanglingConnections(ctx context.Context, user
 }
 
 func (r *redisPresenceRepo) SetLastSeen(ctx context.Context, userID int64, t time.Time) error {
-	return r.rdb.Set(ctx, fmt.Sprintf("last_seen:%d", userID), t.Unix(), 0).Err()
+	return r.rdb.Set(ctx, lastSeenKey(userID), t.Unix(), r.lastSeenTTL).Err()
 }
 
 func (r *redisPresenceRepo) GetLastSeen(ctx context.Context, userID int64) (time.Time, error) {
-	val, err := r.rdb.Get(ctx, fmt.Sprintf("last_seen:%d", userID)).Int64()
+	val, err := r.rdb.Get(ctx, lastSeenKey(userID)).Int64()
 	if err == redis.Nil {
 		return time.Time{}, nil
 	}
@@ -149,6 +243,35 @@ func (r *redisPresenceRepo) GetLastSeen(ctx context.Context, userID int64) (time
 	return time.Unix(val, 0), nil
 }
 
+func (r *redisPresenceRepo) GetSessionHistory(ctx context.Context, userId int64) ([]repo_dto.Session, error) {
+	raw, err := r.rdb.LRange(ctx, sessionHistoryKey(userId), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]repo_dto.Session, 0, len(raw))
+	for _, item := range raw {
+		var s struct {
+			Device         string `json:"device"`
+			AppVersion     string `json:"app_version"`
+			ConnectedAt    int64  `json:"connected_at"`
+			DisconnectedAt int64  `json:"disconnected_at"`
+		}
+		if err := json.Unmarshal([]byte(item), &s); err != nil {
+			continue
+		}
+
+		sessions = append(sessions, repo_dto.Session{
+			Device:         s.Device,
+			AppVersion:     s.AppVersion,
+			ConnectedAt:    time.UnixMilli(s.ConnectedAt),
+			DisconnectedAt: time.UnixMilli(s.DisconnectedAt),
+		})
+	}
+
+	return sessions, nil
+}
+
 func connKey(connId int64) string {
 	return fmt.Sprintf("conn:%d", connId)
 }
@@ -156,3 +279,61 @@ func connKey(connId int64) string {
 func userConnSetKey(userId int64) string {
 	return fmt.Sprintf("user:%d:conns", userId)
 }
+
+func lastSeenKey(userId int64) string {
+	return fmt.Sprintf("last_seen:%d", userId)
+}
+
+func sessionHistoryKey(userId int64) string {
+	return fmt.Sprintf("user:%d:sessions", userId)
+}
+
+// handoffKey помечает пользователя, чьи соединения закрыты при drain инстанса:
+// пока ключ жив, переподключение к другому инстансу не считается новым online.
+func handoffKey(userId int64) string {
+	return fmt.Sprintf("user:%d:handoff", userId)
+}
+
+// idleKey — флаг «о переходе в idle уже сообщили», чтобы события
+// user_idle / user_active публиковались один раз на смену состояния.
+func idleKey(userId int64) string {
+	return fmt.Sprintf("user:%d:idle", userId)
+}
+
+// parseConnection разбирает элемент {connId, userId, device, connectedAt, lastActivity, appVersion},
+// возвращаемый getPresences.lua.
+func parseConnection(raw interface{}) (repo_dto.Connection, bool) {
+	fields, ok := raw.([]interface{})
+	if !ok || len(fields) < 6 {
+		return repo_dto.Connection{}, false
+	}
+
+	connId, err := strconv.ParseInt(toString(fields[0]), 10, 64)
+	if err != nil {
+		return repo_dto.Connection{}, false
+	}
+
+	userId, _ := strconv.ParseInt(toString(fields[1]), 10, 64)
+	connectedAtMs, _ := strconv.ParseInt(toString(fields[3]), 10, 64)
+	lastActivityMs, _ := strconv.ParseInt(toString(fields[4]), 10, 64)
+
+	return repo_dto.Connection{
+		ConnId:       connId,
+		UserId:       userId,
+		Device:       toString(fields[2]),
+		AppVersion:   toString(fields[5]),
+		ConnectedAt:  time.UnixMilli(connectedAtMs),
+		LastActivity: time.UnixMilli(lastActivityMs),
+	}, true
+}
+
+func toString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case int64:
+		return strconv.FormatInt(val, 10)
+	default:
+		return ""
+	}
+}
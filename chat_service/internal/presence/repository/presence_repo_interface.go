@@ -7,13 +7,23 @@ import (
 )
 
 type PresenceRepo interface {
-	AddConnection(ctx context.Context, userId, connId int64, device string) error
+	AddConnection(ctx context.Context, userId, connId int64, device, appVersion string) error
 	RemoveConnection(ctx context.Context, userId, connId int64) error
 	TouchConnection(ctx context.Context, connId int64) error
+	TouchActivity(ctx context.Context, userId, connId int64) (bool, error)
+
+	SetIdle(ctx context.Context, userId int64) (bool, error)
+	ClearIdle(ctx context.Context, userId int64) (bool, error)
+
+	SetHandoff(ctx context.Context, userId int64, ttl time.Duration) error
+	ClearHandoff(ctx context.Context, userId int64) (bool, error)
 
 	GetUserConnections(ctx context.Context, userId int64) ([]repo_dto.Connection, error)
+	GetPresences(ctx context.Context, userIds []int64) ([]repo_dto.UserPresence, error)
 	CleanupDanglingConnections(ctx context.Context, userId int64) error
 
 	SetLastSeen(ctx context.Context, userID int64, t time.Time) error
 	GetLastSeen(ctx context.Context, userID int64) (time.Time, error)
+
+	GetSessionHistory(ctx context.Context, userId int64) ([]repo_dto.Session, error)
 }
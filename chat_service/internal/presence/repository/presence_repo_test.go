@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	benchUsers           = 500
+	benchConnsPerUser    = 2
+	benchConnectionTTL   = time.Minute
+	benchOfflineEveryNth = 3
+)
+
+// roundTripCounter считает запросы к Redis (пайплайн — один запрос).
+type roundTripCounter struct {
+	n atomic.Int64
+}
+
+func (c *roundTripCounter) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (c *roundTripCounter) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		c.n.Add(1)
+		return next(ctx, cmd)
+	}
+}
+
+func (c *roundTripCounter) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		c.n.Add(1)
+		return next(ctx, cmds)
+	}
+}
+
+// newTestRepo поднимает miniredis. Для замеров на настоящем Redis задайте
+// PRESENCE_BENCH_REDIS_ADDR — будет использована (и очищена) БД 15.
+func newTestRepo(tb testing.TB) (*redisPresenceRepo, *roundTripCounter) {
+	tb.Helper()
+
+	opts := &redis.Options{}
+	if addr := os.Getenv("PRESENCE_BENCH_REDIS_ADDR"); addr != "" {
+		opts.Addr = addr
+		opts.DB = 15
+	} else {
+		opts.Addr = miniredis.RunT(tb).Addr()
+	}
+
+	rdb := redis.NewClient(opts)
+	tb.Cleanup(func() { _ = rdb.Close() })
+
+	if err := rdb.FlushDB(context.Background()).Err(); err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			tb.Skipf("redis unavailable: %v", err)
+		}
+		tb.Fatal(err)
+	}
+
+	counter := &roundTripCounter{}
+	rdb.AddHook(counter)
+
+	return NewPresenceRepo(rdb, benchConnectionTTL, 0).(*redisPresenceRepo), counter
+}
+
+// seedUsers создаёт n пользователей: каждый benchOfflineEveryNth — offline с last_seen,
+// остальные с benchConnsPerUser соединениями.
+func seedUsers(tb testing.TB, r *redisPresenceRepo, n int) []int64 {
+	tb.Helper()
+	ctx := context.Background()
+
+	userIds := make([]int64, 0, n)
+	for i := 1; i <= n; i++ {
+		userId := int64(i)
+		userIds = append(userIds, userId)
+
+		if i%benchOfflineEveryNth == 0 {
+			if err := r.SetLastSeen(ctx, userId, time.Now()); err != nil {
+				tb.Fatal(err)
+			}
+			continue
+		}
+
+		for c := 0; c < benchConnsPerUser; c++ {
+			connId := userId*100 + int64(c)
+			if err := r.AddConnection(ctx, userId, connId, "web", "1.0.0"); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+
+	return userIds
+}
+
+func TestGetPresences(t *testing.T) {
+	r, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	userIds := seedUsers(t, r, 10)
+	userIds = append(userIds, 999) // неизвестный пользователь
+
+	presences, err := r.GetPresences(ctx, userIds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(presences) != len(userIds) {
+		t.Fatalf("expected %d presences, got %d", len(userIds), len(presences))
+	}
+
+	for i, p := range presences {
+		if p.UserId != userIds[i] {
+			t.Fatalf("presence %d: expected user %d, got %d", i, userIds[i], p.UserId)
+		}
+
+		switch {
+		case p.UserId == 999:
+			if len(p.Connections) != 0 || !p.LastSeen.IsZero() {
+				t.Errorf("unknown user: unexpected presence %+v", p)
+			}
+		case p.UserId%benchOfflineEveryNth == 0:
+			if len(p.Connections) != 0 {
+				t.Errorf("user %d: expected no connections, got %d", p.UserId, len(p.Connections))
+			}
+			if p.LastSeen.IsZero() {
+				t.Errorf("user %d: expected last_seen", p.UserId)
+			}
+		default:
+			if len(p.Connections) != benchConnsPerUser {
+				t.Errorf("user %d: expected %d connections, got %d", p.UserId, benchConnsPerUser, len(p.Connections))
+			}
+			for _, c := range p.Connections {
+				if c.UserId != p.UserId || c.Device != "web" || c.AppVersion != "1.0.0" || c.LastActivity.IsZero() {
+					t.Errorf("user %d: unexpected connection %+v", p.UserId, c)
+				}
+			}
+		}
+	}
+}
+
+func TestGetPresencesChunked(t *testing.T) {
+	r, _ := newTestRepo(t)
+
+	userIds := seedUsers(t, r, presenceBatchSize+7)
+
+	presences, err := r.GetPresences(context.Background(), userIds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(presences) != len(userIds) {
+		t.Fatalf("expected %d presences, got %d", len(userIds), len(presences))
+	}
+	if last := presences[len(presences)-1]; last.UserId != userIds[len(userIds)-1] {
+		t.Fatalf("expected last user %d, got %d", userIds[len(userIds)-1], last.UserId)
+	}
+}
+
+func TestHeartbeatDoesNotCountAsActivity(t *testing.T) {
+	r, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	if err := r.AddConnection(ctx, 1, 100, "web", "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := r.GetUserConnections(ctx, 1)
+
+	time.Sleep(5 * time.Millisecond)
+
+	if err := r.TouchConnection(ctx, 100); err != nil {
+		t.Fatal(err)
+	}
+	afterHeartbeat, _ := r.GetUserConnections(ctx, 1)
+	if !afterHeartbeat[0].LastActivity.Equal(before[0].LastActivity) {
+		t.Fatalf("heartbeat changed last_activity: %v -> %v", before[0].LastActivity, afterHeartbeat[0].LastActivity)
+	}
+
+	if _, err := r.TouchActivity(ctx, 1, 100); err != nil {
+		t.Fatal(err)
+	}
+	afterActivity, _ := r.GetUserConnections(ctx, 1)
+	if !afterActivity[0].LastActivity.After(before[0].LastActivity) {
+		t.Fatalf("activity did not advance last_activity")
+	}
+}
+
+func TestIdleFlag(t *testing.T) {
+	r, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	if err := r.AddConnection(ctx, 1, 100, "web", "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if changed, err := r.SetIdle(ctx, 1); err != nil || !changed {
+		t.Fatalf("first SetIdle: changed=%v err=%v", changed, err)
+	}
+	if changed, _ := r.SetIdle(ctx, 1); changed {
+		t.Fatal("second SetIdle must not report a transition")
+	}
+	// флаг не должен пережить соединения, если offline так и не будет объявлен
+	if ttl := r.rdb.TTL(ctx, idleKey(1)).Val(); ttl <= 0 || ttl > r.ttl {
+		t.Fatalf("idle flag must expire with presence TTL, got %v", ttl)
+	}
+
+	if wasIdle, err := r.TouchActivity(ctx, 1, 100); err != nil || !wasIdle {
+		t.Fatalf("activity after idle: wasIdle=%v err=%v", wasIdle, err)
+	}
+	if wasIdle, _ := r.TouchActivity(ctx, 1, 100); wasIdle {
+		t.Fatal("repeated activity must not report a transition")
+	}
+
+	if _, err := r.TouchActivity(ctx, 1, 404); err != redis.Nil {
+		t.Fatalf("expected redis.Nil for unknown connection, got %v", err)
+	}
+}
+
+func TestSessionHistoryIsBounded(t *testing.T) {
+	r, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	for i := 0; i < sessionHistoryLimit+5; i++ {
+		connId := int64(100 + i)
+		if err := r.AddConnection(ctx, 1, connId, "mobile", "2.0.0"); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.RemoveConnection(ctx, 1, connId); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// соединение, которого уже нет в Redis, в историю не попадает
+	if err := r.RemoveConnection(ctx, 1, 999); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := r.GetSessionHistory(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != sessionHistoryLimit {
+		t.Fatalf("expected %d sessions, got %d", sessionHistoryLimit, len(sessions))
+	}
+
+	s := sessions[0]
+	if s.Device != "mobile" || s.AppVersion != "2.0.0" || s.ConnectedAt.IsZero() || s.DisconnectedAt.Before(s.ConnectedAt) {
+		t.Fatalf("unexpected session %+v", s)
+	}
+}
+
+func TestHandoffFlag(t *testing.T) {
+	r, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	if err := r.SetHandoff(ctx, 1, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if pending, err := r.ClearHandoff(ctx, 1); err != nil || !pending {
+		t.Fatalf("first ClearHandoff: pending=%v err=%v", pending, err)
+	}
+	// снять флаг может только один — OnConnect на новом инстансе или FinishHandoff
+	if pending, _ := r.ClearHandoff(ctx, 1); pending {
+		t.Fatal("handoff must be cleared only once")
+	}
+}
+
+// BenchmarkGetPresencesPerUser воспроизводит прежний подход: отдельные запросы на каждого пользователя.
+func BenchmarkGetPresencesPerUser(b *testing.B) {
+	r, counter := newTestRepo(b)
+	ctx := context.Background()
+	userIds := seedUsers(b, r, benchUsers)
+
+	counter.n.Store(0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, userId := range userIds {
+			conns, err := r.rdb.SMembers(ctx, userConnSetKey(userId)).Result()
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, connId := range conns {
+				if err := r.rdb.HGetAll(ctx, "conn:"+connId).Err(); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if len(conns) == 0 {
+				if _, err := r.GetLastSeen(ctx, userId); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+	b.ReportMetric(float64(counter.n.Load())/float64(b.N), "roundtrips/op")
+}
+
+func BenchmarkGetPresencesBatch(b *testing.B) {
+	r, counter := newTestRepo(b)
+	ctx := context.Background()
+	userIds := seedUsers(b, r, benchUsers)
+
+	counter.n.Store(0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.GetPresences(ctx, userIds); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(counter.n.Load())/float64(b.N), "roundtrips/op")
+}
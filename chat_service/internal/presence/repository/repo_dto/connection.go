@@ -6,6 +6,21 @@ type Connection struct {
 	ConnId       int64
 	UserId       int64
 	Device       string
+	AppVersion   string
 	ConnectedAt  time.Time
 	LastActivity time.Time
 }
+
+// Session — завершённое соединение из истории пользователя.
+type Session struct {
+	Device         string
+	AppVersion     string
+	ConnectedAt    time.Time
+	DisconnectedAt time.Time
+}
+
+type UserPresence struct {
+	UserId      int64
+	Connections []Connection
+	LastSeen    time.Time
+}
@@ -8,10 +8,12 @@
 -- 3 = device
 -- 4 = nowMs
 -- 5 = ttlSec
+-- 6 = appVersion
 
 redis.call('HSET', KEYS[1],
   'user_id', ARGV[1],
   'device', ARGV[3],
+  'app_version', ARGV[6],
   'connected_at', ARGV[4],
   'last_activity', ARGV[4]
 )
@@ -0,0 +1,26 @@
+-- KEYS
+-- 2i-1 = userConnSet (i-го пользователя)
+-- 2i   = lastSeenKey (i-го пользователя)
+
+-- RETURNS
+-- { { lastSeen, { {connId, userId, device, connectedAt, lastActivity, appVersion}, ... } }, ... }
+
+local result = {}
+
+for i = 1, #KEYS, 2 do
+    local conns = {}
+    local connIds = redis.call('SMEMBERS', KEYS[i])
+
+    for _, connId in ipairs(connIds) do
+        local data = redis.call('HMGET', 'conn:' .. connId,
+            'user_id', 'device', 'connected_at', 'last_activity', 'app_version')
+        if data[1] then
+            table.insert(conns, { connId, data[1], data[2] or '', data[3] or '0', data[4] or '0', data[5] or '' })
+        end
+    end
+
+    local lastSeen = redis.call('GET', KEYS[i + 1]) or ''
+    table.insert(result, { lastSeen, conns })
+end
+
+return result
@@ -1,11 +1,31 @@
 -- KEYS
 -- 1 = connKey
 -- 2 = userConnSet
+-- 3 = sessionHistory
 
 -- ARGV
 -- 1 = connId
+-- 2 = nowMs
+-- 3 = historyLimit
+-- 4 = historyTtlSec (0 = без TTL)
+
+local conn = redis.call('HMGET', KEYS[1], 'device', 'app_version', 'connected_at')
 
 redis.call('DEL', KEYS[1])
 redis.call('SREM', KEYS[2], ARGV[1])
 
+if conn[3] then
+    redis.call('LPUSH', KEYS[3], cjson.encode({
+        device = conn[1] or '',
+        app_version = conn[2] or '',
+        connected_at = tonumber(conn[3]),
+        disconnected_at = tonumber(ARGV[2])
+    }))
+    redis.call('LTRIM', KEYS[3], 0, tonumber(ARGV[3]) - 1)
+
+    if tonumber(ARGV[4]) > 0 then
+        redis.call('EXPIRE', KEYS[3], ARGV[4])
+    end
+end
+
 return 1
@@ -0,0 +1,21 @@
+-- KEYS
+-- 1 = connKey
+-- 2 = idleKey
+
+-- ARGV
+-- 1 = nowMs
+-- 2 = ttlSec
+
+-- RETURN
+-- -1 = соединение не найдено
+--  0 = активность обновлена
+--  1 = активность обновлена, пользователь вышел из idle
+
+if redis.call('EXISTS', KEYS[1]) == 0 then
+    return -1
+end
+
+redis.call('HSET', KEYS[1], 'last_activity', ARGV[1])
+redis.call('EXPIRE', KEYS[1], ARGV[2])
+
+return redis.call('DEL', KEYS[2])
@@ -2,12 +2,12 @@
 -- 1 = connKey
 
 -- ARGV
--- 1 = nowMs
--- 2 = ttlSec
+-- 1 = ttlSec
 
+-- Только продлевает TTL: pong подтверждает живость транспорта,
+-- но не является действием пользователя.
 if redis.call('EXISTS', KEYS[1]) == 1 then
-    redis.call('HSET', KEYS[1], 'last_activity', ARGV[1])
-    redis.call('EXPIRE', KEYS[1], ARGV[2])
+    redis.call('EXPIRE', KEYS[1], ARGV[1])
     return 1
 end
 
@@ -10,8 +10,65 @@ const (
 	Idle    PresenceStatus = "idle"
 )
 
+type DeviceType string
+
+const (
+	DeviceWeb     DeviceType = "web"
+	DeviceDesktop DeviceType = "desktop"
+	DeviceMobile  DeviceType = "mobile"
+)
+
+// ParseDevice приводит тип устройства из рукопожатия к известному значению.
+// Неизвестные и пустые значения считаются web-клиентом.
+func ParseDevice(device string) DeviceType {
+	switch DeviceType(device) {
+	case DeviceDesktop, DeviceMobile:
+		return DeviceType(device)
+	default:
+		return DeviceWeb
+	}
+}
+
+// MaxAppVersionLen — версия клиента хранится в presence каждого соединения,
+// поэтому её длина ограничена.
+const MaxAppVersionLen = 32
+
+// ParseAppVersion пропускает версию клиента вида "1.2.3", "2.0.0-beta+42".
+// Слишком длинные значения и значения с другими символами отбрасываются.
+func ParseAppVersion(version string) string {
+	if len(version) > MaxAppVersionLen {
+		return ""
+	}
+	for _, r := range version {
+		switch {
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r == '.', r == '-', r == '+', r == '_':
+		default:
+			return ""
+		}
+	}
+	return version
+}
+
 type Presence struct {
 	UserId   int64
 	Status   PresenceStatus
 	LastSeen time.Time
+	Devices  []DevicePresence
+}
+
+// DevicePresence — статус пользователя на одном типе устройства.
+// Несколько соединений с одного типа устройства схлопываются в одно.
+type DevicePresence struct {
+	Device       DeviceType
+	AppVersion   string
+	Status       PresenceStatus
+	LastActivity time.Time
+}
+
+type Session struct {
+	Device         DeviceType
+	AppVersion     string
+	ConnectedAt    time.Time
+	DisconnectedAt time.Time
 }
@@ -1,25 +1,52 @@
 package service
 
-import "sync"
+import (
+	"chat_service/internal/pubsub"
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+// presenceChannel — события presence всех инстансов чата.
+const presenceChannel = "presence.events"
 
 type PresenceSubscriber chan PresenceEvent
 
 type PresenceEventBus struct {
-	mu          sync.RWMutex
-	subscribers map[PresenceSubscriber]struct{}
+	mu sync.RWMutex
+	// значение — закрывать ли подписчика при переполнении буфера
+	subscribers map[PresenceSubscriber]bool
+
+	pub        pubsub.PubSub
+	instanceId string
 }
 
 func NewPresenceEventBus() *PresenceEventBus {
 	return &PresenceEventBus{
-		subscribers: make(map[PresenceSubscriber]struct{}),
+		subscribers: make(map[PresenceSubscriber]bool),
 	}
 }
 
+// Subscribe подписывает на события без гарантии доставки: при переполнении
+// буфера события пропускаются.
 func (b *PresenceEventBus) Subscribe() PresenceSubscriber {
-	ch := make(PresenceSubscriber, 16)
+	return b.subscribe(16, false)
+}
+
+// SubscribeWithBuffer нужен подписчикам, которым нельзя терять события
+// (например, gRPC-стримам, по которым инвалидируются внешние кэши).
+// Если буфер переполнился, подписчик отписывается и его канал закрывается:
+// пропуск события он должен обработать как обрыв подписки.
+func (b *PresenceEventBus) SubscribeWithBuffer(size int) PresenceSubscriber {
+	return b.subscribe(size, true)
+}
+
+func (b *PresenceEventBus) subscribe(size int, closeOnOverflow bool) PresenceSubscriber {
+	ch := make(PresenceSubscriber, size)
 
 	b.mu.Lock()
-	b.subscribers[ch] = struct{}{}
+	b.subscribers[ch] = closeOnOverflow
 	b.mu.Unlock()
 
 	return ch
@@ -27,19 +54,86 @@ func (b *PresenceEventBus) Subscribe() PresenceSubscriber {
 
 func (b *PresenceEventBus) Unsubscribe(ch PresenceSubscriber) {
 	b.mu.Lock()
-	delete(b.subscribers, ch)
-	close(ch)
+	defer b.mu.Unlock()
+
+	// канал мог быть уже закрыт из-за переполнения
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// Relay связывает шины всех инстансов через pub/sub: события этого инстанса
+// рассылаются остальным, а их события доставляются локальным подписчикам.
+// Без него подписчики видят только пользователей, подключённых к этому инстансу.
+func (b *PresenceEventBus) Relay(ctx context.Context, pub pubsub.PubSub, instanceId string) error {
+	ch, err := pub.Subscribe(ctx, presenceChannel)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.pub = pub
+	b.instanceId = instanceId
 	b.mu.Unlock()
+
+	go func() {
+		for raw := range ch {
+			var evt pubsub.RedisEvent
+			if err := json.Unmarshal(raw, &evt); err != nil || evt.InstanceId == instanceId {
+				continue
+			}
+
+			var event PresenceEvent
+			if err := json.Unmarshal(evt.Data, &event); err != nil {
+				continue
+			}
+			b.deliver(event)
+		}
+	}()
+
+	return nil
 }
 
 func (b *PresenceEventBus) Publish(event PresenceEvent) {
+	b.deliver(event)
+
 	b.mu.RLock()
-	defer b.mu.RUnlock()
+	pub, instanceId := b.pub, b.instanceId
+	b.mu.RUnlock()
 
-	for sub := range b.subscribers {
+	if pub == nil {
+		return
+	}
+
+	data, _ := json.Marshal(event)
+	raw, _ := json.Marshal(pubsub.RedisEvent{
+		Type:       string(event.Type),
+		InstanceId: instanceId,
+		Data:       data,
+	})
+	if err := pub.Publish(context.Background(), presenceChannel, raw); err != nil {
+		log.Printf("[presence] relay publish failed: %v", err)
+	}
+}
+
+func (b *PresenceEventBus) deliver(event PresenceEvent) {
+	var overflowed []PresenceSubscriber
+
+	b.mu.RLock()
+	for sub, closeOnOverflow := range b.subscribers {
 		select {
 		case sub <- event:
 		default:
+			if closeOnOverflow {
+				overflowed = append(overflowed, sub)
+			}
 		}
 	}
+	b.mu.RUnlock()
+
+	for _, sub := range overflowed {
+		log.Printf("[presence] subscriber buffer overflow, closing subscription")
+		b.Unsubscribe(sub)
+	}
 }
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBusClosesLosslessSubscriberOnOverflow(t *testing.T) {
+	bus := NewPresenceEventBus()
+	lossy := bus.Subscribe()
+	strict := bus.SubscribeWithBuffer(1)
+
+	bus.Publish(PresenceEvent{Type: EventUserOnline, UserId: 1})
+	bus.Publish(PresenceEvent{Type: EventUserOffline, UserId: 1})
+
+	if evt := <-strict; evt.Type != EventUserOnline {
+		t.Fatalf("expected first event, got %v", evt)
+	}
+	if _, ok := <-strict; ok {
+		t.Fatal("subscriber that lost an event must be closed")
+	}
+	// повторная отписка не должна паниковать на закрытом канале
+	bus.Unsubscribe(strict)
+
+	if len(lossy) != 2 {
+		t.Fatalf("lossy subscriber must stay subscribed, got %d events", len(lossy))
+	}
+}
+
+// memPubSub — pub/sub в памяти, общий для нескольких шин.
+type memPubSub struct {
+	subs []chan []byte
+}
+
+func (m *memPubSub) Publish(ctx context.Context, channel string, payload []byte) error {
+	for _, ch := range m.subs {
+		ch <- payload
+	}
+	return nil
+}
+
+func (m *memPubSub) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	ch := make(chan []byte, 8)
+	m.subs = append(m.subs, ch)
+	return ch, nil
+}
+
+func TestBusRelaysEventsBetweenInstances(t *testing.T) {
+	ctx := context.Background()
+	pub := &memPubSub{}
+
+	a, b := NewPresenceEventBus(), NewPresenceEventBus()
+	if err := a.Relay(ctx, pub, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Relay(ctx, pub, "b"); err != nil {
+		t.Fatal(err)
+	}
+	subA, subB := a.Subscribe(), b.Subscribe()
+
+	a.Publish(PresenceEvent{Type: EventUserOnline, UserId: 7})
+
+	select {
+	case evt := <-subB:
+		if evt.UserId != 7 || evt.Type != EventUserOnline {
+			t.Fatalf("unexpected relayed event %v", evt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event was not relayed to the other instance")
+	}
+
+	<-subA
+	select {
+	case evt := <-subA:
+		t.Fatalf("own event delivered twice: %v", evt)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
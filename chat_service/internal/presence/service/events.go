@@ -5,6 +5,8 @@ type PresenceEventType string
 const (
 	EventUserOnline  PresenceEventType = "user_online"
 	EventUserOffline PresenceEventType = "user_offline"
+	EventUserIdle    PresenceEventType = "user_idle"
+	EventUserActive  PresenceEventType = "user_active"
 )
 
 type PresenceEvent struct {
@@ -7,11 +7,16 @@ import (
 	sDto "chat_service/internal/presence/service/dto"
 	"context"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// HandoffGrace — сколько ждать переподключения пользователя к другому инстансу
+// после drain, прежде чем объявить его offline.
+const HandoffGrace = 10 * time.Second
+
 type presenceService struct {
 	repo          repository.PresenceRepo
 	bus           *PresenceEventBus
@@ -30,23 +35,45 @@ func NewPresenceService(
 	}
 }
 
-func (s *presenceService) OnConnect(ctx context.Context, userId, connId int64, device string) error {
-	err := s.repo.AddConnection(ctx, userId, connId, device)
+func (s *presenceService) OnConnect(ctx context.Context, userId, connId int64, device, appVersion string) error {
+	err := s.repo.AddConnection(ctx, userId, connId, string(sDto.ParseDevice(device)), sDto.ParseAppVersion(appVersion))
 	if err != nil {
 		return err
 	}
 
+	// новое соединение — тоже действие пользователя
+	wasIdle, _ := s.repo.ClearIdle(ctx, userId)
+	// переподключение после drain другого инстанса: для подписчиков пользователь не уходил
+	handedOff, _ := s.repo.ClearHandoff(ctx, userId)
+
 	conns, _ := s.repo.GetUserConnections(ctx, userId)
-	if len(conns) == 1 {
+	if len(conns) == 1 && !handedOff {
 		s.bus.Publish(PresenceEvent{
 			Type:   EventUserOnline,
 			UserId: userId,
 		})
+	} else if wasIdle {
+		s.bus.Publish(PresenceEvent{
+			Type:   EventUserActive,
+			UserId: userId,
+		})
 	}
 
 	return nil
 }
 
+// OnResume восстанавливает соединение возобновлённой сессии под прежним connId.
+// Для подписчиков пользователь не уходил, поэтому события не публикуются.
+func (s *presenceService) OnResume(ctx context.Context, userId, connId int64, device, appVersion string) error {
+	err := s.repo.AddConnection(ctx, userId, connId, string(sDto.ParseDevice(device)), sDto.ParseAppVersion(appVersion))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.repo.ClearHandoff(ctx, userId)
+	return err
+}
+
 func (s *presenceService) OnDisconnect(ctx context.Context, userId, connId int64) error {
 	err := s.repo.RemoveConnection(ctx, userId, connId)
 	if err != nil {
@@ -55,16 +82,56 @@ func (s *presenceService) OnDisconnect(ctx context.Context, userId, connId int64
 
 	conns, _ := s.repo.GetUserConnections(ctx, userId)
 	if len(conns) == 0 {
-		s.repo.SetLastSeen(ctx, userId, time.Now())
-		s.bus.Publish(PresenceEvent{
-			Type:   EventUserOffline,
-			UserId: userId,
-		})
+		s.markOffline(ctx, userId)
 	}
 
 	return nil
 }
 
+// OnHandoff снимает соединение, закрытое при drain инстанса. Если оно было
+// последним, offline откладывается до FinishHandoff.
+func (s *presenceService) OnHandoff(ctx context.Context, userId, connId int64) error {
+	err := s.repo.RemoveConnection(ctx, userId, connId)
+	if err != nil {
+		return err
+	}
+
+	conns, _ := s.repo.GetUserConnections(ctx, userId)
+	if len(conns) == 0 {
+		// TTL с запасом: ключ должен дожить до FinishHandoff
+		return s.repo.SetHandoff(ctx, userId, 2*HandoffGrace)
+	}
+
+	return nil
+}
+
+// FinishHandoff объявляет offline тех, кто так и не переподключился.
+// Ключ handoff снимает либо OnConnect на другом инстансе, либо этот вызов.
+func (s *presenceService) FinishHandoff(ctx context.Context, userIds []int64) {
+	for _, userId := range userIds {
+		pending, err := s.repo.ClearHandoff(ctx, userId)
+		if err != nil || !pending {
+			continue
+		}
+
+		conns, _ := s.repo.GetUserConnections(ctx, userId)
+		if len(conns) == 0 {
+			s.markOffline(ctx, userId)
+		}
+	}
+}
+
+func (s *presenceService) markOffline(ctx context.Context, userId int64) {
+	s.repo.SetLastSeen(ctx, userId, time.Now())
+	_, _ = s.repo.ClearIdle(ctx, userId)
+	s.bus.Publish(PresenceEvent{
+		Type:   EventUserOffline,
+		UserId: userId,
+	})
+}
+
+// OnHeartbeat продлевает TTL соединения. last_activity не меняется:
+// pong говорит только о том, что транспорт жив.
 func (s *presenceService) OnHeartbeat(ctx context.Context, connId int64) error {
 	err := s.repo.TouchConnection(ctx, connId)
 	if err != nil {
@@ -79,37 +146,74 @@ func (s *presenceService) OnHeartbeat(ctx context.Context, connId int64) error {
 	return nil
 }
 
-func (s *presenceService) GetPresence(ctx context.Context, userId int64) *sDto.Presence {
-	conns, err := s.repo.GetUserConnections(ctx, userId)
+// OnActivity фиксирует действие пользователя (сообщение, набор текста, явный activity).
+func (s *presenceService) OnActivity(ctx context.Context, userId, connId int64) error {
+	wasIdle, err := s.repo.TouchActivity(ctx, userId, connId)
 	if err != nil {
-		// fail-safe: считаем offline
-		return &sDto.Presence{
-			UserId: userId,
-			Status: sDto.Offline,
+		if err == redis.Nil {
+			log.Printf("[presence] activity ignored, conn %d not found", connId)
+			return nil
 		}
+		return err
 	}
 
-	if len(conns) == 0 {
-		lastSeen, _ := s.repo.GetLastSeen(ctx, userId)
-		return &sDto.Presence{
-			UserId:   userId,
-			Status:   sDto.Offline,
-			LastSeen: lastSeen,
-		}
+	if wasIdle {
+		s.bus.Publish(PresenceEvent{
+			Type:   EventUserActive,
+			UserId: userId,
+		})
 	}
 
-	lastActivity := maxLastActivity(conns)
+	return nil
+}
 
-	status := sDto.Online
-	if time.Since(lastActivity) > s.idleThreshold {
-		status = sDto.Idle
+// CheckIdle публикует user_idle, если пользователь не проявлял активности
+// дольше idleThreshold. Флаг в Redis гарантирует одно событие на переход,
+// даже если проверку выполняют несколько соединений или инстансов.
+func (s *presenceService) CheckIdle(ctx context.Context, userId int64) error {
+	if s.GetPresence(ctx, userId).Status != sDto.Idle {
+		return nil
 	}
 
-	return &sDto.Presence{
-		UserId:   userId,
-		Status:   status,
-		LastSeen: lastActivity,
+	changed, err := s.repo.SetIdle(ctx, userId)
+	if err != nil {
+		return err
 	}
+
+	if changed {
+		s.bus.Publish(PresenceEvent{
+			Type:   EventUserIdle,
+			UserId: userId,
+		})
+	}
+
+	return nil
+}
+
+func (s *presenceService) GetPresence(ctx context.Context, userId int64) *sDto.Presence {
+	return s.GetPresences(ctx, []int64{userId})[0]
+}
+
+func (s *presenceService) GetPresences(ctx context.Context, userIds []int64) []*sDto.Presence {
+	result := make([]*sDto.Presence, len(userIds))
+
+	presences, err := s.repo.GetPresences(ctx, userIds)
+	if err != nil {
+		// fail-safe: считаем всех offline
+		for i, userId := range userIds {
+			result[i] = &sDto.Presence{
+				UserId: userId,
+				Status: sDto.Offline,
+			}
+		}
+		return result
+	}
+
+	for i, p := range presences {
+		result[i] = s.buildPresence(p)
+	}
+
+	return result
 }
 
 func (s *presenceService) GetOnlineFriends(ctx context.Context, userId int64, friends []int64) []int64 {
@@ -119,16 +223,91 @@ func (s *presenceService) GetOnlineFriends(ctx context.Context, userId int64, fr
 
 	online := make([]int64, 0, len(friends))
 
-	for _, friendId := range friends {
-		p := s.GetPresence(ctx, friendId)
+	for _, p := range s.GetPresences(ctx, friends) {
 		if p.Status == sDto.Online || p.Status == sDto.Idle {
-			online = append(online, friendId)
+			online = append(online, p.UserId)
 		}
 	}
 
 	return online
 }
 
+func (s *presenceService) GetSessionHistory(ctx context.Context, userId int64) ([]sDto.Session, error) {
+	history, err := s.repo.GetSessionHistory(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]sDto.Session, 0, len(history))
+	for _, h := range history {
+		sessions = append(sessions, sDto.Session{
+			Device:         sDto.ParseDevice(h.Device),
+			AppVersion:     h.AppVersion,
+			ConnectedAt:    h.ConnectedAt,
+			DisconnectedAt: h.DisconnectedAt,
+		})
+	}
+
+	return sessions, nil
+}
+
+func (s *presenceService) buildPresence(p rDto.UserPresence) *sDto.Presence {
+	if len(p.Connections) == 0 {
+		return &sDto.Presence{
+			UserId:   p.UserId,
+			Status:   sDto.Offline,
+			LastSeen: p.LastSeen,
+		}
+	}
+
+	lastActivity := maxLastActivity(p.Connections)
+
+	return &sDto.Presence{
+		UserId:   p.UserId,
+		Status:   s.statusFor(lastActivity),
+		LastSeen: lastActivity,
+		Devices:  s.buildDevices(p.Connections),
+	}
+}
+
+// buildDevices схлопывает соединения по типу устройства; самое активное устройство идёт первым.
+func (s *presenceService) buildDevices(conns []rDto.Connection) []sDto.DevicePresence {
+	byDevice := make(map[sDto.DeviceType]*sDto.DevicePresence, len(conns))
+
+	for _, c := range conns {
+		device := sDto.ParseDevice(c.Device)
+
+		d, ok := byDevice[device]
+		if !ok {
+			d = &sDto.DevicePresence{Device: device}
+			byDevice[device] = d
+		}
+		if c.LastActivity.After(d.LastActivity) {
+			d.LastActivity = c.LastActivity
+			d.AppVersion = c.AppVersion
+		}
+	}
+
+	devices := make([]sDto.DevicePresence, 0, len(byDevice))
+	for _, d := range byDevice {
+		d.Status = s.statusFor(d.LastActivity)
+		devices = append(devices, *d)
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].LastActivity.After(devices[j].LastActivity)
+	})
+
+	return devices
+}
+
+func (s *presenceService) statusFor(lastActivity time.Time) sDto.PresenceStatus {
+	if time.Since(lastActivity) > s.idleThreshold {
+		return sDto.Idle
+	}
+	return sDto.Online
+}
+
 func maxLastActivity(conns []rDto.Connection) time.Time {
 	var maxLA time.Time
 
@@ -6,10 +6,18 @@ import (
 )
 
 type PresenceService interface {
-	OnConnect(ctx context.Context, userId, connId int64, device string) error
+	OnConnect(ctx context.Context, userId, connId int64, device, appVersion string) error
+	OnResume(ctx context.Context, userId, connId int64, device, appVersion string) error
 	OnDisconnect(ctx context.Context, userId, connId int64) error
+	OnHandoff(ctx context.Context, userId, connId int64) error
+	FinishHandoff(ctx context.Context, userIds []int64)
 	OnHeartbeat(ctx context.Context, connId int64) error
+	OnActivity(ctx context.Context, userId, connId int64) error
+	CheckIdle(ctx context.Context, userId int64) error
 
 	GetPresence(ctx context.Context, userId int64) *sDto.Presence
+	GetPresences(ctx context.Context, userIds []int64) []*sDto.Presence
 	GetOnlineFriends(ctx context.Context, userId int64, friends []int64) []int64
+
+	GetSessionHistory(ctx context.Context, userId int64) ([]sDto.Session, error)
 }
@@ -0,0 +1,45 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	// activityThrottle ограничивает запись активности в Redis,
+	// когда клиент шлёт activity/typing на каждое нажатие клавиши.
+	activityThrottle = 5 * time.Second
+	idleCheckPeriod  = 30 * time.Second
+)
+
+// MarkActivity фиксирует действие пользователя на этом соединении.
+func (c *Connection) MarkActivity(ctx context.Context) {
+	now := time.Now().UnixMilli()
+	last := c.lastActivity.Load()
+
+	if now-last < activityThrottle.Milliseconds() || !c.lastActivity.CompareAndSwap(last, now) {
+		return
+	}
+
+	if err := c.Presence.OnActivity(ctx, c.UserId, c.connId); err != nil {
+		log.Printf("[conn] failed to record activity for user %d: %v", c.UserId, err)
+	}
+}
+
+func (c *Connection) idleLoop() {
+	ticker := time.NewTicker(idleCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+
+		case <-ticker.C:
+			if err := c.Presence.CheckIdle(context.Background(), c.UserId); err != nil {
+				log.Printf("[conn] idle check failed for user %d: %v", c.UserId, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package websocket
+
+import (
+	"chat_service/internal/websocket/dto"
+	"chat_service/internal/websocket/helper"
+	"log"
+	"time"
+)
+
+const (
+	// коды закрытия из диапазона приложения (4000–4999)
+	closeTokenExpired   = 4001
+	closeSessionRevoked = 4003
+
+	// reauthLead — за сколько до истечения токена клиента просят прислать reauth.
+	reauthLead = time.Minute
+)
+
+// SetAuthExpiry запоминает срок действия токена (unix, секунды), которым
+// авторизовано соединение. Вызывается при рукопожатии и после reauth.
+func (c *Connection) SetAuthExpiry(expiresAt int64) {
+	c.authExpiresAt.Store(expiresAt)
+
+	select {
+	case c.reauthed <- struct{}{}:
+	default:
+	}
+}
+
+// SetSessionId запоминает сессию входа, которой принадлежит токен соединения:
+// по ней DisconnectUser закрывает соединения одного устройства.
+func (c *Connection) SetSessionId(sessionId int64) {
+	c.sessionId.Store(sessionId)
+}
+
+// AuthExpired сообщает, что токен соединения истёк и не был продлён.
+func (c *Connection) AuthExpired() bool {
+	exp := c.authExpiresAt.Load()
+	return exp > 0 && time.Now().Unix() >= exp
+}
+
+// authLoop предупреждает клиента о скором истечении токена system-кадром
+// reauth_required и закрывает соединение с кодом 4001, если токен не продлили.
+func (c *Connection) authLoop() {
+	warned := false
+	timer := time.NewTimer(c.untilAuthCheck(warned))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+
+		case <-c.reauthed:
+			warned = false
+
+		case <-timer.C:
+			if c.AuthExpired() {
+				log.Printf("[conn] token of user %d expired, closing connection", c.UserId)
+				c.kick(closeTokenExpired, "token expired")
+				return
+			}
+			if !warned {
+				c.Enqueue(helper.BuildSystemWS(dto.SystemPayload{
+					Event:     dto.SystemReauthRequired,
+					ExpiresAt: c.authExpiresAt.Load(),
+				}))
+				warned = true
+			}
+		}
+
+		timer.Reset(c.untilAuthCheck(warned))
+	}
+}
+
+// untilAuthCheck — время до предупреждения или, если оно уже отправлено, до истечения токена.
+func (c *Connection) untilAuthCheck(warned bool) time.Duration {
+	d := time.Until(time.Unix(c.authExpiresAt.Load(), 0))
+	if !warned {
+		d -= reauthLead
+	}
+	return max(d, 0)
+}
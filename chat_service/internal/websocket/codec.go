@@ -0,0 +1,79 @@
+package websocket
+
+import (
+	"chat_service/internal/websocket/dto"
+	"encoding/json"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	SubprotocolJSON  = "chat.v1.json"
+	SubprotocolProto = "chat.v1.proto"
+)
+
+// Subprotocols — поддерживаемые подпротоколы в порядке предпочтения сервера.
+// Клиент без Sec-WebSocket-Protocol получает JSON.
+var Subprotocols = []string{SubprotocolProto, SubprotocolJSON}
+
+// Codec переводит кадры между форматом соединения и dto.WSMessage, поэтому
+// хэндлеры не зависят от кодировки. Исходящие кадры строятся в JSON один раз
+// на рассылку, Encode переводит их в формат соединения при записи.
+type Codec interface {
+	FrameType() int
+	Decode(data []byte) (dto.WSMessage, error)
+	Encode(frame outFrame) ([]byte, error)
+}
+
+// outFrame — кадр в очереди отправки соединения: пронумерованный JSON
+// dto.WSMessage и, если кадр разослан нескольким соединениям, общее для них тело.
+type outFrame struct {
+	msg    []byte
+	shared *sharedFrame
+}
+
+// sharedFrame — тело рассылки без seq. Бинарная кодировка строится один раз
+// на первом proto-соединении и переиспользуется остальными: seq у каждого
+// соединения свой и дописывается к готовым байтам.
+type sharedFrame struct {
+	body []byte
+
+	once  sync.Once
+	proto []byte
+	err   error
+}
+
+func newSharedFrame(body []byte) *sharedFrame {
+	return &sharedFrame{body: body}
+}
+
+func (f *sharedFrame) protoBody() ([]byte, error) {
+	f.once.Do(func() {
+		f.proto, f.err = encodeProto(f.body)
+	})
+	return f.proto, f.err
+}
+
+func CodecFor(subprotocol string) Codec {
+	if subprotocol == SubprotocolProto {
+		return protoCodec{}
+	}
+	return jsonCodec{}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) FrameType() int {
+	return websocket.TextMessage
+}
+
+func (jsonCodec) Decode(data []byte) (dto.WSMessage, error) {
+	var msg dto.WSMessage
+	err := json.Unmarshal(data, &msg)
+	return msg, err
+}
+
+func (jsonCodec) Encode(frame outFrame) ([]byte, error) {
+	return frame.msg, nil
+}
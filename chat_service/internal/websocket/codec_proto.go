@@ -0,0 +1,168 @@
+package websocket
+
+import (
+	"bytes"
+	"chat_service/internal/websocket/dto"
+	"chat_service/pkg/grpc_generated/chat"
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// protoCodec — бинарный подпротокол chat.v1.proto, кадры описаны в shared-proto/chat/ws.proto.
+type protoCodec struct{}
+
+// presenceFields объединяет поля команд клиента и событий presence, как WsPresence.
+type presenceFields struct {
+	Cmd     dto.PresenceCommand `json:"cmd,omitempty"`
+	UserIds []int64             `json:"user_ids,omitempty"`
+	UserId  int64               `json:"user_id,omitempty"`
+	Event   string              `json:"event,omitempty"`
+}
+
+func (protoCodec) FrameType() int {
+	return websocket.BinaryMessage
+}
+
+func (protoCodec) Decode(data []byte) (dto.WSMessage, error) {
+	var frame chat.WsFrame
+	if err := proto.Unmarshal(data, &frame); err != nil {
+		return dto.WSMessage{}, err
+	}
+
+	msg := dto.WSMessage{
+		Seq:  frame.Seq,
+		Type: dto.MessageType(frame.Type),
+	}
+
+	var payload any
+	switch p := frame.Payload.(type) {
+	case *chat.WsFrame_Chat:
+		payload = dto.ChatPayload{
+			Kind:       dto.ChatKind(p.Chat.Kind),
+			ToUserId:   p.Chat.ToUserId,
+			RoomId:     p.Chat.RoomId,
+			FromUserId: p.Chat.FromUserId,
+			Text:       p.Chat.Text,
+			Action:     p.Chat.Action,
+		}
+	case *chat.WsFrame_Presence:
+		payload = presenceFields{
+			Cmd:     dto.PresenceCommand(p.Presence.Cmd),
+			UserIds: p.Presence.UserIds,
+			UserId:  p.Presence.UserId,
+			Event:   p.Presence.Event,
+		}
+	case *chat.WsFrame_System:
+		payload = dto.SystemPayload{
+			Event:        dto.SystemEvent(p.System.Event),
+			RetryAfterMs: p.System.RetryAfterMs,
+			SessionToken: p.System.SessionToken,
+			ResumeTtlMs:  p.System.ResumeTtlMs,
+			Resumed:      p.System.Resumed,
+			LastSeq:      p.System.LastSeq,
+			FirstSeq:     p.System.FirstSeq,
+			ExpiresAt:    p.System.ExpiresAt,
+		}
+	case *chat.WsFrame_Resend:
+		payload = dto.ResendPayload{FromSeq: p.Resend.FromSeq}
+	case *chat.WsFrame_Json:
+		msg.Payload = p.Json
+		return msg, nil
+	default:
+		return msg, nil
+	}
+
+	raw, err := json.Marshal(payload)
+	msg.Payload = raw
+	return msg, err
+}
+
+func (protoCodec) Encode(f outFrame) ([]byte, error) {
+	if f.shared == nil {
+		return encodeProto(f.msg)
+	}
+
+	body, err := f.shared.protoBody()
+	if err != nil {
+		return nil, err
+	}
+	seq := stampedSeq(f.msg)
+	if seq == 0 {
+		return body, nil
+	}
+
+	// поля protobuf можно записывать в любом порядке: seq идёт перед общим телом
+	data := make([]byte, 0, len(body)+protowire.SizeTag(1)+protowire.SizeVarint(uint64(seq)))
+	data = protowire.AppendTag(data, 1, protowire.VarintType)
+	data = protowire.AppendVarint(data, uint64(seq))
+	return append(data, body...), nil
+}
+
+// encodeProto переводит JSON-кадр dto.WSMessage в WsFrame.
+func encodeProto(data []byte) ([]byte, error) {
+	var msg dto.WSMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+
+	frame := &chat.WsFrame{
+		Seq:  msg.Seq,
+		Type: string(msg.Type),
+	}
+
+	switch msg.Type {
+	case dto.MessageChat:
+		var p dto.ChatPayload
+		if strictUnmarshal(msg.Payload, &p) == nil {
+			frame.Payload = &chat.WsFrame_Chat{Chat: &chat.WsChat{
+				Kind:       string(p.Kind),
+				Action:     p.Action,
+				ToUserId:   p.ToUserId,
+				RoomId:     p.RoomId,
+				FromUserId: p.FromUserId,
+				Text:       p.Text,
+			}}
+		}
+	case dto.MessagePresence:
+		var p presenceFields
+		if strictUnmarshal(msg.Payload, &p) == nil {
+			frame.Payload = &chat.WsFrame_Presence{Presence: &chat.WsPresence{
+				Cmd:     string(p.Cmd),
+				UserIds: p.UserIds,
+				UserId:  p.UserId,
+				Event:   p.Event,
+			}}
+		}
+	case dto.MessageSystem:
+		var p dto.SystemPayload
+		if strictUnmarshal(msg.Payload, &p) == nil {
+			frame.Payload = &chat.WsFrame_System{System: &chat.WsSystem{
+				Event:        string(p.Event),
+				RetryAfterMs: p.RetryAfterMs,
+				SessionToken: p.SessionToken,
+				ResumeTtlMs:  p.ResumeTtlMs,
+				Resumed:      p.Resumed,
+				LastSeq:      p.LastSeq,
+				FirstSeq:     p.FirstSeq,
+				ExpiresAt:    p.ExpiresAt,
+			}}
+		}
+	}
+
+	// ответы со списками (presence_list, session_list и т.п.) идут как JSON
+	if frame.Payload == nil && len(msg.Payload) > 0 {
+		frame.Payload = &chat.WsFrame_Json{Json: msg.Payload}
+	}
+
+	return proto.Marshal(frame)
+}
+
+// strictUnmarshal не даёт молча потерять поля, которых нет в типизированной нагрузке.
+func strictUnmarshal(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
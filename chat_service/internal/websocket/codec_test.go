@@ -0,0 +1,95 @@
+package websocket
+
+import (
+	"chat_service/internal/websocket/dto"
+	"chat_service/internal/websocket/helper"
+	"chat_service/pkg/grpc_generated/chat"
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestProtoCodecDecodesIntoHandlerPayload(t *testing.T) {
+	data, _ := proto.Marshal(&chat.WsFrame{
+		Type: string(dto.MessageChat),
+		Payload: &chat.WsFrame_Chat{Chat: &chat.WsChat{
+			Kind:     string(dto.ChatDirect),
+			ToUserId: 2,
+			Text:     "hi",
+		}},
+	})
+
+	msg, err := protoCodec{}.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var payload dto.ChatPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != dto.MessageChat || payload.Kind != dto.ChatDirect || payload.ToUserId != 2 || payload.Text != "hi" {
+		t.Fatalf("unexpected message %+v, payload %+v", msg, payload)
+	}
+}
+
+func TestProtoCodecEncodesStampedFrames(t *testing.T) {
+	c := &Connection{}
+
+	chatFrame := c.stamp(helper.BuildChatWS([]byte(`{"to_user_id":2,"from_user_id":1,"text":"hi"}`)))
+	data, err := protoCodec{}.Encode(outFrame{msg: chatFrame})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var frame chat.WsFrame
+	if err := proto.Unmarshal(data, &frame); err != nil {
+		t.Fatal(err)
+	}
+	if frame.Seq != 1 || frame.GetChat().GetFromUserId() != 1 || frame.GetChat().GetText() != "hi" {
+		t.Fatalf("unexpected chat frame %v", &frame)
+	}
+
+	// нагрузка без типизированного представления не теряется
+	reply := []byte(`{"type":"presence","payload":{"cmd":"presence_list","users":[{"user_id":2,"status":"online"}]}}`)
+	data, err = protoCodec{}.Encode(outFrame{msg: reply})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame.Reset()
+	_ = proto.Unmarshal(data, &frame)
+	if string(frame.GetJson()) != `{"cmd":"presence_list","users":[{"user_id":2,"status":"online"}]}` {
+		t.Fatalf("expected JSON fallback, got %v", &frame)
+	}
+}
+
+func TestProtoCodecSharesBroadcastBody(t *testing.T) {
+	body := helper.BuildChatWS([]byte(`{"room_id":10,"from_user_id":1,"text":"hi"}`))
+	shared := newSharedFrame(body)
+	a, b := &Connection{}, &Connection{}
+	b.stamp(body)
+
+	for _, c := range []*Connection{a, b} {
+		stamped := c.stamp(body)
+		want, err := protoCodec{}.Encode(outFrame{msg: stamped})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := protoCodec{}.Encode(outFrame{msg: stamped, shared: shared})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var wantFrame, gotFrame chat.WsFrame
+		if err := proto.Unmarshal(want, &wantFrame); err != nil {
+			t.Fatal(err)
+		}
+		if err := proto.Unmarshal(got, &gotFrame); err != nil {
+			t.Fatal(err)
+		}
+		if !proto.Equal(&wantFrame, &gotFrame) || gotFrame.Seq != c.seq {
+			t.Fatalf("shared encoding %v differs from per-connection %v", &gotFrame, &wantFrame)
+		}
+	}
+}
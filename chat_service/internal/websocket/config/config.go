@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type WebSocketConfig struct {
+	ReadBufferSize  int
+	WriteBufferSize int
+	MaxMessageSize  int64
+	// SendBufferSize — ёмкость очереди исходящих кадров соединения.
+	SendBufferSize int
+
+	WriteWait  time.Duration
+	PongWait   time.Duration
+	PingPeriod time.Duration
+
+	// Compression включает согласование permessage-deflate; сжимаются только
+	// кадры не меньше CompressionThreshold байт — мелкие сжимать невыгодно.
+	Compression          bool
+	CompressionLevel     int
+	CompressionThreshold int
+
+	// AllowedOrigins — origin браузерных клиентов (scheme://host[:port]); "*" — любой.
+	// Пустой список допускает только origin, совпадающий с хостом сервиса.
+	AllowedOrigins []string
+}
+
+// Defaults — лимиты, с которыми сервис работал до вынесения настроек в окружение.
+// Сжатие permessage-deflate включено по умолчанию и применяется, только если
+// клиент его согласовал; отключается через WS_COMPRESSION=false.
+func Defaults() *WebSocketConfig {
+	pongWait := 60 * time.Second
+
+	return &WebSocketConfig{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		MaxMessageSize:  8 * 1024,
+		SendBufferSize:  256,
+
+		WriteWait:  10 * time.Second,
+		PongWait:   pongWait,
+		PingPeriod: pongWait * 9 / 10,
+
+		Compression:          true,
+		CompressionLevel:     1,
+		CompressionThreshold: 512,
+	}
+}
+
+// WebSocketCfgLoad читает настройки WS_*; незаданные переменные оставляют значения по умолчанию.
+func WebSocketCfgLoad() (*WebSocketConfig, error) {
+	config := Defaults()
+
+	toInt := func(key string, dst *int) {
+		if num, err := strconv.Atoi(os.Getenv(key)); err == nil && num > 0 {
+			*dst = num
+		}
+	}
+
+	toDuration := func(key string, dst *time.Duration) {
+		if secs, err := strconv.Atoi(os.Getenv(key)); err == nil && secs > 0 {
+			*dst = time.Duration(secs) * time.Second
+		}
+	}
+
+	toInt("WS_READ_BUFFER_SIZE", &config.ReadBufferSize)
+	toInt("WS_WRITE_BUFFER_SIZE", &config.WriteBufferSize)
+	toInt("WS_SEND_BUFFER_SIZE", &config.SendBufferSize)
+	toInt("WS_COMPRESSION_LEVEL", &config.CompressionLevel)
+	toInt("WS_COMPRESSION_THRESHOLD", &config.CompressionThreshold)
+
+	maxMessageSize := int(config.MaxMessageSize)
+	toInt("WS_MAX_MESSAGE_SIZE", &maxMessageSize)
+	config.MaxMessageSize = int64(maxMessageSize)
+
+	toDuration("WS_WRITE_WAIT", &config.WriteWait)
+	toDuration("WS_PONG_WAIT", &config.PongWait)
+	config.PingPeriod = config.PongWait * 9 / 10
+
+	if compression, err := strconv.ParseBool(os.Getenv("WS_COMPRESSION")); err == nil {
+		config.Compression = compression
+	}
+
+	for _, origin := range strings.Split(os.Getenv("WS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			config.AllowedOrigins = append(config.AllowedOrigins, strings.ToLower(strings.TrimSuffix(origin, "/")))
+		}
+	}
+
+	return config, nil
+}
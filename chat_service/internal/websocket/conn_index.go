@@ -0,0 +1,94 @@
+package websocket
+
+import "sync"
+
+const indexShards = 64
+
+// connIndex — потокобезопасное отображение id -> множество соединений,
+// разбитое на шарды, чтобы рассылки по разным пользователям и комнатам
+// не конкурировали за одну блокировку.
+type connIndex struct {
+	shards [indexShards]indexShard
+}
+
+type indexShard struct {
+	mu   sync.RWMutex
+	sets map[int64]map[*Connection]struct{}
+}
+
+func newConnIndex() *connIndex {
+	idx := &connIndex{}
+	for i := range idx.shards {
+		idx.shards[i].sets = make(map[int64]map[*Connection]struct{})
+	}
+	return idx
+}
+
+func (idx *connIndex) shard(key int64) *indexShard {
+	return &idx.shards[uint64(key)%indexShards]
+}
+
+// Add возвращает размер множества после добавления.
+func (idx *connIndex) Add(key int64, c *Connection) int {
+	s := idx.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set, ok := s.sets[key]
+	if !ok {
+		set = make(map[*Connection]struct{})
+		s.sets[key] = set
+	}
+	set[c] = struct{}{}
+
+	return len(set)
+}
+
+func (idx *connIndex) Remove(key int64, c *Connection) {
+	s := idx.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if set, ok := s.sets[key]; ok {
+		delete(set, c)
+		if len(set) == 0 {
+			delete(s.sets, key)
+		}
+	}
+}
+
+// Snapshot копирует множество под блокировкой, чтобы отправка шла без неё.
+func (idx *connIndex) Snapshot(key int64) []*Connection {
+	s := idx.shard(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := s.sets[key]
+	if len(set) == 0 {
+		return nil
+	}
+
+	conns := make([]*Connection, 0, len(set))
+	for c := range set {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// All возвращает снимок всех соединений индекса.
+func (idx *connIndex) All() []*Connection {
+	var conns []*Connection
+
+	for i := range idx.shards {
+		s := &idx.shards[i]
+		s.mu.RLock()
+		for _, set := range s.sets {
+			for c := range set {
+				conns = append(conns, c)
+			}
+		}
+		s.mu.RUnlock()
+	}
+
+	return conns
+}
@@ -3,70 +3,304 @@ package websocket
 import (
 	"chat_service/internal/authz"
 	"chat_service/internal/presence/service"
+	"chat_service/internal/websocket/ratelimit"
+	"chat_service/internal/websocket/session"
 	"context"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// ClientInfo описывает клиента, переданного при рукопожатии.
+type ClientInfo struct {
+	Device     string
+	AppVersion string
+}
+
 type Connection struct {
-	ws   *websocket.Conn
-	Send chan []byte
+	ws    *websocket.Conn
+	codec Codec
+	Send  chan outFrame
 
 	UserId int64
 	connId int64
+	Client ClientInfo
 
 	Ctx      context.Context
 	router   *Router
 	Presence service.PresenceService
+	Sessions session.Store
 	Hub      *Hub
 
 	Authz authz.AuthServiceInterface
 
-	Subscribed map[int64]struct{}
+	// limits — nil, если Hub создан без ограничителя
+	limits *ratelimit.ConnLimiter
+
+	// mu защищает членство в комнатах и подписки на presence;
+	// после closed Hub больше не добавляет соединение в индексы.
+	mu       sync.Mutex
+	rooms    map[int64]struct{}
+	watching map[int64]struct{}
+	closed   bool
+
+	connectedAt time.Time
+	bytesIn     atomic.Int64
+	bytesOut    atomic.Int64
+
+	// lastActivity — unix ms последней записанной активности, см. MarkActivity.
+	lastActivity atomic.Int64
+
+	// authExpiresAt — unix-время истечения токена (0 — срок неизвестен), см. auth.go.
+	authExpiresAt atomic.Int64
+	reauthed      chan struct{}
+	// sessionId — сессия входа в profile_service (claim sid), 0 — неизвестна.
+	sessionId atomic.Int64
+
+	// sessionToken выдаётся клиенту для возобновления после обрыва, см. resume.go.
+	sessionToken string
+	resumeToken  string
+	resumed      *session.State
+	// replay пишется в сокет раньше кадров из Send.
+	replay       [][]byte
+	clientClosed atomic.Bool
+
+	// sendMu упорядочивает нумерацию и постановку кадров в Send, см. sequence.go.
+	// suspended — соединение оборвано и копит кадры без номеров (их пронумерует
+	// возобновлённая сессия), restoring — возобновление ещё идёт, кадры ждут в pending.
+	sendMu    sync.Mutex
+	seq       int64
+	ring      *frameRing
+	suspended bool
+	restoring bool
+	pending   [][]byte
 
-	closeOnce sync.Once
+	// done закрывается вместе с транспортом, released — когда соединение
+	// окончательно снято с Hub (у приостановленной сессии это происходит позже).
+	done        chan struct{}
+	closeOnce   sync.Once
+	released    chan struct{}
+	stopSuspend chan struct{}
+	stopOnce    sync.Once
+	// revoked — приостановленную сессию отозвали, см. DisconnectUser
+	revoked atomic.Bool
+
+	kicked   chan struct{}
+	kickOnce sync.Once
+	kickCode int
+	kickText string
 }
 
-func NewConnection(ws *websocket.Conn, userId int64, presence service.PresenceService,
-	ctx context.Context, router *Router, hub *Hub, authz authz.AuthServiceInterface) *Connection {
+func NewConnection(ws *websocket.Conn, userId int64, client ClientInfo, presence service.PresenceService,
+	sessions session.Store, ctx context.Context, router *Router, hub *Hub, authz authz.AuthServiceInterface) *Connection {
+	var limits *ratelimit.ConnLimiter
+	if hub.limiter != nil {
+		limits = hub.limiter.ForConnection(userId)
+	}
+
 	return &Connection{
-		ws:   ws,
-		Send: make(chan []byte, 256),
+		ws:    ws,
+		codec: CodecFor(ws.Subprotocol()),
+		Send:  make(chan outFrame, hub.cfg.SendBufferSize),
 
 		UserId: userId,
 		connId: time.Now().UnixNano(),
+		Client: client,
+
+		connectedAt: time.Now(),
 
 		Presence: presence,
+		Sessions: sessions,
 		Ctx:      ctx,
 		router:   router,
 		Hub:      hub,
 
-		Authz: authz,
+		Authz:  authz,
+		limits: limits,
 
-		Subscribed: make(map[int64]struct{}),
+		rooms:    make(map[int64]struct{}),
+		watching: make(map[int64]struct{}),
+
+		sessionToken: session.NewToken(),
+		reauthed:     make(chan struct{}, 1),
+
+		done:        make(chan struct{}),
+		released:    make(chan struct{}),
+		stopSuspend: make(chan struct{}),
+		kicked:      make(chan struct{}),
 	}
 }
 
 func (c *Connection) Start() {
 	c.Hub.RegisterConnection(c)
 
-	_ = c.Presence.OnConnect(context.Background(), c.UserId, c.connId, "web")
+	resumed := c.resumed != nil
+	if resumed {
+		c.restore()
+	} else {
+		_ = c.Presence.OnConnect(context.Background(), c.UserId, c.connId, c.Client.Device, c.Client.AppVersion)
+	}
+	c.lastActivity.Store(time.Now().UnixMilli())
+
+	// токен сессии клиент получает первым кадром, до повторно доставляемых
+	c.replay = append([][]byte{c.sessionFrame(resumed)}, c.replay...)
 
 	go c.readLoop()
 	go c.writeLoop()
+	go c.idleLoop()
+	if c.authExpiresAt.Load() > 0 {
+		go c.authLoop()
+	}
 }
 
 func (c *Connection) close() {
 	c.closeOnce.Do(func() {
-		log.Printf("[conn] closing connection for user %d", c.UserId)
+		log.Printf("[conn] closing connection for user %d, in=%dB out=%dB", c.UserId, c.bytesIn.Load(), c.bytesOut.Load())
+
+		close(c.done)
+		_ = c.ws.Close()
+
+		switch {
+		case c.Hub.Draining():
+			c.handoff()
+		case c.resumable():
+			c.suspend()
+		default:
+			c.release(true)
+		}
+	})
+}
 
-		c.Hub.UnregisterConnection(c)
+// release окончательно снимает соединение с Hub; disconnect — сообщить ли presence об уходе.
+func (c *Connection) release(disconnect bool) {
+	c.Hub.UnregisterConnection(c)
 
+	if disconnect {
 		_ = c.Presence.OnDisconnect(context.Background(), c.UserId, c.connId)
+	}
 
-		_ = c.ws.Close()
+	close(c.released)
+}
+
+// Enqueue нумерует сообщение и ставит его в очередь отправки, не блокируясь.
+// При переполненном буфере решение принимает политика Hub для медленных клиентов;
+// номер при этом не пропадает, и клиент может запросить кадр через resend_from.
+func (c *Connection) Enqueue(msg []byte) bool {
+	return c.enqueue(outFrame{msg: msg})
+}
+
+func (c *Connection) enqueue(f outFrame) bool {
+	select {
+	case <-c.released:
+		return false
+	default:
+	}
+
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	switch {
+	case c.suspended:
+		return c.push(f)
+	case c.restoring:
+		c.pending = append(c.pending, f.msg)
+		return true
+	default:
+		f.msg = c.stamp(f.msg)
+		return c.push(f)
+	}
+}
+
+func (c *Connection) push(f outFrame) bool {
+	select {
+	case c.Send <- f:
+		return true
+	default:
+		c.Hub.onSlowConsumer(c)
+		return false
+	}
+}
+
+// kick просит writeLoop закрыть соединение с указанным кодом.
+func (c *Connection) kick(code int, text string) bool {
+	kicked := false
+	c.kickOnce.Do(func() {
+		c.kickCode = code
+		c.kickText = text
+		close(c.kicked)
+		kicked = true
 	})
+	return kicked
+}
+
+func (c *Connection) join(roomId int64, idx *connIndex) {
+	c.track(c.rooms, roomId, idx)
+}
+
+func (c *Connection) leave(roomId int64, idx *connIndex) {
+	c.untrack(c.rooms, roomId, idx)
+}
+
+func (c *Connection) watch(userId int64, idx *connIndex) {
+	c.track(c.watching, userId, idx)
+}
+
+func (c *Connection) unwatch(userId int64, idx *connIndex) {
+	c.untrack(c.watching, userId, idx)
+}
+
+// track и untrack меняют индекс Hub под c.mu: иначе добавление могло бы
+// проскочить после того, как UnregisterConnection собрал memberships.
+func (c *Connection) track(set map[int64]struct{}, id int64, idx *connIndex) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := set[id]; ok || c.closed {
+		return
+	}
+	set[id] = struct{}{}
+	idx.Add(id, c)
+}
+
+func (c *Connection) untrack(set map[int64]struct{}, id int64, idx *connIndex) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := set[id]; !ok {
+		return
+	}
+	delete(set, id)
+	idx.Remove(id, c)
+}
+
+// memberships помечает соединение закрытым и возвращает комнаты и подписки,
+// из которых его нужно убрать.
+func (c *Connection) memberships() (rooms, watched []int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+
+	return c.collect()
+}
+
+// snapshotMemberships возвращает комнаты и подписки, не закрывая соединение.
+func (c *Connection) snapshotMemberships() (rooms, watched []int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.collect()
+}
+
+func (c *Connection) collect() (rooms, watched []int64) {
+	for id := range c.rooms {
+		rooms = append(rooms, id)
+	}
+	for id := range c.watching {
+		watched = append(watched, id)
+	}
+	return rooms, watched
 }
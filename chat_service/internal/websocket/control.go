@@ -0,0 +1,139 @@
+package websocket
+
+import (
+	"chat_service/internal/pubsub"
+	"context"
+	"encoding/json"
+	"log"
+)
+
+// controlChannel — команды, которые должны выполнить все инстансы чата.
+const controlChannel = "ws.control"
+
+const (
+	controlDisconnectUser = "disconnect_user"
+	controlSessionResumed = "session_resumed"
+)
+
+// SessionFilter выбирает соединения пользователя по сессии входа;
+// нулевое значение выбирает все.
+type SessionFilter struct {
+	// SessionId != 0 — только соединения этой сессии
+	SessionId int64 `json:"session_id,omitempty"`
+	// ExceptSessionId != 0 — все, кроме соединений этой сессии
+	ExceptSessionId int64 `json:"except_session_id,omitempty"`
+}
+
+func (f SessionFilter) match(sessionId int64) bool {
+	if f.SessionId != 0 && sessionId != f.SessionId {
+		return false
+	}
+	return f.ExceptSessionId == 0 || sessionId != f.ExceptSessionId
+}
+
+type disconnectUserCommand struct {
+	UserId int64 `json:"user_id"`
+	SessionFilter
+	Reason string `json:"reason"`
+}
+
+// sessionResumedCommand — сессию возобновили: инстанс, где она приостановлена,
+// перестаёт копить для неё кадры.
+type sessionResumedCommand struct {
+	Token string `json:"token"`
+}
+
+// ControlPublisher рассылает команды всем инстансам чата; его используют
+// вне Hub, например gRPC-сервер, через который profile_service отзывает сессии.
+type ControlPublisher struct {
+	pub pubsub.PubSub
+}
+
+func NewControlPublisher(pub pubsub.PubSub) *ControlPublisher {
+	return &ControlPublisher{pub: pub}
+}
+
+// DisconnectUser просит все инстансы закрыть соединения пользователя,
+// выбранные filter.
+func (p *ControlPublisher) DisconnectUser(ctx context.Context, userId int64, filter SessionFilter, reason string) error {
+	data, err := json.Marshal(disconnectUserCommand{UserId: userId, SessionFilter: filter, Reason: reason})
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(pubsub.RedisEvent{
+		Type: controlDisconnectUser,
+		Data: data,
+	})
+	if err != nil {
+		return err
+	}
+
+	return p.pub.Publish(ctx, controlChannel, raw)
+}
+
+// DisconnectUser закрывает соединения пользователя на этом инстансе с кодом 4003:
+// все или выбранные filter по сессии входа, к которой относится их токен.
+// Приостановленные сессии тоже закрываются: возобновить их уже нельзя.
+func (h *Hub) DisconnectUser(userId int64, filter SessionFilter, reason string) int {
+	var conns []*Connection
+	for _, c := range h.users.Snapshot(userId) {
+		if filter.match(c.sessionId.Load()) {
+			conns = append(conns, c)
+		}
+	}
+	for _, c := range conns {
+		if ghost := h.takeSuspended(c.sessionToken); ghost != nil {
+			ghost.revokeSuspended()
+			continue
+		}
+		c.kick(closeSessionRevoked, reason)
+	}
+
+	if len(conns) > 0 {
+		log.Printf("[hub] user %d disconnected (%s), connections: %d", userId, reason, len(conns))
+	}
+	return len(conns)
+}
+
+// announceResumed сообщает остальным инстансам, что сессия token возобновлена
+// здесь. Без этого приостановленное соединение на другом инстансе до конца
+// ResumeGrace копило бы кадры в spool, который уже никто не прочитает,
+// и оставалось бы в индексах комнат и пользователей.
+func (h *Hub) announceResumed(ctx context.Context, token string) {
+	data, _ := json.Marshal(sessionResumedCommand{Token: token})
+	raw, _ := json.Marshal(pubsub.RedisEvent{
+		Type:       controlSessionResumed,
+		InstanceId: h.InstanceId,
+		Data:       data,
+	})
+	if err := h.Pubsub.Publish(ctx, controlChannel, raw); err != nil {
+		log.Printf("[hub] failed to announce resumed session: %v", err)
+	}
+}
+
+func (h *Hub) handleControl(raw []byte) {
+	var evt pubsub.RedisEvent
+	if err := json.Unmarshal(raw, &evt); err != nil {
+		return
+	}
+
+	switch evt.Type {
+	case controlDisconnectUser:
+		var cmd disconnectUserCommand
+		if err := json.Unmarshal(evt.Data, &cmd); err != nil {
+			return
+		}
+		h.DisconnectUser(cmd.UserId, cmd.SessionFilter, cmd.Reason)
+
+	case controlSessionResumed:
+		var cmd sessionResumedCommand
+		if err := json.Unmarshal(evt.Data, &cmd); err != nil {
+			return
+		}
+		if ghost := h.takeSuspended(cmd.Token); ghost != nil {
+			ghost.stopSuspended()
+			log.Printf("[hub] suspended connection %d of user %d resumed on %s", ghost.connId, ghost.UserId, evt.InstanceId)
+		}
+	}
+}
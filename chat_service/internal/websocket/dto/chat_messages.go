@@ -13,5 +13,8 @@ type ChatPayload struct {
 	RoomId   int64    `json:"room_id,omitempty"`
 	Text     string   `json:"text"`
 
+	// FromUserId заполняет сервер в исходящих кадрах
+	FromUserId int64 `json:"from_user_id,omitempty"`
+
 	Action string `json:"action,omitempty"`
 }
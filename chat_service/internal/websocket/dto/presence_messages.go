@@ -1,14 +1,41 @@
 package dto
 
+import "time"
+
 type PresenceCommand string
 
 const (
 	CmdSubscribe        PresenceCommand = "subscribe"
 	CmdUnsubscribe      PresenceCommand = "unsubscribe"
 	CmdGetOnlineFriends PresenceCommand = "get_online_friends"
+	CmdGetPresence      PresenceCommand = "get_presence"
+	CmdGetSessions      PresenceCommand = "get_sessions"
 )
 
 type PresencePayload struct {
 	Cmd     PresenceCommand `json:"cmd"`
 	UserIds []int64         `json:"user_ids"`
 }
+
+// PresenceView — presence пользователя для клиента. LastSeen и LastActivity
+// отсутствуют, если владелец скрыл их настройками приватности.
+type PresenceView struct {
+	UserId   int64        `json:"user_id"`
+	Status   string       `json:"status"`
+	LastSeen *time.Time   `json:"last_seen,omitempty"`
+	Devices  []DeviceView `json:"devices,omitempty"`
+}
+
+type DeviceView struct {
+	Device       string     `json:"device"`
+	AppVersion   string     `json:"app_version"`
+	Status       string     `json:"status"`
+	LastActivity *time.Time `json:"last_activity,omitempty"`
+}
+
+type SessionView struct {
+	Device         string    `json:"device"`
+	AppVersion     string    `json:"app_version"`
+	ConnectedAt    time.Time `json:"connected_at"`
+	DisconnectedAt time.Time `json:"disconnected_at"`
+}
@@ -9,9 +9,73 @@ const (
 	MessageChat     MessageType = "chat"
 	MessageSystem   MessageType = "system"
 	MessagePresence MessageType = "presence"
+	MessageActivity MessageType = "activity"
+	MessageResend   MessageType = "resend_from"
+	MessageReauth   MessageType = "reauth"
+	MessageError    MessageType = "error"
 )
 
+type ErrorCode string
+
+const (
+	ErrRateLimited    ErrorCode = "rate_limited"
+	ErrUnknownType    ErrorCode = "unknown_type"
+	ErrInvalidPayload ErrorCode = "invalid_payload"
+	ErrInternal       ErrorCode = "internal_error"
+	ErrReauthFailed   ErrorCode = "reauth_failed"
+	ErrAuthExpired    ErrorCode = "auth_expired"
+)
+
+// ErrorPayload — отказ в обработке сообщения клиента; type — тип отклонённого сообщения.
+type ErrorPayload struct {
+	Code         ErrorCode   `json:"code"`
+	Type         MessageType `json:"type,omitempty"`
+	Message      string      `json:"message,omitempty"`
+	RetryAfterMs int64       `json:"retry_after_ms,omitempty"`
+}
+
+type SystemEvent string
+
+const (
+	SystemReconnect SystemEvent = "reconnect"
+	SystemSession   SystemEvent = "session"
+	// SystemResendUnavailable — запрошенные кадры вытеснены из буфера, first_seq — самый ранний доступный.
+	SystemResendUnavailable SystemEvent = "resend_unavailable"
+	// SystemReauthRequired — токен скоро истечёт, клиент должен прислать reauth до expires_at.
+	SystemReauthRequired SystemEvent = "reauth_required"
+	SystemReauthOk       SystemEvent = "reauth_ok"
+)
+
+type SystemPayload struct {
+	Event        SystemEvent `json:"event"`
+	RetryAfterMs int64       `json:"retry_after_ms,omitempty"`
+
+	// session: токен для /ws?resume=... и срок, в течение которого им можно воспользоваться
+	SessionToken string `json:"session_token,omitempty"`
+	ResumeTtlMs  int64  `json:"resume_ttl_ms,omitempty"`
+	Resumed      bool   `json:"resumed,omitempty"`
+	LastSeq      int64  `json:"last_seq,omitempty"`
+
+	FirstSeq int64 `json:"first_seq,omitempty"`
+
+	// ExpiresAt — unix-время (секунды) истечения токена, которым авторизовано соединение
+	ExpiresAt int64 `json:"expires_at,omitempty"`
+}
+
+// ResendPayload — запрос повторной отправки кадров начиная с from_seq.
+type ResendPayload struct {
+	FromSeq int64 `json:"from_seq"`
+}
+
+// ReauthPayload — свежий JWT для продления авторизации открытого соединения.
+type ReauthPayload struct {
+	Token string `json:"token"`
+}
+
+// WSMessage.Seq проставляется сервером на исходящих кадрах и растёт на 1
+// в пределах сессии соединения; пропуск номера означает потерянный кадр.
 type WSMessage struct {
+	Seq     int64           `json:"seq,omitempty"`
 	Type    MessageType     `json:"type"`
 	Payload json.RawMessage `json:"payload,omitempty"`
 }
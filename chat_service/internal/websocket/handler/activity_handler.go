@@ -0,0 +1,13 @@
+package handler
+
+import (
+	"chat_service/internal/websocket"
+	"chat_service/internal/websocket/dto"
+	"context"
+)
+
+// ActivityHandler принимает явные сигналы активности от клиента: фокус окна,
+// набор текста, прокрутку. Payload не требуется.
+func ActivityHandler(ctx context.Context, c *websocket.Connection, msg dto.WSMessage) {
+	c.MarkActivity(ctx)
+}
@@ -18,6 +18,8 @@ func ChatHandler(ctx context.Context, c *websocket.Connection, msg dto.WSMessage
 		return
 	}
 
+	c.MarkActivity(ctx)
+
 	switch payload.Kind {
 
 	case dto.ChatDirect:
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	sDto "chat_service/internal/presence/service/dto"
 	"chat_service/internal/websocket"
 	"chat_service/internal/websocket/dto"
 	"context"
@@ -17,7 +18,7 @@ func PresenceHandler(ctx context.Context, c *websocket.Connection, msg dto.WSMes
 	switch payload.Cmd {
 
 	case dto.CmdSubscribe:
-		handleSubscribe(c, payload.UserIds)
+		handleSubscribe(ctx, c, payload.UserIds)
 
 	case dto.CmdUnsubscribe:
 		handleUnsubscribe(c, payload.UserIds)
@@ -25,23 +26,27 @@ func PresenceHandler(ctx context.Context, c *websocket.Connection, msg dto.WSMes
 	case dto.CmdGetOnlineFriends:
 		handleGetOnlineFriends(ctx, c, payload.UserIds)
 
+	case dto.CmdGetPresence:
+		handleGetPresence(ctx, c, payload.UserIds)
+
+	case dto.CmdGetSessions:
+		handleGetSessions(ctx, c)
+
 	default:
 
 	}
 }
 
-func handleSubscribe(c *websocket.Connection, userIDs []int64) {
-	for _, id := range userIDs {
-		c.Subscribed[id] = struct{}{}
-	}
+// handleSubscribe: события presence выдают активность и время выхода, поэтому
+// подписка разрешена только на тех, чей last seen пользователю виден.
+func handleSubscribe(ctx context.Context, c *websocket.Connection, userIDs []int64) {
+	allowed := c.Hub.Subscribe(ctx, c, userIDs)
 
-	log.Printf("[presence] user %d subscribed to %v", c.UserId, userIDs)
+	log.Printf("[presence] user %d subscribed to %v of %v", c.UserId, allowed, userIDs)
 }
 
 func handleUnsubscribe(c *websocket.Connection, userIDs []int64) {
-	for _, id := range userIDs {
-		delete(c.Subscribed, id)
-	}
+	c.Hub.Unsubscribe(c, userIDs)
 }
 
 func handleGetOnlineFriends(ctx context.Context, c *websocket.Connection, userIds []int64) {
@@ -57,5 +62,86 @@ func handleGetOnlineFriends(ctx context.Context, c *websocket.Connection, userId
 		Payload: payload,
 	})
 
-	c.Send <- resp
+	c.Enqueue(resp)
+}
+
+func handleGetPresence(ctx context.Context, c *websocket.Connection, userIds []int64) {
+	presences := c.Presence.GetPresences(ctx, userIds)
+
+	// fail-closed: если профиль недоступен, last seen не показываем никому, кроме себя
+	visible := make(map[int64]struct{}, len(userIds))
+	for _, id := range c.Hub.LastSeenVisible(ctx, c.UserId, userIds) {
+		visible[id] = struct{}{}
+	}
+
+	users := make([]dto.PresenceView, 0, len(presences))
+	for _, p := range presences {
+		_, showLastSeen := visible[p.UserId]
+		users = append(users, toPresenceView(p, showLastSeen))
+	}
+
+	sendPresenceReply(c, map[string]any{
+		"cmd":   "presence_list",
+		"users": users,
+	})
+}
+
+// handleGetSessions отдаёт историю сессий только самому пользователю.
+func handleGetSessions(ctx context.Context, c *websocket.Connection) {
+	history, err := c.Presence.GetSessionHistory(ctx, c.UserId)
+	if err != nil {
+		log.Printf("[presence] failed to get session history for user %d: %v", c.UserId, err)
+		return
+	}
+
+	sessions := make([]dto.SessionView, 0, len(history))
+	for _, s := range history {
+		sessions = append(sessions, dto.SessionView{
+			Device:         string(s.Device),
+			AppVersion:     s.AppVersion,
+			ConnectedAt:    s.ConnectedAt,
+			DisconnectedAt: s.DisconnectedAt,
+		})
+	}
+
+	sendPresenceReply(c, map[string]any{
+		"cmd":      "session_list",
+		"sessions": sessions,
+	})
+}
+
+func toPresenceView(p *sDto.Presence, showLastSeen bool) dto.PresenceView {
+	view := dto.PresenceView{
+		UserId:  p.UserId,
+		Status:  string(p.Status),
+		Devices: make([]dto.DeviceView, 0, len(p.Devices)),
+	}
+	if showLastSeen && !p.LastSeen.IsZero() {
+		view.LastSeen = &p.LastSeen
+	}
+
+	for _, d := range p.Devices {
+		device := dto.DeviceView{
+			Device:     string(d.Device),
+			AppVersion: d.AppVersion,
+			Status:     string(d.Status),
+		}
+		if showLastSeen {
+			device.LastActivity = &d.LastActivity
+		}
+		view.Devices = append(view.Devices, device)
+	}
+
+	return view
+}
+
+func sendPresenceReply(c *websocket.Connection, reply map[string]any) {
+	payload, _ := json.Marshal(reply)
+
+	resp, _ := json.Marshal(dto.WSMessage{
+		Type:    dto.MessagePresence,
+		Payload: payload,
+	})
+
+	c.Enqueue(resp)
 }
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"chat_service/internal/websocket"
+	"chat_service/internal/websocket/dto"
+	"chat_service/internal/websocket/helper"
+	"chat_service/middleware_chat"
+	"chat_service/pkg/grpc_generated/profile"
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// NewReauthHandler продлевает авторизацию открытого соединения свежим токеном,
+// не разрывая его. Токен должен принадлежать тому же пользователю.
+func NewReauthHandler(profileClient middleware_chat.ProfileClient) websocket.HandlerFunc {
+	return func(ctx context.Context, c *websocket.Connection, msg dto.WSMessage) {
+		var payload dto.ReauthPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		resp, err := profileClient.ValidateToken(callCtx, &profile.TokenRequest{Token: payload.Token})
+		if err != nil || !resp.Valid || resp.UserId != strconv.FormatInt(c.UserId, 10) {
+			c.Enqueue(helper.BuildErrorWS(dto.ErrorPayload{
+				Code:    dto.ErrReauthFailed,
+				Type:    msg.Type,
+				Message: "invalid token",
+			}))
+			return
+		}
+
+		c.SetAuthExpiry(resp.ExpiresAt)
+		// после повторного входа на том же устройстве токен принадлежит новой сессии
+		if resp.SessionId != 0 {
+			c.SetSessionId(resp.SessionId)
+		}
+		c.Enqueue(helper.BuildSystemWS(dto.SystemPayload{
+			Event:     dto.SystemReauthOk,
+			ExpiresAt: resp.ExpiresAt,
+		}))
+	}
+}
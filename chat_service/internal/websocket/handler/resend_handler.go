@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"chat_service/internal/websocket"
+	"chat_service/internal/websocket/dto"
+	"chat_service/internal/websocket/helper"
+	"context"
+	"encoding/json"
+)
+
+// ResendHandler повторно отправляет кадры, пропуск которых обнаружил клиент.
+func ResendHandler(ctx context.Context, c *websocket.Connection, msg dto.WSMessage) {
+	var payload dto.ResendPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil || payload.FromSeq < 1 {
+		return
+	}
+
+	if oldest, ok := c.Resend(payload.FromSeq); !ok {
+		c.Enqueue(helper.BuildSystemWS(dto.SystemPayload{
+			Event:    dto.SystemResendUnavailable,
+			FirstSeq: oldest,
+		}))
+	}
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"chat_service/internal/websocket"
+	"chat_service/internal/websocket/dto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+const (
+	maxTextLength  = 4096
+	maxPresenceIds = 500
+)
+
+// Validators — проверки входящих сообщений для websocket.ValidatePayload.
+var Validators = map[dto.MessageType]websocket.Validator{
+	dto.MessageChat:     validateChat,
+	dto.MessagePresence: validatePresence,
+	dto.MessageResend:   validateResend,
+	dto.MessageReauth:   validateReauth,
+}
+
+func validateChat(raw json.RawMessage) error {
+	var p dto.ChatPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return errors.New("malformed chat payload")
+	}
+
+	switch p.Kind {
+	case dto.ChatDirect:
+		if p.ToUserId <= 0 {
+			return errors.New("to_user_id is required")
+		}
+	case dto.ChatRoom:
+		if p.RoomId <= 0 {
+			return errors.New("room_id is required")
+		}
+		if p.Action == "join" || p.Action == "leave" {
+			return nil
+		}
+		if p.Action != "" {
+			return fmt.Errorf("unknown action %q", p.Action)
+		}
+	default:
+		return fmt.Errorf("unknown kind %q", p.Kind)
+	}
+
+	if p.Text == "" || utf8.RuneCountInString(p.Text) > maxTextLength {
+		return fmt.Errorf("text must be 1..%d characters", maxTextLength)
+	}
+	return nil
+}
+
+func validatePresence(raw json.RawMessage) error {
+	var p dto.PresencePayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return errors.New("malformed presence payload")
+	}
+
+	switch p.Cmd {
+	case dto.CmdSubscribe, dto.CmdUnsubscribe, dto.CmdGetOnlineFriends, dto.CmdGetPresence:
+		if len(p.UserIds) > maxPresenceIds {
+			return fmt.Errorf("at most %d user_ids per command", maxPresenceIds)
+		}
+	case dto.CmdGetSessions:
+	default:
+		return fmt.Errorf("unknown cmd %q", p.Cmd)
+	}
+	return nil
+}
+
+func validateResend(raw json.RawMessage) error {
+	var p dto.ResendPayload
+	if err := json.Unmarshal(raw, &p); err != nil || p.FromSeq < 1 {
+		return errors.New("from_seq must be positive")
+	}
+	return nil
+}
+
+func validateReauth(raw json.RawMessage) error {
+	var p dto.ReauthPayload
+	if err := json.Unmarshal(raw, &p); err != nil || p.Token == "" {
+		return errors.New("token is required")
+	}
+	return nil
+}
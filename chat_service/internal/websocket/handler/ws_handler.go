@@ -3,11 +3,18 @@ package handler
 import (
 	"chat_service/internal/authz"
 	"chat_service/internal/presence/service"
+	sDto "chat_service/internal/presence/service/dto"
 	webS "chat_service/internal/websocket"
+	"chat_service/internal/websocket/config"
+	"chat_service/internal/websocket/session"
+	"chat_service/internal/websocket/ticket"
 	"chat_service/middleware_chat"
 	"chat_service/pkg/grpc_generated/profile"
 	"context"
+	"errors"
+	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -15,52 +22,178 @@ import (
 	"github.com/gorilla/websocket"
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true // TODO: Реализовать полноценно
-	},
+func newUpgrader(cfg *config.WebSocketConfig) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:    cfg.ReadBufferSize,
+		WriteBufferSize:   cfg.WriteBufferSize,
+		EnableCompression: cfg.Compression,
+		Subprotocols:      webS.Subprotocols,
+		CheckOrigin:       originChecker(cfg.AllowedOrigins),
+	}
 }
 
-func NewWSHandler(ctx context.Context, router *webS.Router, hub *webS.Hub,
-	presence service.PresenceService, authz authz.AuthServiceInterface,
-	profileClient middleware_chat.ProfileClient) http.HandlerFunc {
+// originChecker защищает от cross-site WebSocket hijacking: чужая страница
+// не должна открывать соединение от имени пользователя.
+func originChecker(allowed []string) func(r *http.Request) bool {
+	origins := make(map[string]struct{}, len(allowed))
+	for _, o := range allowed {
+		origins[o] = struct{}{}
+	}
+	_, allowAll := origins["*"]
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		// Origin ставят только браузеры; мобильные и серверные клиенты его не шлют
+		if origin == "" || allowAll {
+			return true
+		}
+
+		if _, ok := origins[strings.ToLower(origin)]; ok {
+			return true
+		}
+
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+}
+
+func NewWSHandler(ctx context.Context, cfg *config.WebSocketConfig, router *webS.Router, hub *webS.Hub,
+	presence service.PresenceService, sessions session.Store, tickets ticket.Store,
+	authz authz.AuthServiceInterface, profileClient middleware_chat.ProfileClient) http.HandlerFunc {
+	upgrader := newUpgrader(cfg)
+
 	return func(w http.ResponseWriter, r *http.Request) {
 
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "missing authorization header", http.StatusUnauthorized)
+		if hub.Draining() {
+			w.Header().Set("Retry-After", "2")
+			http.Error(w, "server is restarting", http.StatusServiceUnavailable)
 			return
 		}
 
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			http.Error(w, "invalid authorization format", http.StatusUnauthorized)
+		// проверяем до погашения билета, чтобы чужая страница не могла его сжечь
+		if !upgrader.CheckOrigin(r) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
 			return
 		}
 
-		token := strings.TrimPrefix(authHeader, "Bearer ")
-
-		grpcCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		authCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
 
-		resp, err := profileClient.ValidateToken(grpcCtx, &profile.TokenRequest{
-			Token: token,
-		})
-
-		if err != nil || !resp.Valid {
-			http.Error(w, "invalid token", http.StatusUnauthorized)
+		userId, sessionId, expiresAt, err := authenticate(authCtx, r, tickets, profileClient)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
 
-		userId, _ := strconv.ParseInt(resp.UserId, 10, 64)
+		// сессия расходуется только после апгрейда: неудачный апгрейд или
+		// чужой токен не должны лишать владельца возможности возобновиться
+		resumeToken := r.URL.Query().Get("resume")
+		ok := ownsSession(authCtx, sessions, resumeToken, userId)
 
 		ws, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
 			return
 		}
 
-		conn := webS.NewConnection(ws, userId, presence, ctx, router, hub, authz)
+		var resumed session.State
+		if ok {
+			resumed, ok = takeSession(authCtx, sessions, resumeToken, userId)
+		}
+		if cfg.Compression {
+			_ = ws.SetCompressionLevel(cfg.CompressionLevel)
+		}
+
+		conn := webS.NewConnection(ws, userId, clientInfo(r), presence, sessions, ctx, router, hub, authz)
+		conn.SetAuthExpiry(expiresAt)
+		conn.SetSessionId(sessionId)
+		if ok {
+			conn.Resume(resumeToken, resumed)
+		}
 		conn.Start()
 	}
 }
+
+// authenticate принимает одноразовый билет (?ticket=, для браузеров)
+// или JWT в заголовке Authorization (мобильные и серверные клиенты).
+// Возвращает пользователя, сессию входа и срок действия его токена (unix, секунды).
+func authenticate(ctx context.Context, r *http.Request, tickets ticket.Store,
+	profileClient middleware_chat.ProfileClient) (int64, int64, int64, error) {
+	if t := r.URL.Query().Get("ticket"); t != "" {
+		claims, err := tickets.Redeem(ctx, t)
+		if err != nil {
+			return 0, 0, 0, errors.New("invalid ticket")
+		}
+		return claims.UserId, claims.SessionId, claims.ExpiresAt, nil
+	}
+
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return 0, 0, 0, errors.New("missing authorization header")
+	}
+
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return 0, 0, 0, errors.New("invalid authorization format")
+	}
+
+	resp, err := profileClient.ValidateToken(ctx, &profile.TokenRequest{
+		Token: strings.TrimPrefix(authHeader, "Bearer "),
+	})
+	if err != nil || !resp.Valid {
+		return 0, 0, 0, errors.New("invalid token")
+	}
+
+	userId, err := strconv.ParseInt(resp.UserId, 10, 64)
+	return userId, resp.SessionId, resp.ExpiresAt, err
+}
+
+// ownsSession проверяет, что сессию возобновляет её владелец, не расходуя её.
+// Истёкший или чужой токен не ошибка: клиент просто получит новую сессию.
+func ownsSession(ctx context.Context, sessions session.Store, token string, userId int64) bool {
+	if token == "" {
+		return false
+	}
+
+	st, err := sessions.Peek(ctx, token)
+	if err != nil {
+		return false
+	}
+
+	if st.UserId != userId {
+		log.Printf("[ws] user %d tried to resume session of user %d", userId, st.UserId)
+		return false
+	}
+
+	return true
+}
+
+// takeSession забирает состояние возобновляемой сессии. Между Peek и Take
+// сессию мог забрать параллельный запрос — тогда клиент получит новую.
+func takeSession(ctx context.Context, sessions session.Store, token string, userId int64) (session.State, bool) {
+	st, err := sessions.Take(ctx, token)
+	if err != nil {
+		return st, false
+	}
+
+	return st, st.UserId == userId
+}
+
+// clientInfo читает тип устройства и версию клиента из query-параметров
+// (браузеры не могут задать заголовки при открытии WebSocket) или из заголовков.
+func clientInfo(r *http.Request) webS.ClientInfo {
+	query := r.URL.Query()
+
+	device := query.Get("device")
+	if device == "" {
+		device = r.Header.Get("X-Client-Device")
+	}
+
+	appVersion := query.Get("app_version")
+	if appVersion == "" {
+		appVersion = r.Header.Get("X-Client-Version")
+	}
+
+	return webS.ClientInfo{
+		Device:     string(sDto.ParseDevice(device)),
+		AppVersion: sDto.ParseAppVersion(appVersion),
+	}
+}
@@ -0,0 +1,16 @@
+package helper
+
+import (
+	"chat_service/internal/websocket/dto"
+	"encoding/json"
+)
+
+func BuildErrorWS(payload dto.ErrorPayload) []byte {
+	data, _ := json.Marshal(payload)
+
+	msg, _ := json.Marshal(dto.WSMessage{
+		Type:    dto.MessageError,
+		Payload: data,
+	})
+	return msg
+}
@@ -0,0 +1,16 @@
+package helper
+
+import (
+	"chat_service/internal/websocket/dto"
+	"encoding/json"
+)
+
+func BuildSystemWS(payload dto.SystemPayload) []byte {
+	data, _ := json.Marshal(payload)
+
+	msg, _ := json.Marshal(dto.WSMessage{
+		Type:    dto.MessageSystem,
+		Payload: data,
+	})
+	return msg
+}
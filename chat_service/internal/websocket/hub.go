@@ -3,29 +3,62 @@ package websocket
 import (
 	"chat_service/internal/presence/service"
 	"chat_service/internal/pubsub"
+	"chat_service/internal/websocket/config"
 	"chat_service/internal/websocket/dto"
+	"chat_service/internal/websocket/helper"
+	"chat_service/internal/websocket/ratelimit"
 	"context"
 	"encoding/json"
 	"log"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 const (
-	writeWait      = 10 * time.Second
-	pongWait       = 60 * time.Second
-	pingPeriod     = pongWait * 9 / 10
-	maxMessageSize = 8 * 1024 // 8KB
+	closeSlowConsumer = websocket.CloseTryAgainLater
+
+	// drainReconnectDelay — базовая задержка переподключения, которую сервер
+	// подсказывает клиентам при drain; к ней добавляется случайный разброс.
+	drainReconnectDelay = 2 * time.Second
+	drainPollInterval   = 50 * time.Millisecond
 )
 
+// SlowConsumerPolicy определяет, что делать с соединением, чей буфер отправки переполнен.
+type SlowConsumerPolicy int
+
+const (
+	// DropSlowConsumer отбрасывает сообщение, соединение остаётся открытым.
+	DropSlowConsumer SlowConsumerPolicy = iota
+	// DisconnectSlowConsumer закрывает соединение с кодом 1013 (try again later).
+	DisconnectSlowConsumer
+)
+
+// Hub не владеет состоянием через единственную горутину: индексы пользователей,
+// комнат и подписок на presence шардированы и защищены блокировками, поэтому
+// его методы можно вызывать из read-горутин соединений. Отправка никогда не
+// блокирует отправителя — см. Connection.Enqueue.
 type Hub struct {
-	register   chan *Connection
-	unregister chan *Connection
+	users    *connIndex
+	rooms    *connIndex
+	watchers *connIndex // user_id, на чей presence подписаны соединения
 
-	users map[int64]map[*Connection]struct{}
+	connCount atomic.Int64
+	dropped   atomic.Int64
+	bytesIn   atomic.Int64
+	bytesOut  atomic.Int64
+	draining  atomic.Bool
 
-	rooms map[int64]map[*Connection]struct{}
+	// suspended — оборванные соединения, ждущие возобновления: token -> *Connection
+	suspended sync.Map
 
-	connections map[*Connection]struct{}
+	slowConsumer SlowConsumerPolicy
+	cfg          *config.WebSocketConfig
+	limiter      *ratelimit.Limiter
+	lastSeen     *lastSeenCache
 
 	Pubsub     pubsub.PubSub
 	InstanceId string
@@ -33,15 +66,17 @@ type Hub struct {
 	presenceSub service.PresenceSubscriber
 }
 
-func NewHub(presenceSub service.PresenceSubscriber, pub pubsub.PubSub, instanceId string) *Hub {
+func NewHub(presenceSub service.PresenceSubscriber, pub pubsub.PubSub, instanceId string,
+	slowConsumer SlowConsumerPolicy, cfg *config.WebSocketConfig, limiter *ratelimit.Limiter, lastSeen LastSeenChecker) *Hub {
 	return &Hub{
-		register:   make(chan *Connection),
-		unregister: make(chan *Connection),
-
-		users: make(map[int64]map[*Connection]struct{}),
-		rooms: make(map[int64]map[*Connection]struct{}),
+		users:    newConnIndex(),
+		rooms:    newConnIndex(),
+		watchers: newConnIndex(),
 
-		connections: make(map[*Connection]struct{}),
+		slowConsumer: slowConsumer,
+		cfg:          cfg,
+		limiter:      limiter,
+		lastSeen:     newLastSeenCache(lastSeen),
 
 		Pubsub:     pub,
 		InstanceId: instanceId,
@@ -62,17 +97,16 @@ func (h *Hub) Run(ctx context.Context) {
 		panic(err)
 	}
 
+	controlCh, err := h.Pubsub.Subscribe(ctx, controlChannel)
+	if err != nil {
+		panic(err)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 
-		case c := <-h.register:
-			h.addConnection(c)
-
-		case c := <-h.unregister:
-			h.removeConnection(c)
-
 		case evt := <-h.presenceSub:
 			h.broadcastPresence(evt)
 
@@ -81,41 +115,139 @@ func (h *Hub) Run(ctx context.Context) {
 
 		case raw := <-roomCh:
 			h.handleRedisRoom(raw)
+
+		case raw := <-controlCh:
+			h.handleControl(raw)
 		}
 	}
 }
 
 func (h *Hub) RegisterConnection(c *Connection) {
-	h.register <- c
+	total := h.users.Add(c.UserId, c)
+	h.connCount.Add(1)
+
+	log.Printf("[hub] user %d connected, total connections: %d", c.UserId, total)
 }
 
+// UnregisterConnection убирает соединение из всех индексов, включая комнаты
+// и подписки на presence.
 func (h *Hub) UnregisterConnection(c *Connection) {
-	h.unregister <- c
+	h.users.Remove(c.UserId, c)
+
+	rooms, watched := c.memberships()
+	for _, roomId := range rooms {
+		h.rooms.Remove(roomId, c)
+	}
+	for _, userId := range watched {
+		h.watchers.Remove(userId, c)
+	}
+
+	h.connCount.Add(-1)
+
+	log.Printf("[hub] user %d disconnected", c.UserId)
+}
+
+// Draining сообщает, что инстанс останавливается и не принимает новые соединения.
+func (h *Hub) Draining() bool {
+	return h.draining.Load()
 }
 
-func (h *Hub) addConnection(c *Connection) {
-	h.connections[c] = struct{}{}
+// Drain переводит Hub в режим остановки: каждому соединению отправляется
+// system-кадр reconnect, после чего оно закрывается с кодом 1012 (service restart).
+// Ждёт закрытия всех соединений или отмены ctx и возвращает id затронутых пользователей.
+func (h *Hub) Drain(ctx context.Context) []int64 {
+	h.draining.Store(true)
+
+	conns := h.users.All()
+	users := make(map[int64]struct{}, len(conns))
+
+	// приостановленные сессии тоже передаются другому инстансу
+	h.suspended.Range(func(token, c any) bool {
+		if _, ok := h.suspended.LoadAndDelete(token); ok {
+			c.(*Connection).stopSuspended()
+		}
+		return true
+	})
 
-	if h.users[c.UserId] == nil {
-		h.users[c.UserId] = make(map[*Connection]struct{})
+	for _, c := range conns {
+		// разброс, чтобы клиенты не переподключались одновременно
+		delay := drainReconnectDelay + rand.N(drainReconnectDelay)
+
+		c.Enqueue(helper.BuildSystemWS(dto.SystemPayload{
+			Event:        dto.SystemReconnect,
+			RetryAfterMs: delay.Milliseconds(),
+		}))
+		c.kick(websocket.CloseServiceRestart, "server restart")
+
+		users[c.UserId] = struct{}{}
 	}
-	h.users[c.UserId][c] = struct{}{}
 
-	log.Printf("[hub] user %d connected, total connections: %d",
-		c.UserId, len(h.users[c.UserId]))
-}
+	log.Printf("[hub] draining %d connections of %d users", len(conns), len(users))
 
-func (h *Hub) removeConnection(c *Connection) {
-	delete(h.connections, c)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
 
-	if conns, ok := h.users[c.UserId]; ok {
-		delete(conns, c)
-		if len(conns) == 0 {
-			delete(h.users, c.UserId)
+	for h.ConnectionCount() > 0 {
+		select {
+		case <-ctx.Done():
+			log.Printf("[hub] drain interrupted, %d connections left", h.ConnectionCount())
+			return mapKeys(users)
+		case <-ticker.C:
 		}
 	}
 
-	log.Printf("[hub] user %d disconnected", c.UserId)
+	return mapKeys(users)
+}
+
+func mapKeys(m map[int64]struct{}) []int64 {
+	keys := make([]int64, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (h *Hub) suspend(token string, c *Connection) {
+	h.suspended.Store(token, c)
+}
+
+func (h *Hub) takeSuspended(token string) *Connection {
+	c, ok := h.suspended.LoadAndDelete(token)
+	if !ok {
+		return nil
+	}
+	return c.(*Connection)
+}
+
+// ConnectionCount — число соединений на этом инстансе, включая приостановленные.
+func (h *Hub) ConnectionCount() int64 {
+	return h.connCount.Load()
+}
+
+// DroppedMessages — сколько сообщений не доставлено медленным клиентам.
+func (h *Hub) DroppedMessages() int64 {
+	return h.dropped.Load()
+}
+
+// Subscribe подписывает соединение на presence тех userIds, чью активность
+// разрешено видеть его пользователю, и возвращает их.
+func (h *Hub) Subscribe(ctx context.Context, c *Connection, userIds []int64) []int64 {
+	allowed := h.lastSeen.Visible(ctx, c.UserId, userIds)
+	for _, id := range allowed {
+		c.watch(id, h.watchers)
+	}
+	return allowed
+}
+
+// LastSeenVisible — из userIds те, чей last seen виден viewerId.
+func (h *Hub) LastSeenVisible(ctx context.Context, viewerId int64, userIds []int64) []int64 {
+	return h.lastSeen.Visible(ctx, viewerId, userIds)
+}
+
+func (h *Hub) Unsubscribe(c *Connection, userIds []int64) {
+	for _, id := range userIds {
+		c.unwatch(id, h.watchers)
+	}
 }
 
 func (h *Hub) broadcastPresence(evt service.PresenceEvent) {
@@ -137,45 +269,56 @@ func (h *Hub) broadcastPresence(evt service.PresenceEvent) {
 		return
 	}
 
-	log.Printf("[presence] broadcasting %s for user %d to %d connections",
-		evt.Type, evt.UserId, len(h.connections))
+	// видимость могла смениться после подписки: проверяем каждого подписчика
+	ctx, cancel := context.WithTimeout(context.Background(), lastSeenCheckTimeout)
+	defer cancel()
 
-	for c := range h.connections {
-		if _, ok := c.Subscribed[evt.UserId]; ok {
-			c.Send <- msg
+	watchers := h.watchers.Snapshot(evt.UserId)
+	allowed := watchers[:0]
+	for _, w := range watchers {
+		if len(h.lastSeen.Visible(ctx, w.UserId, []int64{evt.UserId})) > 0 {
+			allowed = append(allowed, w)
 		}
 	}
+	watchers = allowed
+
+	log.Printf("[presence] broadcasting %s for user %d to %d connections",
+		evt.Type, evt.UserId, len(watchers))
+
+	h.deliver(watchers, msg)
 }
 
 func (h *Hub) SendToUser(userId int64, msg []byte) {
-	if conns, ok := h.users[userId]; ok {
-		for c := range conns {
-			c.Send <- msg
-		}
-	}
+	h.deliver(h.users.Snapshot(userId), msg)
 }
 
 func (h *Hub) JoinRoom(roomId int64, c *Connection) {
-	if h.rooms[roomId] == nil {
-		h.rooms[roomId] = make(map[*Connection]struct{})
-	}
-	h.rooms[roomId][c] = struct{}{}
+	c.join(roomId, h.rooms)
 }
 
 func (h *Hub) LeaveRoom(roomId int64, c *Connection) {
-	if members, ok := h.rooms[roomId]; ok {
-		delete(members, c)
-		if len(members) == 0 {
-			delete(h.rooms, roomId)
-		}
-	}
+	c.leave(roomId, h.rooms)
 }
 
 func (h *Hub) BroadcastToRoom(roomId int64, msg []byte) {
-	if members, ok := h.rooms[roomId]; ok {
-		for c := range members {
-			c.Send <- msg
-		}
+	h.deliver(h.rooms.Snapshot(roomId), msg)
+}
+
+// deliver ставит кадр в очереди соединений; тело для бинарного подпротокола
+// кодируется один раз на всю рассылку.
+func (h *Hub) deliver(conns []*Connection, msg []byte) {
+	shared := newSharedFrame(msg)
+	for _, c := range conns {
+		c.enqueue(outFrame{msg: msg, shared: shared})
+	}
+}
+
+// onSlowConsumer вызывается из Connection.Enqueue, когда буфер отправки полон.
+func (h *Hub) onSlowConsumer(c *Connection) {
+	h.dropped.Add(1)
+
+	if h.slowConsumer == DisconnectSlowConsumer && c.kick(closeSlowConsumer, "slow consumer") {
+		log.Printf("[hub] user %d disconnected as slow consumer", c.UserId)
 	}
 }
 
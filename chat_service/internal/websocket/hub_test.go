@@ -0,0 +1,653 @@
+package websocket
+
+import (
+	"chat_service/internal/presence/service"
+	"chat_service/internal/websocket/config"
+	"chat_service/internal/websocket/dto"
+	"chat_service/internal/websocket/helper"
+	"chat_service/internal/websocket/session"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMain(m *testing.M) {
+	// Hub логирует каждое подключение — в стресс-тестах это только шум
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// allowAll разрешает видеть активность всех пользователей.
+type allowAll struct{}
+
+func (allowAll) CanViewLastSeen(ctx context.Context, viewerId int64, userIds []int64) ([]int64, error) {
+	return userIds, nil
+}
+
+func newTestHub(policy SlowConsumerPolicy) *Hub {
+	return NewHub(make(service.PresenceSubscriber), nil, "test", policy, config.Defaults(), nil, allowAll{})
+}
+
+func newTestConnection(h *Hub, userId int64, sendBuf int) *Connection {
+	return &Connection{
+		Send:     make(chan outFrame, sendBuf),
+		UserId:   userId,
+		Hub:      h,
+		rooms:    make(map[int64]struct{}),
+		watching: make(map[int64]struct{}),
+		done:     make(chan struct{}),
+		released: make(chan struct{}),
+		kicked:   make(chan struct{}),
+	}
+}
+
+// drain читает очередь соединения, пока оно не закрыто.
+func drain(c *Connection, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for {
+		select {
+		case <-c.Send:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func TestSlowConsumerDrop(t *testing.T) {
+	h := newTestHub(DropSlowConsumer)
+	c := newTestConnection(h, 1, 1)
+	h.RegisterConnection(c)
+
+	h.SendToUser(1, []byte("a"))
+	h.SendToUser(1, []byte("b")) // буфер полон — сообщение отбрасывается, отправитель не блокируется
+
+	if got := h.DroppedMessages(); got != 1 {
+		t.Fatalf("expected 1 dropped message, got %d", got)
+	}
+	select {
+	case <-c.kicked:
+		t.Fatal("drop policy must not disconnect the client")
+	default:
+	}
+}
+
+func TestSlowConsumerDisconnect(t *testing.T) {
+	h := newTestHub(DisconnectSlowConsumer)
+	c := newTestConnection(h, 1, 1)
+	h.RegisterConnection(c)
+
+	for i := 0; i < 3; i++ {
+		h.SendToUser(1, []byte("msg"))
+	}
+
+	select {
+	case <-c.kicked:
+	default:
+		t.Fatal("slow consumer was not disconnected")
+	}
+	if c.kickCode != closeSlowConsumer {
+		t.Fatalf("expected close code %d, got %d", closeSlowConsumer, c.kickCode)
+	}
+}
+
+func TestUnregisterCleansRoomsAndWatchers(t *testing.T) {
+	h := newTestHub(DropSlowConsumer)
+	c := newTestConnection(h, 1, 4)
+	h.RegisterConnection(c)
+	h.JoinRoom(10, c)
+	h.Subscribe(context.Background(), c, []int64{2})
+
+	h.UnregisterConnection(c)
+
+	if n := len(h.rooms.Snapshot(10)); n != 0 {
+		t.Fatalf("room still has %d members", n)
+	}
+	if n := len(h.watchers.Snapshot(2)); n != 0 {
+		t.Fatalf("user 2 still has %d watchers", n)
+	}
+
+	// после закрытия соединение не должно возвращаться в индексы
+	h.JoinRoom(11, c)
+	if n := len(h.rooms.Snapshot(11)); n != 0 {
+		t.Fatal("closed connection joined a room")
+	}
+}
+
+// friendsOnly — у всех пользователей last seen виден только друзьям.
+type friendsOnly struct {
+	mu      sync.Mutex
+	friends map[[2]int64]bool
+}
+
+func (f *friendsOnly) CanViewLastSeen(ctx context.Context, viewerId int64, userIds []int64) ([]int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var allowed []int64
+	for _, id := range userIds {
+		if f.friends[[2]int64{viewerId, id}] {
+			allowed = append(allowed, id)
+		}
+	}
+	return allowed, nil
+}
+
+func TestPresenceEventsOnlyForAllowedViewers(t *testing.T) {
+	policy := &friendsOnly{friends: map[[2]int64]bool{{2, 3}: true}}
+	h := NewHub(make(service.PresenceSubscriber), nil, "test", DropSlowConsumer, config.Defaults(), nil, policy)
+	now := time.Unix(1_700_000_000, 0)
+	h.lastSeen.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	stranger := newTestConnection(h, 1, 4)
+	friend := newTestConnection(h, 2, 4)
+	h.RegisterConnection(stranger)
+	h.RegisterConnection(friend)
+
+	if got := h.Subscribe(ctx, stranger, []int64{3}); len(got) != 0 {
+		t.Fatalf("non-friend must not subscribe, got %v", got)
+	}
+	if got := h.Subscribe(ctx, friend, []int64{3}); len(got) != 1 {
+		t.Fatalf("friend must subscribe, got %v", got)
+	}
+
+	h.broadcastPresence(service.PresenceEvent{Type: service.EventUserOffline, UserId: 3})
+	if n := len(stranger.Send); n != 0 {
+		t.Fatalf("non-friend received %d presence events", n)
+	}
+	if n := len(friend.Send); n != 1 {
+		t.Fatalf("friend expected 1 presence event, got %d", n)
+	}
+
+	// после разрыва дружбы события перестают приходить, как только истечёт кэш
+	policy.mu.Lock()
+	delete(policy.friends, [2]int64{2, 3})
+	policy.mu.Unlock()
+	now = now.Add(lastSeenTTL + time.Second)
+
+	h.broadcastPresence(service.PresenceEvent{Type: service.EventUserIdle, UserId: 3})
+	if n := len(friend.Send); n != 1 {
+		t.Fatalf("former friend must not receive new events, got %d", n)
+	}
+}
+
+// TestHubConcurrentAccess рассчитан на запуск с -race: чтение и изменение
+// индексов идут одновременно из множества горутин, как из read-циклов соединений.
+func TestHubConcurrentAccess(t *testing.T) {
+	const (
+		users   = 200
+		rooms   = 20
+		workers = 16
+		ops     = 2000
+	)
+
+	h := newTestHub(DropSlowConsumer)
+
+	var drainers sync.WaitGroup
+	conns := make([]*Connection, users)
+	for i := range conns {
+		conns[i] = newTestConnection(h, int64(i), 8)
+		h.RegisterConnection(conns[i])
+		drainers.Add(1)
+		go drain(conns[i], &drainers)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < ops; i++ {
+				c := conns[rand.IntN(users)]
+				target := int64(rand.IntN(users))
+				roomId := int64(rand.IntN(rooms))
+
+				switch rand.IntN(7) {
+				case 0:
+					h.JoinRoom(roomId, c)
+				case 1:
+					h.LeaveRoom(roomId, c)
+				case 2:
+					h.Subscribe(context.Background(), c, []int64{target})
+				case 3:
+					h.Unsubscribe(c, []int64{target})
+				case 4:
+					h.SendToUser(target, []byte("direct"))
+				case 5:
+					h.BroadcastToRoom(roomId, []byte("room"))
+				case 6:
+					h.broadcastPresence(service.PresenceEvent{Type: service.EventUserOnline, UserId: target})
+				}
+			}
+		}()
+	}
+
+	// параллельно подключаются и отключаются новые соединения
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < ops; i++ {
+			c := newTestConnection(h, int64(users+i), 1)
+			h.RegisterConnection(c)
+			h.JoinRoom(int64(i%rooms), c)
+			h.Subscribe(context.Background(), c, []int64{int64(i % users)})
+			h.UnregisterConnection(c)
+		}
+	}()
+
+	wg.Wait()
+
+	for _, c := range conns {
+		h.UnregisterConnection(c)
+		close(c.done)
+	}
+	drainers.Wait()
+
+	if got := h.ConnectionCount(); got != 0 {
+		t.Fatalf("expected 0 connections, got %d", got)
+	}
+	for r := int64(0); r < rooms; r++ {
+		if n := len(h.rooms.Snapshot(r)); n != 0 {
+			t.Fatalf("room %d still has %d members", r, n)
+		}
+	}
+}
+
+func benchmarkHub(b *testing.B, n int) (*Hub, []*Connection, func()) {
+	b.Helper()
+
+	h := newTestHub(DropSlowConsumer)
+
+	var drainers sync.WaitGroup
+	conns := make([]*Connection, n)
+	for i := range conns {
+		conns[i] = newTestConnection(h, int64(i), 256)
+		h.RegisterConnection(conns[i])
+		drainers.Add(1)
+		go drain(conns[i], &drainers)
+	}
+
+	stop := func() {
+		for _, c := range conns {
+			close(c.done)
+		}
+		drainers.Wait()
+	}
+
+	return h, conns, stop
+}
+
+func BenchmarkBroadcastToRoom(b *testing.B) {
+	for _, n := range []int{1_000, 10_000} {
+		b.Run(fmt.Sprintf("members=%d", n), func(b *testing.B) {
+			h, conns, stop := benchmarkHub(b, n)
+			defer stop()
+
+			for _, c := range conns {
+				h.JoinRoom(1, c)
+			}
+			msg := []byte("room message")
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				h.BroadcastToRoom(1, msg)
+			}
+			b.StopTimer()
+
+			b.ReportMetric(float64(h.DroppedMessages())/float64(b.N), "dropped/op")
+		})
+	}
+}
+
+func BenchmarkSendToUserParallel(b *testing.B) {
+	const n = 10_000
+
+	h, _, stop := benchmarkHub(b, n)
+	defer stop()
+
+	msg := []byte("direct message")
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			h.SendToUser(int64(rand.IntN(n)), msg)
+		}
+	})
+}
+
+func BenchmarkRegisterUnregister(b *testing.B) {
+	const n = 10_000
+
+	h := newTestHub(DropSlowConsumer)
+	conns := make([]*Connection, n)
+	for i := range conns {
+		conns[i] = newTestConnection(h, int64(i), 1)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c := conns[rand.IntN(n)]
+			h.RegisterConnection(c)
+			h.UnregisterConnection(c)
+		}
+	})
+}
+
+func TestDrainSendsReconnectAndCloses(t *testing.T) {
+	h := newTestHub(DropSlowConsumer)
+	c := newTestConnection(h, 1, 4)
+	h.RegisterConnection(c)
+
+	// write-цикл: после kick соединение снимается с учёта
+	go func() {
+		<-c.kicked
+		h.UnregisterConnection(c)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	users := h.Drain(ctx)
+	if len(users) != 1 || users[0] != 1 {
+		t.Fatalf("expected handed off user 1, got %v", users)
+	}
+	if !h.Draining() {
+		t.Fatal("hub must stay in drain mode")
+	}
+	if c.kickCode != websocket.CloseServiceRestart {
+		t.Fatalf("expected close code %d, got %d", websocket.CloseServiceRestart, c.kickCode)
+	}
+
+	var msg dto.WSMessage
+	if err := json.Unmarshal((<-c.Send).msg, &msg); err != nil || msg.Type != dto.MessageSystem {
+		t.Fatalf("expected system frame, got %+v (err %v)", msg, err)
+	}
+	var payload dto.SystemPayload
+	_ = json.Unmarshal(msg.Payload, &payload)
+	if payload.Event != dto.SystemReconnect || payload.RetryAfterMs < drainReconnectDelay.Milliseconds() {
+		t.Fatalf("unexpected reconnect payload %+v", payload)
+	}
+}
+
+func seqOf(t *testing.T, frame []byte) int64 {
+	t.Helper()
+
+	var msg dto.WSMessage
+	if err := json.Unmarshal(frame, &msg); err != nil {
+		t.Fatalf("invalid frame %s: %v", frame, err)
+	}
+	return msg.Seq
+}
+
+func TestSequenceGapAndResend(t *testing.T) {
+	h := newTestHub(DropSlowConsumer)
+	c := newTestConnection(h, 1, 2)
+	h.RegisterConnection(c)
+
+	frame := helper.BuildSystemWS(dto.SystemPayload{Event: dto.SystemReconnect})
+	for i := 0; i < 3; i++ {
+		h.SendToUser(1, frame)
+	}
+
+	// третий кадр отброшен, но номер за ним закреплён
+	if a, b := seqOf(t, (<-c.Send).msg), seqOf(t, (<-c.Send).msg); a != 1 || b != 2 {
+		t.Fatalf("expected seq 1,2, got %d,%d", a, b)
+	}
+
+	if _, ok := c.Resend(3); !ok {
+		t.Fatal("frame 3 must be available for resend")
+	}
+	if seq := seqOf(t, (<-c.Send).msg); seq != 3 {
+		t.Fatalf("expected resent seq 3, got %d", seq)
+	}
+
+	for i := 0; i < resendWindow; i++ {
+		h.SendToUser(1, frame)
+		<-c.Send
+	}
+	if oldest, ok := c.Resend(1); ok || oldest != 4 {
+		t.Fatalf("expected evicted frames, got oldest=%d ok=%v", oldest, ok)
+	}
+}
+
+func TestResendAfterResumeRejectsFramesOfPreviousConnection(t *testing.T) {
+	h := newTestHub(DropSlowConsumer)
+	c := newTestConnection(h, 1, 8)
+	h.RegisterConnection(c)
+
+	// как после Resume: нумерация продолжается с LastSeq прежнего соединения
+	c.seq = 10
+
+	frame := helper.BuildSystemWS(dto.SystemPayload{Event: dto.SystemReconnect})
+	h.SendToUser(1, frame)
+	h.SendToUser(1, frame)
+	<-c.Send
+	<-c.Send
+
+	for _, from := range []int64{1, 10} {
+		if oldest, ok := c.Resend(from); ok || oldest != 11 {
+			t.Fatalf("resend_from %d: expected unavailable with oldest 11, got oldest=%d ok=%v", from, oldest, ok)
+		}
+	}
+	if len(c.Send) != 0 {
+		t.Fatal("no frames must be queued for unavailable resend")
+	}
+
+	if _, ok := c.Resend(11); !ok {
+		t.Fatal("frames of the resumed connection must be available")
+	}
+	if a, b := seqOf(t, (<-c.Send).msg), seqOf(t, (<-c.Send).msg); a != 11 || b != 12 {
+		t.Fatalf("expected resent seq 11,12, got %d,%d", a, b)
+	}
+}
+
+func TestSuspendRenumbersUnsentFrames(t *testing.T) {
+	h := newTestHub(DropSlowConsumer)
+	c := newTestConnection(h, 1, 8)
+	h.RegisterConnection(c)
+
+	frame := helper.BuildSystemWS(dto.SystemPayload{Event: dto.SystemReconnect})
+	h.SendToUser(1, frame)
+	<-c.Send // seq 1 дошёл до клиента
+	h.SendToUser(1, frame)
+	h.SendToUser(1, frame)
+
+	if last := c.suspendSequence(); last != 1 {
+		t.Fatalf("expected last delivered seq 1, got %d", last)
+	}
+
+	h.SendToUser(1, frame)
+	for i := 0; i < 3; i++ {
+		if seq := seqOf(t, (<-c.Send).msg); seq != 0 {
+			t.Fatalf("suspended connection must queue unnumbered frames, got seq %d", seq)
+		}
+	}
+}
+
+func TestAuthExpiryWarnsAndCloses(t *testing.T) {
+	h := newTestHub(DropSlowConsumer)
+	c := newTestConnection(h, 1, 4)
+	h.RegisterConnection(c)
+	defer close(c.done)
+
+	c.SetAuthExpiry(time.Now().Add(time.Second).Unix())
+	go c.authLoop()
+
+	var msg dto.WSMessage
+	if err := json.Unmarshal((<-c.Send).msg, &msg); err != nil || msg.Type != dto.MessageSystem {
+		t.Fatalf("expected system frame, got %+v (err %v)", msg, err)
+	}
+	var payload dto.SystemPayload
+	_ = json.Unmarshal(msg.Payload, &payload)
+	if payload.Event != dto.SystemReauthRequired || payload.ExpiresAt == 0 {
+		t.Fatalf("unexpected reauth payload %+v", payload)
+	}
+
+	select {
+	case <-c.kicked:
+	case <-time.After(3 * time.Second):
+		t.Fatal("connection with expired token must be closed")
+	}
+	if c.kickCode != closeTokenExpired {
+		t.Fatalf("expected close code %d, got %d", closeTokenExpired, c.kickCode)
+	}
+}
+
+func TestDisconnectUserKicksOnlyThatUser(t *testing.T) {
+	h := newTestHub(DropSlowConsumer)
+	a1 := newTestConnection(h, 1, 1)
+	a2 := newTestConnection(h, 1, 1)
+	b := newTestConnection(h, 2, 1)
+	for _, c := range []*Connection{a1, a2, b} {
+		h.RegisterConnection(c)
+	}
+
+	if n := h.DisconnectUser(1, SessionFilter{}, "account_deleted"); n != 2 {
+		t.Fatalf("expected 2 connections closed, got %d", n)
+	}
+	for _, c := range []*Connection{a1, a2} {
+		if c.kickCode != closeSessionRevoked || c.kickText != "account_deleted" {
+			t.Fatalf("unexpected close %d %q", c.kickCode, c.kickText)
+		}
+	}
+
+	select {
+	case <-b.kicked:
+		t.Fatal("other users must stay connected")
+	default:
+	}
+}
+
+func TestDisconnectUserSessionKeepsOtherDevices(t *testing.T) {
+	h := newTestHub(DropSlowConsumer)
+	phone := newTestConnection(h, 1, 1)
+	laptop := newTestConnection(h, 1, 1)
+	phone.SetSessionId(10)
+	laptop.SetSessionId(11)
+	for _, c := range []*Connection{phone, laptop} {
+		h.RegisterConnection(c)
+	}
+
+	if n := h.DisconnectUser(1, SessionFilter{SessionId: 10}, "session_revoked"); n != 1 {
+		t.Fatalf("expected 1 connection closed, got %d", n)
+	}
+	if phone.kickCode != closeSessionRevoked {
+		t.Fatalf("revoked session must be closed with %d, got %d", closeSessionRevoked, phone.kickCode)
+	}
+
+	select {
+	case <-laptop.kicked:
+		t.Fatal("other sessions of the user must stay connected")
+	default:
+	}
+}
+
+func TestDisconnectUserExceptKeepsCurrentSession(t *testing.T) {
+	h := newTestHub(DropSlowConsumer)
+	phone := newTestConnection(h, 1, 1)
+	laptop := newTestConnection(h, 1, 1)
+	phone.SetSessionId(10)
+	laptop.SetSessionId(11)
+	for _, c := range []*Connection{phone, laptop} {
+		h.RegisterConnection(c)
+	}
+
+	// смена пароля с ноутбука: остальные устройства выходят, ноутбук остаётся
+	if n := h.DisconnectUser(1, SessionFilter{ExceptSessionId: 11}, "tokens_revoked"); n != 1 {
+		t.Fatalf("expected 1 connection closed, got %d", n)
+	}
+	if phone.kickCode != closeSessionRevoked {
+		t.Fatalf("other session must be closed with %d, got %d", closeSessionRevoked, phone.kickCode)
+	}
+
+	select {
+	case <-laptop.kicked:
+		t.Fatal("kept session must stay connected")
+	default:
+	}
+}
+
+// memPubSub — pub/sub в памяти, общий для нескольких Hub, как Redis для инстансов.
+type memPubSub struct {
+	mu   sync.Mutex
+	subs map[string][]chan []byte
+}
+
+func newMemPubSub() *memPubSub {
+	return &memPubSub{subs: make(map[string][]chan []byte)}
+}
+
+func (m *memPubSub) Publish(ctx context.Context, channel string, payload []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, ch := range m.subs[channel] {
+		ch <- payload
+	}
+	return nil
+}
+
+func (m *memPubSub) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch := make(chan []byte, 8)
+	m.subs[channel] = append(m.subs[channel], ch)
+	return ch, nil
+}
+
+func TestResumeOnOtherInstanceReleasesSuspendedConnection(t *testing.T) {
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	store := session.NewRedisStore(rdb)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pub := newMemPubSub()
+	oldHub := NewHub(make(service.PresenceSubscriber), pub, "old", DropSlowConsumer, config.Defaults(), nil, allowAll{})
+	newHub := NewHub(make(service.PresenceSubscriber), pub, "new", DropSlowConsumer, config.Defaults(), nil, allowAll{})
+	go oldHub.Run(ctx)
+	go newHub.Run(ctx)
+
+	ghost := newTestConnection(oldHub, 1, 8)
+	ghost.Sessions = store
+	ghost.sessionToken = "tok"
+	ghost.stopSuspend = make(chan struct{})
+	oldHub.RegisterConnection(ghost)
+	oldHub.JoinRoom(10, ghost)
+	ghost.suspend()
+
+	// новый инстанс забрал состояние и spool, как restore
+	if _, err := store.Take(ctx, "tok"); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = store.TakeSpooled(ctx, "tok")
+	time.Sleep(20 * time.Millisecond) // Run обоих Hub подписывается на control
+	newHub.announceResumed(ctx, "tok")
+
+	select {
+	case <-ghost.released:
+	case <-time.After(time.Second):
+		t.Fatal("suspended connection must be released after resume on another instance")
+	}
+	if n := len(oldHub.rooms.Snapshot(10)); n != 0 {
+		t.Fatalf("room still has %d members on the old instance", n)
+	}
+	if n := len(oldHub.users.Snapshot(1)); n != 0 {
+		t.Fatalf("user still has %d connections on the old instance", n)
+	}
+
+	oldHub.BroadcastToRoom(10, helper.BuildSystemWS(dto.SystemPayload{Event: dto.SystemReconnect}))
+	if frames, _ := store.TakeSpooled(ctx, "tok"); len(frames) != 0 {
+		t.Fatalf("old instance must stop spooling, got %d frames", len(frames))
+	}
+}
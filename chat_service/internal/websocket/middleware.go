@@ -0,0 +1,153 @@
+package websocket
+
+import (
+	"chat_service/internal/websocket/dto"
+	"chat_service/internal/websocket/helper"
+	"context"
+	"encoding/json"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Recover не даёт панике в обработчике оборвать соединение:
+// клиент получает internal_error, чтение продолжается.
+func Recover(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, c *Connection, msg dto.WSMessage) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[ws] panic handling %s from user %d: %v\n%s", msg.Type, c.UserId, r, debug.Stack())
+				c.Enqueue(helper.BuildErrorWS(dto.ErrorPayload{
+					Code: dto.ErrInternal,
+					Type: msg.Type,
+				}))
+			}
+		}()
+
+		next(ctx, c, msg)
+	}
+}
+
+func Logging(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, c *Connection, msg dto.WSMessage) {
+		start := time.Now()
+		next(ctx, c, msg)
+		log.Printf("[ws] %s from user %d handled in %s", msg.Type, c.UserId, time.Since(start))
+	}
+}
+
+// RateLimit применяет лимиты Hub к входящим сообщениям, см. Connection.allow.
+func RateLimit(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, c *Connection, msg dto.WSMessage) {
+		if !c.allow(ctx, msg.Type) {
+			return
+		}
+		next(ctx, c, msg)
+	}
+}
+
+// RequireAuth пропускает сообщения соединения с истёкшим токеном только
+// типа reauth: до продления токена остальные отклоняются с auth_expired.
+func RequireAuth(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, c *Connection, msg dto.WSMessage) {
+		if msg.Type != dto.MessageReauth && c.AuthExpired() {
+			c.Enqueue(helper.BuildErrorWS(dto.ErrorPayload{
+				Code: dto.ErrAuthExpired,
+				Type: msg.Type,
+			}))
+			return
+		}
+		next(ctx, c, msg)
+	}
+}
+
+// Validator проверяет нагрузку сообщения до обработчика.
+type Validator func(payload json.RawMessage) error
+
+// ValidatePayload отклоняет сообщения, не прошедшие валидатор своего типа,
+// с ошибкой invalid_payload. Типы без валидатора пропускаются как есть.
+func ValidatePayload(validators map[dto.MessageType]Validator) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, c *Connection, msg dto.WSMessage) {
+			if validate, ok := validators[msg.Type]; ok {
+				if err := validate(msg.Payload); err != nil {
+					c.Enqueue(helper.BuildErrorWS(dto.ErrorPayload{
+						Code:    dto.ErrInvalidPayload,
+						Type:    msg.Type,
+						Message: err.Error(),
+					}))
+					return
+				}
+			}
+			next(ctx, c, msg)
+		}
+	}
+}
+
+// MessageStats — счётчики обработки одного типа сообщений.
+type MessageStats struct {
+	Count       int64 `json:"count"`
+	TotalTimeUs int64 `json:"total_time_us"`
+	MaxTimeUs   int64 `json:"max_time_us"`
+}
+
+// unknownMetricsType — общий счётчик для типов без обработчика, чтобы
+// выдуманные клиентами типы не раздували карту.
+const unknownMetricsType dto.MessageType = "unknown"
+
+// MessageMetrics собирает счётчики по типам сообщений.
+type MessageMetrics struct {
+	known func(dto.MessageType) bool
+
+	mu    sync.Mutex
+	stats map[dto.MessageType]*MessageStats
+}
+
+// NewMessageMetrics считает отдельно только типы, для которых known == true,
+// обычно Router.Handles.
+func NewMessageMetrics(known func(dto.MessageType) bool) *MessageMetrics {
+	return &MessageMetrics{
+		known: known,
+		stats: make(map[dto.MessageType]*MessageStats),
+	}
+}
+
+func (m *MessageMetrics) Middleware(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, c *Connection, msg dto.WSMessage) {
+		start := time.Now()
+		next(ctx, c, msg)
+		m.observe(msg.Type, time.Since(start))
+	}
+}
+
+func (m *MessageMetrics) observe(t dto.MessageType, d time.Duration) {
+	if !m.known(t) {
+		t = unknownMetricsType
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.stats[t]
+	if !ok {
+		s = &MessageStats{}
+		m.stats[t] = s
+	}
+
+	us := d.Microseconds()
+	s.Count++
+	s.TotalTimeUs += us
+	s.MaxTimeUs = max(s.MaxTimeUs, us)
+}
+
+func (m *MessageMetrics) Snapshot() map[dto.MessageType]MessageStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make(map[dto.MessageType]MessageStats, len(m.stats))
+	for t, s := range m.stats {
+		snapshot[t] = *s
+	}
+	return snapshot
+}
@@ -0,0 +1,186 @@
+package ratelimit
+
+import (
+	"chat_service/internal/websocket/dto"
+	"context"
+	_ "embed"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+//go:embed scripts/tokenBucket.lua
+var tokenBucketLua string
+
+const (
+	// violationWindow и maxViolations определяют злостного нарушителя:
+	// столько отказов за окно — и соединение закрывается.
+	violationWindow = 10 * time.Second
+	maxViolations   = 20
+)
+
+// Limit — token bucket: Rate токенов в секунду, не больше Burst подряд.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Budget — лимиты одного типа сообщений: на соединение (в памяти)
+// и на пользователя суммарно по всем соединениям и инстансам (в Redis).
+type Budget struct {
+	Conn Limit
+	User Limit
+}
+
+// DefaultBudgets — лимиты по типам сообщений; для типов вне списка действует defaultBudget.
+var DefaultBudgets = map[dto.MessageType]Budget{
+	dto.MessageChat:     {Conn: Limit{Rate: 5, Burst: 10}, User: Limit{Rate: 10, Burst: 20}},
+	dto.MessagePresence: {Conn: Limit{Rate: 2, Burst: 10}, User: Limit{Rate: 4, Burst: 20}},
+	dto.MessageActivity: {Conn: Limit{Rate: 1, Burst: 5}, User: Limit{Rate: 3, Burst: 10}},
+	dto.MessageResend:   {Conn: Limit{Rate: 1, Burst: 3}, User: Limit{Rate: 2, Burst: 6}},
+	dto.MessageReauth:   {Conn: Limit{Rate: 0.1, Burst: 3}, User: Limit{Rate: 0.5, Burst: 10}}, // запрос в profile_service
+}
+
+var defaultBudget = Budget{Conn: Limit{Rate: 5, Burst: 10}, User: Limit{Rate: 10, Burst: 20}}
+
+// defaultKey — общая корзина всех типов вне budgets. Иначе каждый выдуманный
+// клиентом тип получал бы свежий burst и новый ключ в памяти и в Redis.
+const defaultKey dto.MessageType = "default"
+
+// Limiter общий для инстанса; состояние соединения — в ConnLimiter.
+type Limiter struct {
+	rdb     *redis.Client
+	script  *redis.Script
+	budgets map[dto.MessageType]Budget
+}
+
+func NewLimiter(rdb *redis.Client, budgets map[dto.MessageType]Budget) *Limiter {
+	return &Limiter{
+		rdb:     rdb,
+		script:  redis.NewScript(tokenBucketLua),
+		budgets: budgets,
+	}
+}
+
+// budget возвращает лимиты типа и ключ его корзины.
+func (l *Limiter) budget(t dto.MessageType) (dto.MessageType, Budget) {
+	if b, ok := l.budgets[t]; ok {
+		return t, b
+	}
+	return defaultKey, defaultBudget
+}
+
+// allowUser списывает токен из общей корзины пользователя. При недоступности
+// Redis пропускает сообщение: от флуда всё равно защищает лимит соединения.
+func (l *Limiter) allowUser(ctx context.Context, userId int64, t dto.MessageType, limit Limit, now time.Time) (bool, time.Duration) {
+	res, err := l.script.Run(ctx, l.rdb, []string{bucketKey(userId, t)},
+		limit.Rate, limit.Burst, now.UnixMilli()).Int64Slice()
+	if err != nil || len(res) != 2 {
+		log.Printf("[ratelimit] user bucket unavailable for user %d: %v", userId, err)
+		return true, 0
+	}
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond
+}
+
+func bucketKey(userId int64, t dto.MessageType) string {
+	return fmt.Sprintf("ratelimit:%d:%s", userId, t)
+}
+
+// Decision — результат проверки сообщения.
+type Decision struct {
+	Allowed    bool
+	RetryAfter time.Duration
+	// Disconnect — нарушений за violationWindow слишком много.
+	Disconnect bool
+}
+
+// ConnLimiter — корзины одного соединения.
+type ConnLimiter struct {
+	limiter *Limiter
+	userId  int64
+
+	mu         sync.Mutex
+	buckets    map[dto.MessageType]*bucket
+	violations []time.Time
+}
+
+func (l *Limiter) ForConnection(userId int64) *ConnLimiter {
+	return &ConnLimiter{
+		limiter: l,
+		userId:  userId,
+		buckets: make(map[dto.MessageType]*bucket),
+	}
+}
+
+func (c *ConnLimiter) Allow(ctx context.Context, t dto.MessageType) Decision {
+	now := time.Now()
+	key, budget := c.limiter.budget(t)
+
+	c.mu.Lock()
+	b, ok := c.buckets[key]
+	if !ok {
+		b = newBucket(budget.Conn, now)
+		c.buckets[key] = b
+	}
+	allowed, retry := b.take(now)
+	c.mu.Unlock()
+
+	// корзину пользователя трогаем, только если прошёл лимит соединения:
+	// флудящее соединение не должно выедать бюджет остальных устройств
+	if allowed {
+		allowed, retry = c.limiter.allowUser(ctx, c.userId, key, budget.User, now)
+	}
+	if allowed {
+		return Decision{Allowed: true}
+	}
+
+	return Decision{
+		RetryAfter: retry,
+		Disconnect: c.violate(now),
+	}
+}
+
+func (c *ConnLimiter) violate(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cutoff := now.Add(-violationWindow)
+	kept := c.violations[:0]
+	for _, v := range c.violations {
+		if v.After(cutoff) {
+			kept = append(kept, v)
+		}
+	}
+	c.violations = append(kept, now)
+
+	return len(c.violations) >= maxViolations
+}
+
+// bucket — token bucket в памяти соединения.
+type bucket struct {
+	limit  Limit
+	tokens float64
+	ts     time.Time
+}
+
+func newBucket(limit Limit, now time.Time) *bucket {
+	return &bucket{limit: limit, tokens: float64(limit.Burst), ts: now}
+}
+
+func (b *bucket) take(now time.Time) (bool, time.Duration) {
+	elapsed := now.Sub(b.ts).Seconds()
+	b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+	b.ts = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := (1 - b.tokens) / b.limit.Rate
+	return false, time.Duration(math.Ceil(wait*1000)) * time.Millisecond
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"chat_service/internal/websocket/dto"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestLimiter(t *testing.T, budgets map[dto.MessageType]Budget) *Limiter {
+	t.Helper()
+
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	return NewLimiter(rdb, budgets)
+}
+
+func TestConnectionBudget(t *testing.T) {
+	l := newTestLimiter(t, map[dto.MessageType]Budget{
+		dto.MessageChat: {Conn: Limit{Rate: 1, Burst: 3}, User: Limit{Rate: 100, Burst: 100}},
+	})
+	c := l.ForConnection(1)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if d := c.Allow(ctx, dto.MessageChat); !d.Allowed {
+			t.Fatalf("message %d must be allowed", i)
+		}
+	}
+
+	d := c.Allow(ctx, dto.MessageChat)
+	if d.Allowed || d.RetryAfter <= 0 {
+		t.Fatalf("expected rate limit with retry hint, got %+v", d)
+	}
+
+	// бюджеты типов независимы
+	if d := c.Allow(ctx, dto.MessagePresence); !d.Allowed {
+		t.Fatal("presence must have its own budget")
+	}
+}
+
+func TestUserBudgetIsShared(t *testing.T) {
+	l := newTestLimiter(t, map[dto.MessageType]Budget{
+		dto.MessageChat: {Conn: Limit{Rate: 100, Burst: 100}, User: Limit{Rate: 1, Burst: 4}},
+	})
+	ctx := context.Background()
+
+	// два соединения одного пользователя, например на разных инстансах
+	a, b := l.ForConnection(1), l.ForConnection(1)
+	for i := 0; i < 2; i++ {
+		if !a.Allow(ctx, dto.MessageChat).Allowed || !b.Allow(ctx, dto.MessageChat).Allowed {
+			t.Fatalf("message %d must be allowed", i)
+		}
+	}
+	if b.Allow(ctx, dto.MessageChat).Allowed {
+		t.Fatal("user budget must be shared between connections")
+	}
+
+	if !l.ForConnection(2).Allow(ctx, dto.MessageChat).Allowed {
+		t.Fatal("other users must not be affected")
+	}
+}
+
+func TestUnknownTypesShareDefaultBucket(t *testing.T) {
+	l := newTestLimiter(t, map[dto.MessageType]Budget{})
+	c := l.ForConnection(1)
+	ctx := context.Background()
+
+	// каждый выдуманный тип не должен получать собственный burst
+	for i := 0; i < defaultBudget.Conn.Burst; i++ {
+		if !c.Allow(ctx, dto.MessageType(fmt.Sprintf("made_up_%d", i))).Allowed {
+			t.Fatalf("message %d must be allowed", i)
+		}
+	}
+	if c.Allow(ctx, "another_made_up").Allowed {
+		t.Fatal("unknown types must share the default bucket")
+	}
+	if len(c.buckets) != 1 {
+		t.Fatalf("expected a single bucket, got %d", len(c.buckets))
+	}
+
+	keys := l.rdb.Keys(ctx, "ratelimit:*").Val()
+	if len(keys) != 1 || keys[0] != bucketKey(1, defaultKey) {
+		t.Fatalf("expected a single redis key, got %v", keys)
+	}
+}
+
+func TestRepeatOffenderIsDisconnected(t *testing.T) {
+	l := newTestLimiter(t, map[dto.MessageType]Budget{
+		dto.MessageChat: {Conn: Limit{Rate: 0.001, Burst: 1}, User: Limit{Rate: 100, Burst: 100}},
+	})
+	c := l.ForConnection(1)
+	ctx := context.Background()
+
+	c.Allow(ctx, dto.MessageChat)
+	for i := 1; i < maxViolations; i++ {
+		if d := c.Allow(ctx, dto.MessageChat); d.Disconnect {
+			t.Fatalf("disconnected after %d violations", i)
+		}
+	}
+	if d := c.Allow(ctx, dto.MessageChat); !d.Disconnect {
+		t.Fatal("repeat offender must be disconnected")
+	}
+}
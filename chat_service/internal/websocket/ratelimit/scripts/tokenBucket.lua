@@ -0,0 +1,36 @@
+-- KEYS
+-- 1 = bucketKey
+
+-- ARGV
+-- 1 = rate (токенов в секунду)
+-- 2 = burst
+-- 3 = nowMs
+
+-- RETURN
+-- {1, 0}            = разрешено
+-- {0, retryAfterMs} = лимит исчерпан
+
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or burst
+local ts = tonumber(bucket[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+    tokens = tokens - 1
+    allowed = 1
+else
+    retry = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+-- корзина без запросов восполняется полностью — хранить её дольше незачем
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+
+return {allowed, retry}
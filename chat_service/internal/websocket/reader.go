@@ -2,34 +2,79 @@ package websocket
 
 import (
 	"chat_service/internal/websocket/dto"
+	"chat_service/internal/websocket/helper"
 	"context"
-	"encoding/json"
+	"log"
+	"runtime/debug"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 func (c *Connection) readLoop() {
 	defer c.close()
+	// паника вне обработчиков (кодек, middleware) закрывает только это соединение
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[conn] panic in read loop of user %d: %v\n%s", c.UserId, r, debug.Stack())
+		}
+	}()
 
-	c.ws.SetReadLimit(maxMessageSize)
-	c.ws.SetReadDeadline(time.Now().Add(pongWait))
+	c.ws.SetReadLimit(c.Hub.cfg.MaxMessageSize)
+	c.ws.SetReadDeadline(time.Now().Add(c.Hub.cfg.PongWait))
 
+	// pong — только живость транспорта: продлевает дедлайн чтения и TTL
+	// соединения в Redis, но не last_activity.
 	c.ws.SetPongHandler(func(string) error {
 		_ = c.Presence.OnHeartbeat(context.Background(), c.connId)
-		c.ws.SetReadDeadline(time.Now().Add(pongWait))
+		c.ws.SetReadDeadline(time.Now().Add(c.Hub.cfg.PongWait))
 		return nil
 	})
 
 	for {
 		_, data, err := c.ws.ReadMessage()
 		if err != nil {
+			// клиент закрыл соединение сам — возобновлять нечего
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				c.clientClosed.Store(true)
+			}
 			return
 		}
 
-		var msg dto.WSMessage
-		if err := json.Unmarshal(data, &msg); err != nil {
+		c.countIn(len(data))
+
+		msg, err := c.codec.Decode(data)
+		if err != nil {
 			continue // плохой клиент
 		}
 
 		c.router.Route(c.Ctx, c, msg)
 	}
 }
+
+// allow применяет лимиты сообщений: при отказе клиент получает кадр rate_limited,
+// злостный нарушитель отключается с кодом 1008 (policy violation).
+func (c *Connection) allow(ctx context.Context, t dto.MessageType) bool {
+	if c.limits == nil {
+		return true
+	}
+
+	d := c.limits.Allow(ctx, t)
+	if d.Allowed {
+		return true
+	}
+
+	if d.Disconnect {
+		if c.kick(websocket.ClosePolicyViolation, "rate limit exceeded") {
+			log.Printf("[conn] user %d disconnected for exceeding rate limits", c.UserId)
+		}
+		return false
+	}
+
+	c.Enqueue(helper.BuildErrorWS(dto.ErrorPayload{
+		Code:         dto.ErrRateLimited,
+		Type:         t,
+		RetryAfterMs: d.RetryAfter.Milliseconds(),
+	}))
+	return false
+}
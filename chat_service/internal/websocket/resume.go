@@ -0,0 +1,219 @@
+package websocket
+
+import (
+	"chat_service/internal/websocket/dto"
+	"chat_service/internal/websocket/helper"
+	"chat_service/internal/websocket/session"
+	"context"
+	"log"
+	"time"
+)
+
+// Resume готовит соединение к продолжению оборванной сессии: прежний connId,
+// комнаты, подписки и нумерация кадров. Применяется в Start.
+func (c *Connection) Resume(token string, st session.State) {
+	c.connId = st.ConnId
+	c.resumeToken = token
+	c.resumed = &st
+
+	// до конца restore кадры копятся в pending, чтобы номера шли после накопленных в spool
+	c.seq = st.LastSeq
+	c.restoring = true
+}
+
+func (c *Connection) restore() {
+	ctx := context.Background()
+	st := c.resumed
+
+	for _, roomId := range st.Rooms {
+		c.Hub.JoinRoom(roomId, c)
+	}
+	c.Hub.Subscribe(ctx, c, st.Watching)
+
+	if err := c.Presence.OnResume(ctx, c.UserId, c.connId, c.Client.Device, c.Client.AppVersion); err != nil {
+		log.Printf("[conn] failed to restore presence for user %d: %v", c.UserId, err)
+	}
+
+	// оборванное соединение на этом инстансе ещё копит кадры — забираем их до чтения spool;
+	// если оно на другом инстансе, тот снимает его с учёта сам
+	if suspended := c.Hub.takeSuspended(c.resumeToken); suspended != nil {
+		suspended.stopSuspended()
+		<-suspended.released
+	} else {
+		c.Hub.announceResumed(ctx, c.resumeToken)
+	}
+
+	spooled, err := c.Sessions.TakeSpooled(ctx, c.resumeToken)
+	if err != nil {
+		log.Printf("[conn] failed to read spooled frames for user %d: %v", c.UserId, err)
+	}
+
+	c.sendMu.Lock()
+	for _, msg := range append(spooled, c.pending...) {
+		c.replay = append(c.replay, c.stamp(msg))
+	}
+	c.pending = nil
+	c.restoring = false
+	c.sendMu.Unlock()
+
+	log.Printf("[conn] user %d resumed connection %d, replaying %d frames", c.UserId, c.connId, len(spooled))
+}
+
+// sessionFrame не нумеруется: last_seq — номер, после которого продолжается поток.
+func (c *Connection) sessionFrame(resumed bool) []byte {
+	lastSeq := int64(0)
+	if resumed {
+		lastSeq = c.resumed.LastSeq
+	}
+
+	return helper.BuildSystemWS(dto.SystemPayload{
+		Event:        dto.SystemSession,
+		SessionToken: c.sessionToken,
+		ResumeTtlMs:  session.ResumeGrace.Milliseconds(),
+		Resumed:      resumed,
+		LastSeq:      lastSeq,
+	})
+}
+
+// resumable — обрыв, после которого клиент может вернуться: не закрытие
+// самим клиентом и не принудительное отключение сервером.
+func (c *Connection) resumable() bool {
+	if c.Sessions == nil || c.clientClosed.Load() {
+		return false
+	}
+
+	select {
+	case <-c.kicked:
+		return false
+	default:
+		return true
+	}
+}
+
+// state фиксирует состояние сессии; после него кадры копятся без номеров.
+func (c *Connection) state() session.State {
+	rooms, watched := c.snapshotMemberships()
+
+	return session.State{
+		UserId:   c.UserId,
+		ConnId:   c.connId,
+		Rooms:    rooms,
+		Watching: watched,
+		LastSeq:  c.suspendSequence(),
+	}
+}
+
+// suspend сохраняет состояние сессии и оставляет соединение в индексах Hub:
+// адресованные ему кадры копятся в Redis до возобновления или истечения ResumeGrace.
+// Presence не меняется — для подписчиков пользователь остаётся online.
+func (c *Connection) suspend() {
+	ctx := context.Background()
+
+	if err := c.Sessions.Save(ctx, c.sessionToken, c.state(), session.ResumeGrace); err != nil {
+		log.Printf("[conn] failed to suspend session for user %d: %v", c.UserId, err)
+		c.release(true)
+		return
+	}
+
+	c.Hub.suspend(c.sessionToken, c)
+	go c.spoolLoop()
+
+	log.Printf("[conn] connection %d of user %d suspended", c.connId, c.UserId)
+}
+
+// handoff — закрытие при drain: состояние сохраняется, чтобы клиент мог
+// возобновить сессию на другом инстансе.
+func (c *Connection) handoff() {
+	ctx := context.Background()
+
+	if c.Sessions != nil {
+		if err := c.Sessions.Save(ctx, c.sessionToken, c.state(), session.ResumeGrace); err != nil {
+			log.Printf("[conn] failed to save session for user %d: %v", c.UserId, err)
+		}
+	}
+
+	c.Hub.UnregisterConnection(c)
+	_ = c.Presence.OnHandoff(ctx, c.UserId, c.connId)
+	close(c.released)
+}
+
+func (c *Connection) stopSuspended() {
+	c.stopOnce.Do(func() {
+		close(c.stopSuspend)
+	})
+}
+
+// revokeSuspended завершает приостановленную сессию без возможности возобновления.
+func (c *Connection) revokeSuspended() {
+	c.revoked.Store(true)
+	c.stopSuspended()
+}
+
+func (c *Connection) spoolLoop() {
+	ctx := context.Background()
+
+	expire := time.NewTimer(session.ResumeGrace)
+	heartbeat := time.NewTicker(c.Hub.cfg.PingPeriod)
+	defer func() {
+		expire.Stop()
+		heartbeat.Stop()
+	}()
+
+	for {
+		select {
+		case f := <-c.Send:
+			c.spool(ctx, f.msg)
+
+		case <-heartbeat.C:
+			_ = c.Presence.OnHeartbeat(ctx, c.connId)
+
+		case <-c.stopSuspend:
+			if c.revoked.Load() {
+				_, _ = c.Sessions.Take(ctx, c.sessionToken)
+				_, _ = c.Sessions.TakeSpooled(ctx, c.sessionToken)
+				c.release(true)
+				return
+			}
+
+			// сессию возобновили (здесь или на другом инстансе) или инстанс останавливается
+			c.flushToSpool(ctx)
+			c.Hub.UnregisterConnection(c)
+			if c.Hub.Draining() {
+				_ = c.Presence.OnHandoff(ctx, c.UserId, c.connId)
+			}
+			close(c.released)
+			return
+
+		case <-expire.C:
+			c.Hub.takeSuspended(c.sessionToken)
+
+			// состояние уже забрано — сессию возобновили на другом инстансе
+			if _, err := c.Sessions.Take(ctx, c.sessionToken); err != nil {
+				c.flushToSpool(ctx)
+				c.release(false)
+				return
+			}
+
+			c.release(true)
+			_, _ = c.Sessions.TakeSpooled(ctx, c.sessionToken)
+			return
+		}
+	}
+}
+
+func (c *Connection) flushToSpool(ctx context.Context) {
+	for {
+		select {
+		case f := <-c.Send:
+			c.spool(ctx, f.msg)
+		default:
+			return
+		}
+	}
+}
+
+func (c *Connection) spool(ctx context.Context, msg []byte) {
+	if err := c.Sessions.Spool(ctx, c.sessionToken, msg, session.ResumeGrace); err != nil {
+		log.Printf("[conn] failed to spool frame for user %d: %v", c.UserId, err)
+	}
+}
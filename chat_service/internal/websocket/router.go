@@ -2,34 +2,75 @@ package websocket
 
 import (
 	"chat_service/internal/websocket/dto"
+	"chat_service/internal/websocket/helper"
 	"context"
-	"log"
 )
 
 type HandlerFunc func(ctx context.Context, c *Connection, msg dto.WSMessage)
 
+// Middleware оборачивает обработчик, как middleware в gin: может выполнить
+// действия до и после next или не вызывать его вовсе.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Router настраивается до первого Route: обёрнутые middleware обработчики
+// собираются в Use и Register, а не на каждое сообщение.
 type Router struct {
-	handlers map[dto.MessageType]HandlerFunc
+	handlers   map[dto.MessageType]HandlerFunc
+	middleware []Middleware
+
+	chains  map[dto.MessageType]HandlerFunc
+	unknown HandlerFunc
 }
 
 func NewRouter() *Router {
 	return &Router{
 		handlers: make(map[dto.MessageType]HandlerFunc),
+		chains:   make(map[dto.MessageType]HandlerFunc),
+		unknown:  unknownType,
 	}
 }
 
+// Use добавляет middleware; первое добавленное выполняется первым.
+// Цепочка применяется ко всем типам сообщений, включая неизвестные.
+func (r *Router) Use(mw ...Middleware) {
+	r.middleware = append(r.middleware, mw...)
+
+	for t, h := range r.handlers {
+		r.chains[t] = r.wrap(h)
+	}
+	r.unknown = r.wrap(unknownType)
+}
+
 func (r *Router) Register(t dto.MessageType, h HandlerFunc) {
 	r.handlers[t] = h
+	r.chains[t] = r.wrap(h)
 }
 
-func (r *Router) Route(ctx context.Context, c *Connection, msg dto.WSMessage) {
-	log.Printf("[ws] incoming type: %s", msg.Type)
+// Handles сообщает, зарегистрирован ли обработчик для типа.
+func (r *Router) Handles(t dto.MessageType) bool {
+	_, ok := r.handlers[t]
+	return ok
+}
 
-	h, ok := r.handlers[msg.Type]
+func (r *Router) Route(ctx context.Context, c *Connection, msg dto.WSMessage) {
+	h, ok := r.chains[msg.Type]
 	if !ok {
-		log.Printf("[ws] unknown message type: %s", msg.Type)
-		return
+		h = r.unknown
 	}
 
 	h(ctx, c, msg)
 }
+
+func (r *Router) wrap(h HandlerFunc) HandlerFunc {
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		h = r.middleware[i](h)
+	}
+	return h
+}
+
+func unknownType(ctx context.Context, c *Connection, msg dto.WSMessage) {
+	c.Enqueue(helper.BuildErrorWS(dto.ErrorPayload{
+		Code: dto.ErrUnknownType,
+		Type: msg.Type,
+	}))
+}
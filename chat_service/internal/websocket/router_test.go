@@ -0,0 +1,132 @@
+package websocket
+
+import (
+	"chat_service/internal/websocket/dto"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func errorCode(t *testing.T, frame []byte) dto.ErrorCode {
+	t.Helper()
+
+	var msg dto.WSMessage
+	var payload dto.ErrorPayload
+	if err := json.Unmarshal(frame, &msg); err != nil || msg.Type != dto.MessageError {
+		t.Fatalf("expected error frame, got %s", frame)
+	}
+	_ = json.Unmarshal(msg.Payload, &payload)
+	return payload.Code
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	r := NewRouter()
+
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, c *Connection, msg dto.WSMessage) {
+				calls = append(calls, name)
+				next(ctx, c, msg)
+			}
+		}
+	}
+	r.Use(trace("first"), trace("second"))
+	r.Register(dto.MessageChat, func(ctx context.Context, c *Connection, msg dto.WSMessage) {
+		calls = append(calls, "handler")
+	})
+
+	r.Route(context.Background(), newTestConnection(newTestHub(DropSlowConsumer), 1, 1), dto.WSMessage{Type: dto.MessageChat})
+
+	if len(calls) != 3 || calls[0] != "first" || calls[1] != "second" || calls[2] != "handler" {
+		t.Fatalf("unexpected call order %v", calls)
+	}
+}
+
+func TestUnknownTypeAndRecover(t *testing.T) {
+	r := NewRouter()
+	r.Use(Recover)
+	r.Register(dto.MessageChat, func(ctx context.Context, c *Connection, msg dto.WSMessage) {
+		panic("boom")
+	})
+
+	c := newTestConnection(newTestHub(DropSlowConsumer), 1, 4)
+
+	r.Route(context.Background(), c, dto.WSMessage{Type: "bogus"})
+	if code := errorCode(t, (<-c.Send).msg); code != dto.ErrUnknownType {
+		t.Fatalf("expected unknown_type, got %s", code)
+	}
+
+	r.Route(context.Background(), c, dto.WSMessage{Type: dto.MessageChat})
+	if code := errorCode(t, (<-c.Send).msg); code != dto.ErrInternal {
+		t.Fatalf("expected internal_error, got %s", code)
+	}
+}
+
+func TestValidatePayload(t *testing.T) {
+	r := NewRouter()
+	r.Use(ValidatePayload(map[dto.MessageType]Validator{
+		dto.MessageResend: func(payload json.RawMessage) error {
+			return json.Unmarshal(payload, &struct{}{})
+		},
+	}))
+
+	handled := false
+	r.Register(dto.MessageResend, func(ctx context.Context, c *Connection, msg dto.WSMessage) {
+		handled = true
+	})
+
+	c := newTestConnection(newTestHub(DropSlowConsumer), 1, 4)
+	r.Route(context.Background(), c, dto.WSMessage{Type: dto.MessageResend, Payload: json.RawMessage(`[`)})
+
+	if handled {
+		t.Fatal("invalid payload reached the handler")
+	}
+	if code := errorCode(t, (<-c.Send).msg); code != dto.ErrInvalidPayload {
+		t.Fatalf("expected invalid_payload, got %s", code)
+	}
+}
+
+func TestRequireAuthAllowsOnlyReauth(t *testing.T) {
+	r := NewRouter()
+	r.Use(RequireAuth)
+
+	var handled []dto.MessageType
+	record := func(ctx context.Context, c *Connection, msg dto.WSMessage) {
+		handled = append(handled, msg.Type)
+	}
+	r.Register(dto.MessageChat, record)
+	r.Register(dto.MessageReauth, record)
+
+	c := newTestConnection(newTestHub(DropSlowConsumer), 1, 4)
+	c.authExpiresAt.Store(time.Now().Add(-time.Second).Unix())
+
+	r.Route(context.Background(), c, dto.WSMessage{Type: dto.MessageChat})
+	if code := errorCode(t, (<-c.Send).msg); code != dto.ErrAuthExpired {
+		t.Fatalf("expected auth_expired, got %s", code)
+	}
+
+	r.Route(context.Background(), c, dto.WSMessage{Type: dto.MessageReauth})
+	if len(handled) != 1 || handled[0] != dto.MessageReauth {
+		t.Fatalf("only reauth must pass with expired token, handled %v", handled)
+	}
+}
+
+func TestMetricsGroupUnknownTypes(t *testing.T) {
+	r := NewRouter()
+	m := NewMessageMetrics(r.Handles)
+	r.Use(m.Middleware)
+	r.Register(dto.MessageChat, func(ctx context.Context, c *Connection, msg dto.WSMessage) {})
+
+	c := newTestConnection(newTestHub(DropSlowConsumer), 1, 8)
+	r.Route(context.Background(), c, dto.WSMessage{Type: dto.MessageChat})
+	for _, typ := range []dto.MessageType{"bogus1", "bogus2", "bogus3"} {
+		r.Route(context.Background(), c, dto.WSMessage{Type: typ})
+	}
+
+	stats := m.Snapshot()
+	if len(stats) != 2 || stats[dto.MessageChat].Count != 1 || stats[unknownMetricsType].Count != 3 {
+		t.Fatalf("unexpected metrics %+v", stats)
+	}
+}
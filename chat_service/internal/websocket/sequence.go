@@ -0,0 +1,145 @@
+package websocket
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// resendWindow — сколько последних кадров соединение хранит для resend_from.
+const resendWindow = 256
+
+// frameRing — кольцевой буфер последних пронумерованных кадров.
+type frameRing struct {
+	frames [resendWindow][]byte
+	// first — первый записанный seq: после возобновления нумерация
+	// продолжается с LastSeq+1, и более ранних кадров в буфере нет.
+	first int64
+	last  int64
+}
+
+func (r *frameRing) put(seq int64, msg []byte) {
+	if r.first == 0 {
+		r.first = seq
+	}
+	r.frames[seq%resendWindow] = msg
+	r.last = seq
+}
+
+// since возвращает кадры начиная с seq from. ok=false, если часть из них
+// уже вытеснена из буфера или не записывалась; oldest — самый ранний доступный seq.
+func (r *frameRing) since(from int64) (frames [][]byte, oldest int64, ok bool) {
+	oldest = max(r.last-resendWindow+1, r.first)
+	if from < oldest {
+		return nil, oldest, false
+	}
+
+	for seq := from; seq <= r.last; seq++ {
+		frames = append(frames, r.frames[seq%resendWindow])
+	}
+	return frames, oldest, true
+}
+
+// stamp присваивает кадру следующий seq и запоминает его для повторной отправки.
+// Вызывается под sendMu. Кадры — JSON-объекты dto.WSMessage, поэтому seq
+// вставляется первым полем без повторного кодирования.
+func (c *Connection) stamp(msg []byte) []byte {
+	if c.ring == nil {
+		c.ring = &frameRing{}
+	}
+
+	c.seq++
+
+	stamped := make([]byte, 0, len(msg)+24)
+	stamped = append(stamped, stampPrefix...)
+	stamped = strconv.AppendInt(stamped, c.seq, 10)
+	stamped = append(stamped, ',')
+	stamped = append(stamped, msg[1:]...)
+
+	c.ring.put(c.seq, stamped)
+	return stamped
+}
+
+const stampPrefix = `{"seq":`
+
+// unstamp снимает номер, проставленный stamp.
+func unstamp(msg []byte) (int64, []byte) {
+	seq := stampedSeq(msg)
+	if seq == 0 {
+		return 0, msg
+	}
+
+	end := bytes.IndexByte(msg, ',')
+	return seq, append([]byte{'{'}, msg[end+1:]...)
+}
+
+// stampedSeq возвращает номер, проставленный stamp, или 0, если его нет.
+func stampedSeq(msg []byte) int64 {
+	if !bytes.HasPrefix(msg, []byte(stampPrefix)) {
+		return 0
+	}
+
+	end := bytes.IndexByte(msg, ',')
+	if end < 0 {
+		return 0
+	}
+
+	seq, err := strconv.ParseInt(string(msg[len(stampPrefix):end]), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return seq
+}
+
+// suspendSequence переводит соединение в режим без нумерации и возвращает
+// последний seq, дошедший до клиента. Кадры, не успевшие уйти из Send,
+// возвращаются в очередь без номеров: возобновлённая сессия пронумерует их заново.
+func (c *Connection) suspendSequence() int64 {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	c.suspended = true
+	lastSeq := c.seq
+
+	var unsent []outFrame
+	for drained := false; !drained; {
+		select {
+		case f := <-c.Send:
+			seq, raw := unstamp(f.msg)
+			if len(unsent) == 0 && seq > 0 {
+				lastSeq = seq - 1
+			}
+			f.msg = raw
+			unsent = append(unsent, f)
+		default:
+			drained = true
+		}
+	}
+
+	for _, f := range unsent {
+		c.push(f)
+	}
+	return lastSeq
+}
+
+// Resend повторно ставит в очередь кадры начиная с fromSeq. Если они уже
+// вытеснены из буфера, возвращает самый ранний доступный seq и false.
+func (c *Connection) Resend(fromSeq int64) (int64, bool) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	if c.ring == nil {
+		return c.seq + 1, fromSeq > c.seq
+	}
+
+	frames, oldest, ok := c.ring.since(fromSeq)
+	if !ok {
+		return oldest, false
+	}
+
+	for _, msg := range frames {
+		if !c.push(outFrame{msg: msg}) {
+			break
+		}
+	}
+	return oldest, true
+}
@@ -0,0 +1,131 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// ResumeGrace — сколько сессия оборванного соединения ждёт возобновления.
+	ResumeGrace = 30 * time.Second
+
+	// spoolLimit ограничивает число кадров, накопленных за время обрыва;
+	// при переполнении отбрасываются самые старые.
+	spoolLimit = 256
+)
+
+var ErrNotFound = errors.New("session not found")
+
+// State — состояние соединения, переживающее переподключение.
+type State struct {
+	UserId   int64   `json:"user_id"`
+	ConnId   int64   `json:"conn_id"`
+	Rooms    []int64 `json:"rooms,omitempty"`
+	Watching []int64 `json:"watching,omitempty"`
+	// LastSeq — сколько кадров было доставлено клиенту до обрыва.
+	LastSeq int64 `json:"last_seq"`
+}
+
+type Store interface {
+	Save(ctx context.Context, token string, st State, ttl time.Duration) error
+	// Peek читает состояние, не забирая его: например, чтобы проверить владельца
+	// до апгрейда соединения.
+	Peek(ctx context.Context, token string) (State, error)
+	// Take атомарно забирает состояние: возобновить сессию можно только один раз.
+	Take(ctx context.Context, token string) (State, error)
+
+	Spool(ctx context.Context, token string, msg []byte, ttl time.Duration) error
+	TakeSpooled(ctx context.Context, token string) ([][]byte, error)
+}
+
+type redisStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisStore(rdb *redis.Client) Store {
+	return &redisStore{rdb: rdb}
+}
+
+// NewToken выдаёт непредсказуемый токен возобновления.
+func NewToken() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (s *redisStore) Save(ctx context.Context, token string, st State, ttl time.Duration) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, stateKey(token), data, ttl).Err()
+}
+
+func (s *redisStore) Peek(ctx context.Context, token string) (State, error) {
+	return decodeState(s.rdb.Get(ctx, stateKey(token)).Bytes())
+}
+
+func (s *redisStore) Take(ctx context.Context, token string) (State, error) {
+	return decodeState(s.rdb.GetDel(ctx, stateKey(token)).Bytes())
+}
+
+func decodeState(data []byte, err error) (State, error) {
+	var st State
+
+	if errors.Is(err, redis.Nil) {
+		return st, ErrNotFound
+	}
+	if err != nil {
+		return st, err
+	}
+
+	err = json.Unmarshal(data, &st)
+	return st, err
+}
+
+func (s *redisStore) Spool(ctx context.Context, token string, msg []byte, ttl time.Duration) error {
+	key := spoolKey(token)
+
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, key, msg)
+		pipe.LTrim(ctx, key, -spoolLimit, -1)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	return err
+}
+
+func (s *redisStore) TakeSpooled(ctx context.Context, token string) ([][]byte, error) {
+	key := spoolKey(token)
+
+	var rangeCmd *redis.StringSliceCmd
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		rangeCmd = pipe.LRange(ctx, key, 0, -1)
+		pipe.Del(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([][]byte, 0, len(rangeCmd.Val()))
+	for _, f := range rangeCmd.Val() {
+		frames = append(frames, []byte(f))
+	}
+	return frames, nil
+}
+
+func stateKey(token string) string {
+	return fmt.Sprintf("ws:session:%s", token)
+}
+
+func spoolKey(token string) string {
+	return fmt.Sprintf("ws:session:%s:spool", token)
+}
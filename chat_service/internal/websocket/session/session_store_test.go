@@ -0,0 +1,74 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestStore(t *testing.T) Store {
+	t.Helper()
+
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	return NewRedisStore(rdb)
+}
+
+func TestTakeIsOneShot(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	want := State{UserId: 1, ConnId: 42, Rooms: []int64{10}, Watching: []int64{2, 3}, LastSeq: 7}
+	if err := s.Save(ctx, "tok", want, ResumeGrace); err != nil {
+		t.Fatal(err)
+	}
+
+	// Peek не расходует сессию
+	if peeked, err := s.Peek(ctx, "tok"); err != nil || peeked.UserId != want.UserId {
+		t.Fatalf("Peek: %+v %v", peeked, err)
+	}
+
+	got, err := s.Take(ctx, "tok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ConnId != want.ConnId || got.LastSeq != want.LastSeq || len(got.Rooms) != 1 || len(got.Watching) != 2 {
+		t.Fatalf("unexpected state %+v", got)
+	}
+
+	if _, err := s.Take(ctx, "tok"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second Take must fail with ErrNotFound, got %v", err)
+	}
+}
+
+func TestSpoolIsBounded(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	for i := 0; i < spoolLimit+10; i++ {
+		if err := s.Spool(ctx, "tok", []byte(fmt.Sprint(i)), ResumeGrace); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	frames, err := s.TakeSpooled(ctx, "tok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != spoolLimit {
+		t.Fatalf("expected %d frames, got %d", spoolLimit, len(frames))
+	}
+	// сохраняются самые свежие кадры в исходном порядке
+	if string(frames[0]) != "10" || string(frames[len(frames)-1]) != fmt.Sprint(spoolLimit+9) {
+		t.Fatalf("unexpected spool bounds: %s..%s", frames[0], frames[len(frames)-1])
+	}
+
+	if frames, _ := s.TakeSpooled(ctx, "tok"); len(frames) != 0 {
+		t.Fatal("spool must be emptied")
+	}
+}
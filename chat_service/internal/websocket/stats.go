@@ -0,0 +1,66 @@
+package websocket
+
+import "time"
+
+// ConnStats — счётчики соединения. Байты считаются до сжатия permessage-deflate.
+type ConnStats struct {
+	ConnId      int64     `json:"conn_id"`
+	Device      string    `json:"device"`
+	Subprotocol string    `json:"subprotocol,omitempty"`
+	ConnectedAt time.Time `json:"connected_at"`
+	BytesIn     int64     `json:"bytes_in"`
+	BytesOut    int64     `json:"bytes_out"`
+	Suspended   bool      `json:"suspended,omitempty"`
+}
+
+// HubStats — сводка по инстансу. Суммарные байты включают закрытые соединения.
+type HubStats struct {
+	Connections     int64       `json:"connections"`
+	DroppedMessages int64       `json:"dropped_messages"`
+	BytesIn         int64       `json:"bytes_in"`
+	BytesOut        int64       `json:"bytes_out"`
+	PerConnection   []ConnStats `json:"per_connection"`
+}
+
+func (c *Connection) countIn(n int) {
+	c.bytesIn.Add(int64(n))
+	c.Hub.bytesIn.Add(int64(n))
+}
+
+func (c *Connection) countOut(n int) {
+	c.bytesOut.Add(int64(n))
+	c.Hub.bytesOut.Add(int64(n))
+}
+
+func (c *Connection) Stats() ConnStats {
+	c.sendMu.Lock()
+	suspended := c.suspended
+	c.sendMu.Unlock()
+
+	return ConnStats{
+		ConnId:      c.connId,
+		Device:      c.Client.Device,
+		Subprotocol: c.ws.Subprotocol(),
+		ConnectedAt: c.connectedAt,
+		BytesIn:     c.bytesIn.Load(),
+		BytesOut:    c.bytesOut.Load(),
+		Suspended:   suspended,
+	}
+}
+
+// Stats не раскрывает user_id: эндпоинт предназначен для мониторинга.
+func (h *Hub) Stats() HubStats {
+	conns := h.users.All()
+
+	stats := HubStats{
+		Connections:     h.ConnectionCount(),
+		DroppedMessages: h.DroppedMessages(),
+		BytesIn:         h.bytesIn.Load(),
+		BytesOut:        h.bytesOut.Load(),
+		PerConnection:   make([]ConnStats, 0, len(conns)),
+	}
+	for _, c := range conns {
+		stats.PerConnection = append(stats.PerConnection, c.Stats())
+	}
+	return stats
+}
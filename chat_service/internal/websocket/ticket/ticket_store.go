@@ -0,0 +1,80 @@
+package ticket
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TTL — сколько живёт билет: его выдают непосредственно перед открытием WebSocket.
+const TTL = 30 * time.Second
+
+var ErrInvalidTicket = errors.New("invalid or expired ws ticket")
+
+// Store выдаёт одноразовые билеты на рукопожатие /ws?ticket=... Браузер не может
+// передать Authorization при открытии WebSocket, а долгоживущий JWT в query-строке
+// оседает в логах прокси.
+type Store interface {
+	Issue(ctx context.Context, claims Claims) (string, error)
+	Redeem(ctx context.Context, ticket string) (Claims, error)
+}
+
+// Claims — чей билет и до какого момента (unix, секунды) действует JWT, по которому
+// он выдан: соединение, открытое по билету, живёт не дольше этого токена.
+type Claims struct {
+	UserId    int64 `json:"user_id"`
+	ExpiresAt int64 `json:"expires_at"`
+	SessionId int64 `json:"session_id,omitempty"`
+}
+
+type redisStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisStore(rdb *redis.Client) Store {
+	return &redisStore{rdb: rdb}
+}
+
+func (s *redisStore) Issue(ctx context.Context, claims Claims) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	ticket := hex.EncodeToString(b)
+
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.rdb.Set(ctx, ticketKey(ticket), data, TTL).Err(); err != nil {
+		return "", err
+	}
+	return ticket, nil
+}
+
+// Redeem погашает билет: GETDEL гарантирует, что второй раз им не воспользоваться.
+func (s *redisStore) Redeem(ctx context.Context, ticket string) (Claims, error) {
+	var claims Claims
+
+	data, err := s.rdb.GetDel(ctx, ticketKey(ticket)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return claims, ErrInvalidTicket
+	}
+	if err != nil {
+		return claims, err
+	}
+
+	err = json.Unmarshal(data, &claims)
+	return claims, err
+}
+
+func ticketKey(ticket string) string {
+	return fmt.Sprintf("ws:ticket:%s", ticket)
+}
@@ -0,0 +1,39 @@
+package ticket
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestTicketIsSingleUse(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	s := NewRedisStore(rdb)
+	ctx := context.Background()
+
+	claims := Claims{UserId: 42, ExpiresAt: 1700000000}
+	ticket, err := s.Issue(ctx, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Redeem(ctx, ticket)
+	if err != nil || got != claims {
+		t.Fatalf("redeem: claims=%+v err=%v", got, err)
+	}
+	if _, err := s.Redeem(ctx, ticket); !errors.Is(err, ErrInvalidTicket) {
+		t.Fatalf("second redeem must fail, got %v", err)
+	}
+
+	expired, _ := s.Issue(ctx, claims)
+	mr.FastForward(TTL)
+	if _, err := s.Redeem(ctx, expired); !errors.Is(err, ErrInvalidTicket) {
+		t.Fatalf("expired ticket must fail, got %v", err)
+	}
+}
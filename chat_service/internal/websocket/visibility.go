@@ -0,0 +1,116 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// lastSeenTTL — сколько помнится решение о видимости. Смена настроек
+	// приватности или разрыв дружбы доходят до подписчиков не позже.
+	lastSeenTTL = time.Minute
+	// lastSeenCheckTimeout ограничивает проверки одной рассылки presence:
+	// не успевшие подписчики события не получают.
+	lastSeenCheckTimeout = 2 * time.Second
+)
+
+// LastSeenChecker — кто из userIds разрешил viewerId видеть свою активность.
+type LastSeenChecker interface {
+	CanViewLastSeen(ctx context.Context, viewerId int64, userIds []int64) ([]int64, error)
+}
+
+type lastSeenKey struct {
+	viewer int64
+	target int64
+}
+
+type lastSeenEntry struct {
+	allowed   bool
+	expiresAt time.Time
+}
+
+// lastSeenCache кэширует ответы CanViewLastSeen: presence-события фильтруются
+// по каждому подписчику, и спрашивать profile_service на каждом переходе дорого.
+type lastSeenCache struct {
+	checker LastSeenChecker
+	now     func() time.Time
+
+	mu        sync.Mutex
+	entries   map[lastSeenKey]lastSeenEntry
+	lastSweep time.Time
+}
+
+func newLastSeenCache(checker LastSeenChecker) *lastSeenCache {
+	return &lastSeenCache{
+		checker: checker,
+		now:     time.Now,
+		entries: make(map[lastSeenKey]lastSeenEntry),
+	}
+}
+
+// Visible возвращает targets, чья активность видна viewer; себя видно всегда.
+// Fail-closed: при ошибке проверки непроверенные targets не видны и не кэшируются.
+func (c *lastSeenCache) Visible(ctx context.Context, viewer int64, targets []int64) []int64 {
+	now := c.now()
+	visible := make([]int64, 0, len(targets))
+	var missing []int64
+
+	c.mu.Lock()
+	c.sweep(now)
+	for _, id := range targets {
+		if id == viewer {
+			visible = append(visible, id)
+			continue
+		}
+		entry, ok := c.entries[lastSeenKey{viewer, id}]
+		switch {
+		case !ok || now.After(entry.expiresAt):
+			missing = append(missing, id)
+		case entry.allowed:
+			visible = append(visible, id)
+		}
+	}
+	c.mu.Unlock()
+
+	if len(missing) == 0 || c.checker == nil {
+		return visible
+	}
+
+	allowed, err := c.checker.CanViewLastSeen(ctx, viewer, missing)
+	if err != nil {
+		log.Printf("[presence] last seen visibility check failed for user %d: %v", viewer, err)
+		return visible
+	}
+
+	allowedSet := make(map[int64]struct{}, len(allowed))
+	for _, id := range allowed {
+		allowedSet[id] = struct{}{}
+	}
+
+	c.mu.Lock()
+	for _, id := range missing {
+		_, ok := allowedSet[id]
+		c.entries[lastSeenKey{viewer, id}] = lastSeenEntry{allowed: ok, expiresAt: now.Add(lastSeenTTL)}
+		if ok {
+			visible = append(visible, id)
+		}
+	}
+	c.mu.Unlock()
+
+	return visible
+}
+
+// sweep удаляет просроченные решения не чаще раза в lastSeenTTL. Вызывается под c.mu.
+func (c *lastSeenCache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < lastSeenTTL {
+		return
+	}
+	c.lastSweep = now
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
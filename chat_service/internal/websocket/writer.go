@@ -7,28 +7,77 @@ import (
 )
 
 func (c *Connection) writeLoop() {
-	ticker := time.NewTicker(pingPeriod)
+	ticker := time.NewTicker(c.Hub.cfg.PingPeriod)
 	defer func() {
 		ticker.Stop()
 		c.close()
 	}()
 
+	for _, msg := range c.replay {
+		c.ws.SetWriteDeadline(time.Now().Add(c.Hub.cfg.WriteWait))
+		if err := c.write(outFrame{msg: msg}); err != nil {
+			return
+		}
+	}
+	c.replay = nil
+
 	for {
 		select {
-		case msg, ok := <-c.Send:
+		case f, ok := <-c.Send:
 			if !ok {
 				return
 			}
-			c.ws.SetWriteDeadline(time.Now().Add(writeWait))
-			if err := c.ws.WriteMessage(websocket.TextMessage, msg); err != nil {
+			c.ws.SetWriteDeadline(time.Now().Add(c.Hub.cfg.WriteWait))
+			if err := c.write(f); err != nil {
 				return
 			}
 
+		case <-c.kicked:
+			c.ws.SetWriteDeadline(time.Now().Add(c.Hub.cfg.WriteWait))
+			c.flush()
+			_ = c.ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(c.kickCode, c.kickText))
+			return
+
+		case <-c.done:
+			return
+
 		case <-ticker.C:
-			c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+			c.ws.SetWriteDeadline(time.Now().Add(c.Hub.cfg.WriteWait))
 			if err := c.ws.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
 		}
 	}
 }
+
+// flush дописывает уже поставленные в очередь сообщения перед закрытием,
+// чтобы, например, кадр reconnect ушёл раньше close-кадра.
+func (c *Connection) flush() {
+	for {
+		select {
+		case f := <-c.Send:
+			if err := c.write(f); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// write кодирует кадр в формат, согласованный при рукопожатии.
+func (c *Connection) write(f outFrame) error {
+	data, err := c.codec.Encode(f)
+	if err != nil {
+		return err
+	}
+
+	// без согласованного permessage-deflate вызов ничего не меняет
+	c.ws.EnableWriteCompression(len(data) >= c.Hub.cfg.CompressionThreshold)
+
+	if err := c.ws.WriteMessage(c.codec.FrameType(), data); err != nil {
+		return err
+	}
+	c.countOut(len(data))
+	return nil
+}
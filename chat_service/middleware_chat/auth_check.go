@@ -11,6 +11,8 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// ProfileClient проверяет access-токен: gRPC-клиент profile_service
+// или локальный jwtverify.Verifier.
 type ProfileClient interface {
 	ValidateToken(ctx context.Context, req *profile.TokenRequest) (*profile.TokenResponse, error)
 }
@@ -33,7 +35,7 @@ func NewAuthMiddleware(client ProfileClient, log *logrus.Logger) gin.HandlerFunc
 		grpcCtx, cancel := context.WithTimeout(ctx, 5*time.Second) // Увеличили таймаут
 		defer cancel()
 
-		// Проверяем токен через gRPC-клиент
+		// Проверяем токен
 		resp, err := client.ValidateToken(grpcCtx, &profile.TokenRequest{Token: token})
 		if err != nil || !resp.Valid {
 			customErr := NewCustomError(http.StatusUnauthorized, "Invalid token", err)
@@ -43,6 +45,8 @@ func NewAuthMiddleware(client ProfileClient, log *logrus.Logger) gin.HandlerFunc
 		}
 
 		ctx.Set("user_id", resp.UserId)
+		ctx.Set("token_expires_at", resp.ExpiresAt)
+		ctx.Set("session_id", resp.SessionId)
 		ctx.Next()
 	}
 }
@@ -88,3 +88,16 @@ func (c *ProfileClient) CanSendDirect(ctx context.Context, from, to int64) (*pro
 		},
 	)
 }
+
+func (c *ProfileClient) CanViewLastSeen(ctx context.Context, viewerId int64, userIds []int64) (*profile.CanViewLastSeenResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	return c.authzClient.CanViewLastSeen(
+		ctx,
+		&profile.CanViewLastSeenRequest{
+			ViewerId: viewerId,
+			UserIds:  userIds,
+		},
+	)
+}
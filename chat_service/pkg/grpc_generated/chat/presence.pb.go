@@ -27,7 +27,8 @@ type OnConnectRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	ConnId        int64                  `protobuf:"varint,2,opt,name=conn_id,json=connId,proto3" json:"conn_id,omitempty"`
-	Device        string                 `protobuf:"bytes,3,opt,name=device,proto3" json:"device,omitempty"`
+	Device        string                 `protobuf:"bytes,3,opt,name=device,proto3" json:"device,omitempty"` // "web", "desktop", "mobile"
+	AppVersion    string                 `protobuf:"bytes,4,opt,name=app_version,json=appVersion,proto3" json:"app_version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -83,6 +84,13 @@ func (x *OnConnectRequest) GetDevice() string {
 	return ""
 }
 
+func (x *OnConnectRequest) GetAppVersion() string {
+	if x != nil {
+		return x.AppVersion
+	}
+	return ""
+}
+
 type OnDisconnectRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
@@ -223,6 +231,50 @@ func (x *GetPresenceRequest) GetUserId() int64 {
 	return 0
 }
 
+type GetPresenceBatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserIds       []int64                `protobuf:"varint,1,rep,packed,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPresenceBatchRequest) Reset() {
+	*x = GetPresenceBatchRequest{}
+	mi := &file_chat_presence_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPresenceBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPresenceBatchRequest) ProtoMessage() {}
+
+func (x *GetPresenceBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_presence_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPresenceBatchRequest.ProtoReflect.Descriptor instead.
+func (*GetPresenceBatchRequest) Descriptor() ([]byte, []int) {
+	return file_chat_presence_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetPresenceBatchRequest) GetUserIds() []int64 {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
 type GetOnlineFriendsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
@@ -233,7 +285,7 @@ type GetOnlineFriendsRequest struct {
 
 func (x *GetOnlineFriendsRequest) Reset() {
 	*x = GetOnlineFriendsRequest{}
-	mi := &file_chat_presence_proto_msgTypes[4]
+	mi := &file_chat_presence_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -245,7 +297,7 @@ func (x *GetOnlineFriendsRequest) String() string {
 func (*GetOnlineFriendsRequest) ProtoMessage() {}
 
 func (x *GetOnlineFriendsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_presence_proto_msgTypes[4]
+	mi := &file_chat_presence_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -258,7 +310,7 @@ func (x *GetOnlineFriendsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetOnlineFriendsRequest.ProtoReflect.Descriptor instead.
 func (*GetOnlineFriendsRequest) Descriptor() ([]byte, []int) {
-	return file_chat_presence_proto_rawDescGZIP(), []int{4}
+	return file_chat_presence_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GetOnlineFriendsRequest) GetUserId() int64 {
@@ -275,6 +327,118 @@ func (x *GetOnlineFriendsRequest) GetFriendsIds() []int64 {
 	return nil
 }
 
+type WatchPresenceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserIds       []int64                `protobuf:"varint,1,rep,packed,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchPresenceRequest) Reset() {
+	*x = WatchPresenceRequest{}
+	mi := &file_chat_presence_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchPresenceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchPresenceRequest) ProtoMessage() {}
+
+func (x *WatchPresenceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_presence_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchPresenceRequest.ProtoReflect.Descriptor instead.
+func (*WatchPresenceRequest) Descriptor() ([]byte, []int) {
+	return file_chat_presence_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WatchPresenceRequest) GetUserIds() []int64 {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
+type DisconnectUserRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Reason          string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`                                             // "account_deleted", "tokens_revoked"
+	SessionId       int64                  `protobuf:"varint,3,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`                     // 0 — все соединения пользователя
+	ExceptSessionId int64                  `protobuf:"varint,4,opt,name=except_session_id,json=exceptSessionId,proto3" json:"except_session_id,omitempty"` // соединения этой сессии не закрываются
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DisconnectUserRequest) Reset() {
+	*x = DisconnectUserRequest{}
+	mi := &file_chat_presence_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DisconnectUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DisconnectUserRequest) ProtoMessage() {}
+
+func (x *DisconnectUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_presence_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DisconnectUserRequest.ProtoReflect.Descriptor instead.
+func (*DisconnectUserRequest) Descriptor() ([]byte, []int) {
+	return file_chat_presence_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DisconnectUserRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *DisconnectUserRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *DisconnectUserRequest) GetSessionId() int64 {
+	if x != nil {
+		return x.SessionId
+	}
+	return 0
+}
+
+func (x *DisconnectUserRequest) GetExceptSessionId() int64 {
+	if x != nil {
+		return x.ExceptSessionId
+	}
+	return 0
+}
+
 type EmptyResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -283,7 +447,7 @@ type EmptyResponse struct {
 
 func (x *EmptyResponse) Reset() {
 	*x = EmptyResponse{}
-	mi := &file_chat_presence_proto_msgTypes[5]
+	mi := &file_chat_presence_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -295,7 +459,7 @@ func (x *EmptyResponse) String() string {
 func (*EmptyResponse) ProtoMessage() {}
 
 func (x *EmptyResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_presence_proto_msgTypes[5]
+	mi := &file_chat_presence_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -308,7 +472,7 @@ func (x *EmptyResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EmptyResponse.ProtoReflect.Descriptor instead.
 func (*EmptyResponse) Descriptor() ([]byte, []int) {
-	return file_chat_presence_proto_rawDescGZIP(), []int{5}
+	return file_chat_presence_proto_rawDescGZIP(), []int{8}
 }
 
 type GetPresenceResponse struct {
@@ -316,13 +480,14 @@ type GetPresenceResponse struct {
 	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // "online", "idle", "offline"
 	LastSeen      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	Devices       []*DevicePresence      `protobuf:"bytes,4,rep,name=devices,proto3" json:"devices,omitempty"` // пусто, если пользователь offline
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetPresenceResponse) Reset() {
 	*x = GetPresenceResponse{}
-	mi := &file_chat_presence_proto_msgTypes[6]
+	mi := &file_chat_presence_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -334,7 +499,7 @@ func (x *GetPresenceResponse) String() string {
 func (*GetPresenceResponse) ProtoMessage() {}
 
 func (x *GetPresenceResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_presence_proto_msgTypes[6]
+	mi := &file_chat_presence_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -347,7 +512,7 @@ func (x *GetPresenceResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetPresenceResponse.ProtoReflect.Descriptor instead.
 func (*GetPresenceResponse) Descriptor() ([]byte, []int) {
-	return file_chat_presence_proto_rawDescGZIP(), []int{6}
+	return file_chat_presence_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *GetPresenceResponse) GetUserId() int64 {
@@ -371,6 +536,125 @@ func (x *GetPresenceResponse) GetLastSeen() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *GetPresenceResponse) GetDevices() []*DevicePresence {
+	if x != nil {
+		return x.Devices
+	}
+	return nil
+}
+
+type DevicePresence struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Device        string                 `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"` // "web", "desktop", "mobile"
+	AppVersion    string                 `protobuf:"bytes,2,opt,name=app_version,json=appVersion,proto3" json:"app_version,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"` // "online", "idle"
+	LastActivity  *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_activity,json=lastActivity,proto3" json:"last_activity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DevicePresence) Reset() {
+	*x = DevicePresence{}
+	mi := &file_chat_presence_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DevicePresence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DevicePresence) ProtoMessage() {}
+
+func (x *DevicePresence) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_presence_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DevicePresence.ProtoReflect.Descriptor instead.
+func (*DevicePresence) Descriptor() ([]byte, []int) {
+	return file_chat_presence_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DevicePresence) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *DevicePresence) GetAppVersion() string {
+	if x != nil {
+		return x.AppVersion
+	}
+	return ""
+}
+
+func (x *DevicePresence) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *DevicePresence) GetLastActivity() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastActivity
+	}
+	return nil
+}
+
+type GetPresenceBatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Presences     []*GetPresenceResponse `protobuf:"bytes,1,rep,name=presences,proto3" json:"presences,omitempty"` // в порядке user_ids из запроса
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPresenceBatchResponse) Reset() {
+	*x = GetPresenceBatchResponse{}
+	mi := &file_chat_presence_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPresenceBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPresenceBatchResponse) ProtoMessage() {}
+
+func (x *GetPresenceBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_presence_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPresenceBatchResponse.ProtoReflect.Descriptor instead.
+func (*GetPresenceBatchResponse) Descriptor() ([]byte, []int) {
+	return file_chat_presence_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetPresenceBatchResponse) GetPresences() []*GetPresenceResponse {
+	if x != nil {
+		return x.Presences
+	}
+	return nil
+}
+
 type GetOnlineFriendsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	OnlineFriends []int64                `protobuf:"varint,1,rep,packed,name=online_friends,json=onlineFriends,proto3" json:"online_friends,omitempty"`
@@ -380,7 +664,7 @@ type GetOnlineFriendsResponse struct {
 
 func (x *GetOnlineFriendsResponse) Reset() {
 	*x = GetOnlineFriendsResponse{}
-	mi := &file_chat_presence_proto_msgTypes[7]
+	mi := &file_chat_presence_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -392,7 +676,7 @@ func (x *GetOnlineFriendsResponse) String() string {
 func (*GetOnlineFriendsResponse) ProtoMessage() {}
 
 func (x *GetOnlineFriendsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_presence_proto_msgTypes[7]
+	mi := &file_chat_presence_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -405,7 +689,7 @@ func (x *GetOnlineFriendsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetOnlineFriendsResponse.ProtoReflect.Descriptor instead.
 func (*GetOnlineFriendsResponse) Descriptor() ([]byte, []int) {
-	return file_chat_presence_proto_rawDescGZIP(), []int{7}
+	return file_chat_presence_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *GetOnlineFriendsResponse) GetOnlineFriends() []int64 {
@@ -415,39 +699,127 @@ func (x *GetOnlineFriendsResponse) GetOnlineFriends() []int64 {
 	return nil
 }
 
+type PresenceUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Event         string                 `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"` // "user_online", "user_offline", "user_idle", "user_active"
+	Presence      *GetPresenceResponse   `protobuf:"bytes,2,opt,name=presence,proto3" json:"presence,omitempty"`
+	UserId        int64                  `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PresenceUpdate) Reset() {
+	*x = PresenceUpdate{}
+	mi := &file_chat_presence_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PresenceUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PresenceUpdate) ProtoMessage() {}
+
+func (x *PresenceUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_presence_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PresenceUpdate.ProtoReflect.Descriptor instead.
+func (*PresenceUpdate) Descriptor() ([]byte, []int) {
+	return file_chat_presence_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *PresenceUpdate) GetEvent() string {
+	if x != nil {
+		return x.Event
+	}
+	return ""
+}
+
+func (x *PresenceUpdate) GetPresence() *GetPresenceResponse {
+	if x != nil {
+		return x.Presence
+	}
+	return nil
+}
+
+func (x *PresenceUpdate) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
 var File_chat_presence_proto protoreflect.FileDescriptor
 
 const file_chat_presence_proto_rawDesc = "" +
 	"\n" +
-	"\x13chat/presence.proto\x12\x04chat\x1a\x1fgoogle/protobuf/timestamp.proto\"\\\n" +
+	"\x13chat/presence.proto\x12\x04chat\x1a\x1fgoogle/protobuf/timestamp.proto\"}\n" +
 	"\x10OnConnectRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x17\n" +
 	"\aconn_id\x18\x02 \x01(\x03R\x06connId\x12\x16\n" +
-	"\x06device\x18\x03 \x01(\tR\x06device\"G\n" +
+	"\x06device\x18\x03 \x01(\tR\x06device\x12\x1f\n" +
+	"\vapp_version\x18\x04 \x01(\tR\n" +
+	"appVersion\"G\n" +
 	"\x13OnDisconnectRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x17\n" +
 	"\aconn_id\x18\x02 \x01(\x03R\x06connId\"-\n" +
 	"\x12OnHeartbeatRequest\x12\x17\n" +
 	"\aconn_id\x18\x01 \x01(\x03R\x06connId\"-\n" +
 	"\x12GetPresenceRequest\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\x03R\x06userId\"S\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\"4\n" +
+	"\x17GetPresenceBatchRequest\x12\x19\n" +
+	"\buser_ids\x18\x01 \x03(\x03R\auserIds\"S\n" +
 	"\x17GetOnlineFriendsRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x1f\n" +
 	"\vfriends_ids\x18\x02 \x03(\x03R\n" +
-	"friendsIds\"\x0f\n" +
-	"\rEmptyResponse\"\x7f\n" +
+	"friendsIds\"1\n" +
+	"\x14WatchPresenceRequest\x12\x19\n" +
+	"\buser_ids\x18\x01 \x03(\x03R\auserIds\"\x93\x01\n" +
+	"\x15DisconnectUserRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x03 \x01(\x03R\tsessionId\x12*\n" +
+	"\x11except_session_id\x18\x04 \x01(\x03R\x0fexceptSessionId\"\x0f\n" +
+	"\rEmptyResponse\"\xaf\x01\n" +
 	"\x13GetPresenceResponse\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x16\n" +
 	"\x06status\x18\x02 \x01(\tR\x06status\x127\n" +
-	"\tlast_seen\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\blastSeen\"A\n" +
+	"\tlast_seen\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\blastSeen\x12.\n" +
+	"\adevices\x18\x04 \x03(\v2\x14.chat.DevicePresenceR\adevices\"\xa2\x01\n" +
+	"\x0eDevicePresence\x12\x16\n" +
+	"\x06device\x18\x01 \x01(\tR\x06device\x12\x1f\n" +
+	"\vapp_version\x18\x02 \x01(\tR\n" +
+	"appVersion\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12?\n" +
+	"\rlast_activity\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\flastActivity\"S\n" +
+	"\x18GetPresenceBatchResponse\x127\n" +
+	"\tpresences\x18\x01 \x03(\v2\x19.chat.GetPresenceResponseR\tpresences\"A\n" +
 	"\x18GetOnlineFriendsResponse\x12%\n" +
-	"\x0eonline_friends\x18\x01 \x03(\x03R\ronlineFriends2\xd9\x02\n" +
+	"\x0eonline_friends\x18\x01 \x03(\x03R\ronlineFriends\"v\n" +
+	"\x0ePresenceUpdate\x12\x14\n" +
+	"\x05event\x18\x01 \x01(\tR\x05event\x125\n" +
+	"\bpresence\x18\x02 \x01(\v2\x19.chat.GetPresenceResponseR\bpresence\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\x03R\x06userId2\xb5\x04\n" +
 	"\bPresence\x128\n" +
 	"\tOnConnect\x12\x16.chat.OnConnectRequest\x1a\x13.chat.EmptyResponse\x12>\n" +
 	"\fOnDisconnect\x12\x19.chat.OnDisconnectRequest\x1a\x13.chat.EmptyResponse\x12<\n" +
 	"\vOnHeartbeat\x12\x18.chat.OnHeartbeatRequest\x1a\x13.chat.EmptyResponse\x12B\n" +
 	"\vGetPresence\x12\x18.chat.GetPresenceRequest\x1a\x19.chat.GetPresenceResponse\x12Q\n" +
-	"\x10GetOnlineFriends\x12\x1d.chat.GetOnlineFriendsRequest\x1a\x1e.chat.GetOnlineFriendsResponseB\bZ\x06./gRPCb\x06proto3"
+	"\x10GetPresenceBatch\x12\x1d.chat.GetPresenceBatchRequest\x1a\x1e.chat.GetPresenceBatchResponse\x12Q\n" +
+	"\x10GetOnlineFriends\x12\x1d.chat.GetOnlineFriendsRequest\x1a\x1e.chat.GetOnlineFriendsResponse\x12C\n" +
+	"\rWatchPresence\x12\x1a.chat.WatchPresenceRequest\x1a\x14.chat.PresenceUpdate0\x01\x12B\n" +
+	"\x0eDisconnectUser\x12\x1b.chat.DisconnectUserRequest\x1a\x13.chat.EmptyResponseB\bZ\x06./gRPCb\x06proto3"
 
 var (
 	file_chat_presence_proto_rawDescOnce sync.Once
@@ -461,35 +833,51 @@ func file_chat_presence_proto_rawDescGZIP() []byte {
 	return file_chat_presence_proto_rawDescData
 }
 
-var file_chat_presence_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_chat_presence_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_chat_presence_proto_goTypes = []any{
 	(*OnConnectRequest)(nil),         // 0: chat.OnConnectRequest
 	(*OnDisconnectRequest)(nil),      // 1: chat.OnDisconnectRequest
 	(*OnHeartbeatRequest)(nil),       // 2: chat.OnHeartbeatRequest
 	(*GetPresenceRequest)(nil),       // 3: chat.GetPresenceRequest
-	(*GetOnlineFriendsRequest)(nil),  // 4: chat.GetOnlineFriendsRequest
-	(*EmptyResponse)(nil),            // 5: chat.EmptyResponse
-	(*GetPresenceResponse)(nil),      // 6: chat.GetPresenceResponse
-	(*GetOnlineFriendsResponse)(nil), // 7: chat.GetOnlineFriendsResponse
-	(*timestamppb.Timestamp)(nil),    // 8: google.protobuf.Timestamp
+	(*GetPresenceBatchRequest)(nil),  // 4: chat.GetPresenceBatchRequest
+	(*GetOnlineFriendsRequest)(nil),  // 5: chat.GetOnlineFriendsRequest
+	(*WatchPresenceRequest)(nil),     // 6: chat.WatchPresenceRequest
+	(*DisconnectUserRequest)(nil),    // 7: chat.DisconnectUserRequest
+	(*EmptyResponse)(nil),            // 8: chat.EmptyResponse
+	(*GetPresenceResponse)(nil),      // 9: chat.GetPresenceResponse
+	(*DevicePresence)(nil),           // 10: chat.DevicePresence
+	(*GetPresenceBatchResponse)(nil), // 11: chat.GetPresenceBatchResponse
+	(*GetOnlineFriendsResponse)(nil), // 12: chat.GetOnlineFriendsResponse
+	(*PresenceUpdate)(nil),           // 13: chat.PresenceUpdate
+	(*timestamppb.Timestamp)(nil),    // 14: google.protobuf.Timestamp
 }
 var file_chat_presence_proto_depIdxs = []int32{
-	8, // 0: chat.GetPresenceResponse.last_seen:type_name -> google.protobuf.Timestamp
-	0, // 1: chat.Presence.OnConnect:input_type -> chat.OnConnectRequest
-	1, // 2: chat.Presence.OnDisconnect:input_type -> chat.OnDisconnectRequest
-	2, // 3: chat.Presence.OnHeartbeat:input_type -> chat.OnHeartbeatRequest
-	3, // 4: chat.Presence.GetPresence:input_type -> chat.GetPresenceRequest
-	4, // 5: chat.Presence.GetOnlineFriends:input_type -> chat.GetOnlineFriendsRequest
-	5, // 6: chat.Presence.OnConnect:output_type -> chat.EmptyResponse
-	5, // 7: chat.Presence.OnDisconnect:output_type -> chat.EmptyResponse
-	5, // 8: chat.Presence.OnHeartbeat:output_type -> chat.EmptyResponse
-	6, // 9: chat.Presence.GetPresence:output_type -> chat.GetPresenceResponse
-	7, // 10: chat.Presence.GetOnlineFriends:output_type -> chat.GetOnlineFriendsResponse
-	6, // [6:11] is the sub-list for method output_type
-	1, // [1:6] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	14, // 0: chat.GetPresenceResponse.last_seen:type_name -> google.protobuf.Timestamp
+	10, // 1: chat.GetPresenceResponse.devices:type_name -> chat.DevicePresence
+	14, // 2: chat.DevicePresence.last_activity:type_name -> google.protobuf.Timestamp
+	9,  // 3: chat.GetPresenceBatchResponse.presences:type_name -> chat.GetPresenceResponse
+	9,  // 4: chat.PresenceUpdate.presence:type_name -> chat.GetPresenceResponse
+	0,  // 5: chat.Presence.OnConnect:input_type -> chat.OnConnectRequest
+	1,  // 6: chat.Presence.OnDisconnect:input_type -> chat.OnDisconnectRequest
+	2,  // 7: chat.Presence.OnHeartbeat:input_type -> chat.OnHeartbeatRequest
+	3,  // 8: chat.Presence.GetPresence:input_type -> chat.GetPresenceRequest
+	4,  // 9: chat.Presence.GetPresenceBatch:input_type -> chat.GetPresenceBatchRequest
+	5,  // 10: chat.Presence.GetOnlineFriends:input_type -> chat.GetOnlineFriendsRequest
+	6,  // 11: chat.Presence.WatchPresence:input_type -> chat.WatchPresenceRequest
+	7,  // 12: chat.Presence.DisconnectUser:input_type -> chat.DisconnectUserRequest
+	8,  // 13: chat.Presence.OnConnect:output_type -> chat.EmptyResponse
+	8,  // 14: chat.Presence.OnDisconnect:output_type -> chat.EmptyResponse
+	8,  // 15: chat.Presence.OnHeartbeat:output_type -> chat.EmptyResponse
+	9,  // 16: chat.Presence.GetPresence:output_type -> chat.GetPresenceResponse
+	11, // 17: chat.Presence.GetPresenceBatch:output_type -> chat.GetPresenceBatchResponse
+	12, // 18: chat.Presence.GetOnlineFriends:output_type -> chat.GetOnlineFriendsResponse
+	13, // 19: chat.Presence.WatchPresence:output_type -> chat.PresenceUpdate
+	8,  // 20: chat.Presence.DisconnectUser:output_type -> chat.EmptyResponse
+	13, // [13:21] is the sub-list for method output_type
+	5,  // [5:13] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_chat_presence_proto_init() }
@@ -503,7 +891,7 @@ func file_chat_presence_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_chat_presence_proto_rawDesc), len(file_chat_presence_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   8,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
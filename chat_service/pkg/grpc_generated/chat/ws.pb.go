@@ -0,0 +1,574 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.31.1
+// source: chat/ws.proto
+
+package chat
+
+import (
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Кадр WebSocket-подпротокола chat.v1.proto (бинарные сообщения).
+// Повторяет JSON-кадр {"seq", "type", "payload"}: известные типы нагрузки
+// передаются типизированно, остальные — как JSON в поле json.
+type WsFrame struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Seq   int64                  `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Type  string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*WsFrame_Chat
+	//	*WsFrame_Presence
+	//	*WsFrame_System
+	//	*WsFrame_Resend
+	//	*WsFrame_Json
+	Payload       isWsFrame_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WsFrame) Reset() {
+	*x = WsFrame{}
+	mi := &file_chat_ws_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WsFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WsFrame) ProtoMessage() {}
+
+func (x *WsFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_ws_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WsFrame.ProtoReflect.Descriptor instead.
+func (*WsFrame) Descriptor() ([]byte, []int) {
+	return file_chat_ws_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WsFrame) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *WsFrame) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *WsFrame) GetPayload() isWsFrame_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *WsFrame) GetChat() *WsChat {
+	if x != nil {
+		if x, ok := x.Payload.(*WsFrame_Chat); ok {
+			return x.Chat
+		}
+	}
+	return nil
+}
+
+func (x *WsFrame) GetPresence() *WsPresence {
+	if x != nil {
+		if x, ok := x.Payload.(*WsFrame_Presence); ok {
+			return x.Presence
+		}
+	}
+	return nil
+}
+
+func (x *WsFrame) GetSystem() *WsSystem {
+	if x != nil {
+		if x, ok := x.Payload.(*WsFrame_System); ok {
+			return x.System
+		}
+	}
+	return nil
+}
+
+func (x *WsFrame) GetResend() *WsResend {
+	if x != nil {
+		if x, ok := x.Payload.(*WsFrame_Resend); ok {
+			return x.Resend
+		}
+	}
+	return nil
+}
+
+func (x *WsFrame) GetJson() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*WsFrame_Json); ok {
+			return x.Json
+		}
+	}
+	return nil
+}
+
+type isWsFrame_Payload interface {
+	isWsFrame_Payload()
+}
+
+type WsFrame_Chat struct {
+	Chat *WsChat `protobuf:"bytes,3,opt,name=chat,proto3,oneof"`
+}
+
+type WsFrame_Presence struct {
+	Presence *WsPresence `protobuf:"bytes,4,opt,name=presence,proto3,oneof"`
+}
+
+type WsFrame_System struct {
+	System *WsSystem `protobuf:"bytes,5,opt,name=system,proto3,oneof"`
+}
+
+type WsFrame_Resend struct {
+	Resend *WsResend `protobuf:"bytes,6,opt,name=resend,proto3,oneof"`
+}
+
+type WsFrame_Json struct {
+	Json []byte `protobuf:"bytes,15,opt,name=json,proto3,oneof"`
+}
+
+func (*WsFrame_Chat) isWsFrame_Payload() {}
+
+func (*WsFrame_Presence) isWsFrame_Payload() {}
+
+func (*WsFrame_System) isWsFrame_Payload() {}
+
+func (*WsFrame_Resend) isWsFrame_Payload() {}
+
+func (*WsFrame_Json) isWsFrame_Payload() {}
+
+type WsChat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Action        string                 `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`
+	ToUserId      int64                  `protobuf:"varint,3,opt,name=to_user_id,json=toUserId,proto3" json:"to_user_id,omitempty"`
+	RoomId        int64                  `protobuf:"varint,4,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	FromUserId    int64                  `protobuf:"varint,5,opt,name=from_user_id,json=fromUserId,proto3" json:"from_user_id,omitempty"`
+	Text          string                 `protobuf:"bytes,6,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WsChat) Reset() {
+	*x = WsChat{}
+	mi := &file_chat_ws_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WsChat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WsChat) ProtoMessage() {}
+
+func (x *WsChat) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_ws_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WsChat.ProtoReflect.Descriptor instead.
+func (*WsChat) Descriptor() ([]byte, []int) {
+	return file_chat_ws_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *WsChat) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *WsChat) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *WsChat) GetToUserId() int64 {
+	if x != nil {
+		return x.ToUserId
+	}
+	return 0
+}
+
+func (x *WsChat) GetRoomId() int64 {
+	if x != nil {
+		return x.RoomId
+	}
+	return 0
+}
+
+func (x *WsChat) GetFromUserId() int64 {
+	if x != nil {
+		return x.FromUserId
+	}
+	return 0
+}
+
+func (x *WsChat) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+// Команды клиента (cmd, user_ids) и события presence от сервера (user_id, event)
+type WsPresence struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cmd           string                 `protobuf:"bytes,1,opt,name=cmd,proto3" json:"cmd,omitempty"`
+	UserIds       []int64                `protobuf:"varint,2,rep,packed,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	UserId        int64                  `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Event         string                 `protobuf:"bytes,4,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WsPresence) Reset() {
+	*x = WsPresence{}
+	mi := &file_chat_ws_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WsPresence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WsPresence) ProtoMessage() {}
+
+func (x *WsPresence) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_ws_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WsPresence.ProtoReflect.Descriptor instead.
+func (*WsPresence) Descriptor() ([]byte, []int) {
+	return file_chat_ws_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *WsPresence) GetCmd() string {
+	if x != nil {
+		return x.Cmd
+	}
+	return ""
+}
+
+func (x *WsPresence) GetUserIds() []int64 {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
+func (x *WsPresence) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *WsPresence) GetEvent() string {
+	if x != nil {
+		return x.Event
+	}
+	return ""
+}
+
+type WsSystem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Event         string                 `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	RetryAfterMs  int64                  `protobuf:"varint,2,opt,name=retry_after_ms,json=retryAfterMs,proto3" json:"retry_after_ms,omitempty"`
+	SessionToken  string                 `protobuf:"bytes,3,opt,name=session_token,json=sessionToken,proto3" json:"session_token,omitempty"`
+	ResumeTtlMs   int64                  `protobuf:"varint,4,opt,name=resume_ttl_ms,json=resumeTtlMs,proto3" json:"resume_ttl_ms,omitempty"`
+	Resumed       bool                   `protobuf:"varint,5,opt,name=resumed,proto3" json:"resumed,omitempty"`
+	LastSeq       int64                  `protobuf:"varint,6,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`
+	FirstSeq      int64                  `protobuf:"varint,7,opt,name=first_seq,json=firstSeq,proto3" json:"first_seq,omitempty"`
+	ExpiresAt     int64                  `protobuf:"varint,8,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WsSystem) Reset() {
+	*x = WsSystem{}
+	mi := &file_chat_ws_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WsSystem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WsSystem) ProtoMessage() {}
+
+func (x *WsSystem) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_ws_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WsSystem.ProtoReflect.Descriptor instead.
+func (*WsSystem) Descriptor() ([]byte, []int) {
+	return file_chat_ws_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *WsSystem) GetEvent() string {
+	if x != nil {
+		return x.Event
+	}
+	return ""
+}
+
+func (x *WsSystem) GetRetryAfterMs() int64 {
+	if x != nil {
+		return x.RetryAfterMs
+	}
+	return 0
+}
+
+func (x *WsSystem) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
+func (x *WsSystem) GetResumeTtlMs() int64 {
+	if x != nil {
+		return x.ResumeTtlMs
+	}
+	return 0
+}
+
+func (x *WsSystem) GetResumed() bool {
+	if x != nil {
+		return x.Resumed
+	}
+	return false
+}
+
+func (x *WsSystem) GetLastSeq() int64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
+func (x *WsSystem) GetFirstSeq() int64 {
+	if x != nil {
+		return x.FirstSeq
+	}
+	return 0
+}
+
+func (x *WsSystem) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type WsResend struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromSeq       int64                  `protobuf:"varint,1,opt,name=from_seq,json=fromSeq,proto3" json:"from_seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WsResend) Reset() {
+	*x = WsResend{}
+	mi := &file_chat_ws_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WsResend) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WsResend) ProtoMessage() {}
+
+func (x *WsResend) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_ws_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WsResend.ProtoReflect.Descriptor instead.
+func (*WsResend) Descriptor() ([]byte, []int) {
+	return file_chat_ws_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WsResend) GetFromSeq() int64 {
+	if x != nil {
+		return x.FromSeq
+	}
+	return 0
+}
+
+var File_chat_ws_proto protoreflect.FileDescriptor
+
+const file_chat_ws_proto_rawDesc = "" +
+	"\n" +
+	"\rchat/ws.proto\x12\x04chat\"\xf8\x01\n" +
+	"\aWsFrame\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x03R\x03seq\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\"\n" +
+	"\x04chat\x18\x03 \x01(\v2\f.chat.WsChatH\x00R\x04chat\x12.\n" +
+	"\bpresence\x18\x04 \x01(\v2\x10.chat.WsPresenceH\x00R\bpresence\x12(\n" +
+	"\x06system\x18\x05 \x01(\v2\x0e.chat.WsSystemH\x00R\x06system\x12(\n" +
+	"\x06resend\x18\x06 \x01(\v2\x0e.chat.WsResendH\x00R\x06resend\x12\x14\n" +
+	"\x04json\x18\x0f \x01(\fH\x00R\x04jsonB\t\n" +
+	"\apayload\"\xa1\x01\n" +
+	"\x06WsChat\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x16\n" +
+	"\x06action\x18\x02 \x01(\tR\x06action\x12\x1c\n" +
+	"\n" +
+	"to_user_id\x18\x03 \x01(\x03R\btoUserId\x12\x17\n" +
+	"\aroom_id\x18\x04 \x01(\x03R\x06roomId\x12 \n" +
+	"\ffrom_user_id\x18\x05 \x01(\x03R\n" +
+	"fromUserId\x12\x12\n" +
+	"\x04text\x18\x06 \x01(\tR\x04text\"h\n" +
+	"\n" +
+	"WsPresence\x12\x10\n" +
+	"\x03cmd\x18\x01 \x01(\tR\x03cmd\x12\x19\n" +
+	"\buser_ids\x18\x02 \x03(\x03R\auserIds\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\x03R\x06userId\x12\x14\n" +
+	"\x05event\x18\x04 \x01(\tR\x05event\"\x80\x02\n" +
+	"\bWsSystem\x12\x14\n" +
+	"\x05event\x18\x01 \x01(\tR\x05event\x12$\n" +
+	"\x0eretry_after_ms\x18\x02 \x01(\x03R\fretryAfterMs\x12#\n" +
+	"\rsession_token\x18\x03 \x01(\tR\fsessionToken\x12\"\n" +
+	"\rresume_ttl_ms\x18\x04 \x01(\x03R\vresumeTtlMs\x12\x18\n" +
+	"\aresumed\x18\x05 \x01(\bR\aresumed\x12\x19\n" +
+	"\blast_seq\x18\x06 \x01(\x03R\alastSeq\x12\x1b\n" +
+	"\tfirst_seq\x18\a \x01(\x03R\bfirstSeq\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\b \x01(\x03R\texpiresAt\"%\n" +
+	"\bWsResend\x12\x19\n" +
+	"\bfrom_seq\x18\x01 \x01(\x03R\afromSeqB\bZ\x06./gRPCb\x06proto3"
+
+var (
+	file_chat_ws_proto_rawDescOnce sync.Once
+	file_chat_ws_proto_rawDescData []byte
+)
+
+func file_chat_ws_proto_rawDescGZIP() []byte {
+	file_chat_ws_proto_rawDescOnce.Do(func() {
+		file_chat_ws_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_chat_ws_proto_rawDesc), len(file_chat_ws_proto_rawDesc)))
+	})
+	return file_chat_ws_proto_rawDescData
+}
+
+var file_chat_ws_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_chat_ws_proto_goTypes = []any{
+	(*WsFrame)(nil),    // 0: chat.WsFrame
+	(*WsChat)(nil),     // 1: chat.WsChat
+	(*WsPresence)(nil), // 2: chat.WsPresence
+	(*WsSystem)(nil),   // 3: chat.WsSystem
+	(*WsResend)(nil),   // 4: chat.WsResend
+}
+var file_chat_ws_proto_depIdxs = []int32{
+	1, // 0: chat.WsFrame.chat:type_name -> chat.WsChat
+	2, // 1: chat.WsFrame.presence:type_name -> chat.WsPresence
+	3, // 2: chat.WsFrame.system:type_name -> chat.WsSystem
+	4, // 3: chat.WsFrame.resend:type_name -> chat.WsResend
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_chat_ws_proto_init() }
+func file_chat_ws_proto_init() {
+	if File_chat_ws_proto != nil {
+		return
+	}
+	file_chat_ws_proto_msgTypes[0].OneofWrappers = []any{
+		(*WsFrame_Chat)(nil),
+		(*WsFrame_Presence)(nil),
+		(*WsFrame_System)(nil),
+		(*WsFrame_Resend)(nil),
+		(*WsFrame_Json)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_chat_ws_proto_rawDesc), len(file_chat_ws_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_chat_ws_proto_goTypes,
+		DependencyIndexes: file_chat_ws_proto_depIdxs,
+		MessageInfos:      file_chat_ws_proto_msgTypes,
+	}.Build()
+	File_chat_ws_proto = out.File
+	file_chat_ws_proto_goTypes = nil
+	file_chat_ws_proto_depIdxs = nil
+}
@@ -20,8 +20,9 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	AuthorizationService_CanSendDirect_FullMethodName = "/profile.AuthorizationService/CanSendDirect"
-	AuthorizationService_CanJoinRoom_FullMethodName   = "/profile.AuthorizationService/CanJoinRoom"
+	AuthorizationService_CanSendDirect_FullMethodName   = "/profile.AuthorizationService/CanSendDirect"
+	AuthorizationService_CanJoinRoom_FullMethodName     = "/profile.AuthorizationService/CanJoinRoom"
+	AuthorizationService_CanViewLastSeen_FullMethodName = "/profile.AuthorizationService/CanViewLastSeen"
 )
 
 // AuthorizationServiceClient is the client API for AuthorizationService service.
@@ -30,6 +31,7 @@ const (
 type AuthorizationServiceClient interface {
 	CanSendDirect(ctx context.Context, in *CanSendDirectRequest, opts ...grpc.CallOption) (*CanSendDirectResponse, error)
 	CanJoinRoom(ctx context.Context, in *CanJoinRoomRequest, opts ...grpc.CallOption) (*CanJoinRoomResponse, error)
+	CanViewLastSeen(ctx context.Context, in *CanViewLastSeenRequest, opts ...grpc.CallOption) (*CanViewLastSeenResponse, error)
 }
 
 type authorizationServiceClient struct {
@@ -60,12 +62,23 @@ func (c *authorizationServiceClient) CanJoinRoom(ctx context.Context, in *CanJoi
 	return out, nil
 }
 
+func (c *authorizationServiceClient) CanViewLastSeen(ctx context.Context, in *CanViewLastSeenRequest, opts ...grpc.CallOption) (*CanViewLastSeenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CanViewLastSeenResponse)
+	err := c.cc.Invoke(ctx, AuthorizationService_CanViewLastSeen_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AuthorizationServiceServer is the server API for AuthorizationService service.
 // All implementations must embed UnimplementedAuthorizationServiceServer
 // for forward compatibility.
 type AuthorizationServiceServer interface {
 	CanSendDirect(context.Context, *CanSendDirectRequest) (*CanSendDirectResponse, error)
 	CanJoinRoom(context.Context, *CanJoinRoomRequest) (*CanJoinRoomResponse, error)
+	CanViewLastSeen(context.Context, *CanViewLastSeenRequest) (*CanViewLastSeenResponse, error)
 	mustEmbedUnimplementedAuthorizationServiceServer()
 }
 
@@ -82,6 +95,9 @@ func (UnimplementedAuthorizationServiceServer) CanSendDirect(context.Context, *C
 func (UnimplementedAuthorizationServiceServer) CanJoinRoom(context.Context, *CanJoinRoomRequest) (*CanJoinRoomResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method CanJoinRoom not implemented")
 }
+func (UnimplementedAuthorizationServiceServer) CanViewLastSeen(context.Context, *CanViewLastSeenRequest) (*CanViewLastSeenResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CanViewLastSeen not implemented")
+}
 func (UnimplementedAuthorizationServiceServer) mustEmbedUnimplementedAuthorizationServiceServer() {}
 func (UnimplementedAuthorizationServiceServer) testEmbeddedByValue()                              {}
 
@@ -139,6 +155,24 @@ func _AuthorizationService_CanJoinRoom_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AuthorizationService_CanViewLastSeen_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CanViewLastSeenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthorizationServiceServer).CanViewLastSeen(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthorizationService_CanViewLastSeen_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthorizationServiceServer).CanViewLastSeen(ctx, req.(*CanViewLastSeenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AuthorizationService_ServiceDesc is the grpc.ServiceDesc for AuthorizationService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -154,6 +188,10 @@ var AuthorizationService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CanJoinRoom",
 			Handler:    _AuthorizationService_CanJoinRoom_Handler,
 		},
+		{
+			MethodName: "CanViewLastSeen",
+			Handler:    _AuthorizationService_CanViewLastSeen_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "profile/authz.proto",
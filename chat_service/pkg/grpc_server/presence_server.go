@@ -2,23 +2,41 @@ package grpc_server
 
 import (
 	"chat_service/internal/presence/service"
+	sDto "chat_service/internal/presence/service/dto"
+	"chat_service/internal/websocket"
 	"chat_service/pkg/grpc_generated/chat"
 	"context"
+	"fmt"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+const watchBufferSize = 1024
+
+// maxPresenceBatch — предел user_ids в GetPresenceBatch: один Lua-скрипт
+// на тысячи ключей надолго занимает Redis.
+const maxPresenceBatch = 500
+
+// SessionRevoker закрывает WS-сессии пользователя (все или выбранные по сессии входа) на всех инстансах.
+type SessionRevoker interface {
+	DisconnectUser(ctx context.Context, userId int64, filter websocket.SessionFilter, reason string) error
+}
+
 type GRPCServer struct {
-	svc service.PresenceService
+	svc      service.PresenceService
+	bus      *service.PresenceEventBus
+	sessions SessionRevoker
 	chat.UnimplementedPresenceServer
 }
 
-func NewGRPCServer(svc service.PresenceService) *GRPCServer {
-	return &GRPCServer{svc: svc}
+func NewGRPCServer(svc service.PresenceService, bus *service.PresenceEventBus, sessions SessionRevoker) *GRPCServer {
+	return &GRPCServer{svc: svc, bus: bus, sessions: sessions}
 }
 
 func (s *GRPCServer) OnConnect(ctx context.Context, req *chat.OnConnectRequest) (*chat.EmptyResponse, error) {
-	if err := s.svc.OnConnect(ctx, req.UserId, req.ConnId, req.Device); err != nil {
+	if err := s.svc.OnConnect(ctx, req.UserId, req.ConnId, req.Device, req.AppVersion); err != nil {
 		return nil, err
 	}
 	return &chat.EmptyResponse{}, nil
@@ -39,12 +57,23 @@ func (s *GRPCServer) OnHeartbeat(ctx context.Context, req *chat.OnHeartbeatReque
 }
 
 func (s *GRPCServer) GetPresence(ctx context.Context, req *chat.GetPresenceRequest) (*chat.GetPresenceResponse, error) {
-	p := s.svc.GetPresence(ctx, req.UserId)
-	return &chat.GetPresenceResponse{
-		UserId:   p.UserId,
-		Status:   string(p.Status),
-		LastSeen: timestamppb.New(p.LastSeen),
-	}, nil
+	return toPresenceResponse(s.svc.GetPresence(ctx, req.UserId)), nil
+}
+
+func (s *GRPCServer) GetPresenceBatch(ctx context.Context, req *chat.GetPresenceBatchRequest) (*chat.GetPresenceBatchResponse, error) {
+	if len(req.UserIds) > maxPresenceBatch {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("too many user_ids: %d, max %d", len(req.UserIds), maxPresenceBatch))
+	}
+	presences := s.svc.GetPresences(ctx, req.UserIds)
+
+	resp := &chat.GetPresenceBatchResponse{
+		Presences: make([]*chat.GetPresenceResponse, 0, len(presences)),
+	}
+	for _, p := range presences {
+		resp.Presences = append(resp.Presences, toPresenceResponse(p))
+	}
+
+	return resp, nil
 }
 
 func (s *GRPCServer) GetOnlineFriends(ctx context.Context, req *chat.GetOnlineFriendsRequest) (*chat.GetOnlineFriendsResponse, error) {
@@ -53,3 +82,71 @@ func (s *GRPCServer) GetOnlineFriends(ctx context.Context, req *chat.GetOnlineFr
 		OnlineFriends: online,
 	}, nil
 }
+
+func (s *GRPCServer) WatchPresence(req *chat.WatchPresenceRequest, stream chat.Presence_WatchPresenceServer) error {
+	watched := make(map[int64]struct{}, len(req.UserIds))
+	for _, id := range req.UserIds {
+		watched[id] = struct{}{}
+	}
+
+	sub := s.bus.SubscribeWithBuffer(watchBufferSize)
+	defer s.bus.Unsubscribe(sub)
+
+	ctx := stream.Context()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case evt, ok := <-sub:
+			if !ok {
+				// шина закрыла подписку при переполнении: клиент должен переподписаться
+				return status.Error(codes.Aborted, "presence events lost, resubscribe")
+			}
+
+			update := &chat.PresenceUpdate{Event: string(evt.Type), UserId: evt.UserId}
+			// подписке на всех пользователей уходит только само событие: читать
+			// presence из Redis на каждый переход ради всех подряд слишком дорого
+			if len(watched) > 0 {
+				if _, ok := watched[evt.UserId]; !ok {
+					continue
+				}
+				update.Presence = toPresenceResponse(s.svc.GetPresence(ctx, evt.UserId))
+			}
+
+			err := stream.Send(update)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *GRPCServer) DisconnectUser(ctx context.Context, req *chat.DisconnectUserRequest) (*chat.EmptyResponse, error) {
+	filter := websocket.SessionFilter{SessionId: req.SessionId, ExceptSessionId: req.ExceptSessionId}
+	if err := s.sessions.DisconnectUser(ctx, req.UserId, filter, req.Reason); err != nil {
+		return nil, err
+	}
+	return &chat.EmptyResponse{}, nil
+}
+
+func toPresenceResponse(p *sDto.Presence) *chat.GetPresenceResponse {
+	resp := &chat.GetPresenceResponse{
+		UserId:   p.UserId,
+		Status:   string(p.Status),
+		LastSeen: timestamppb.New(p.LastSeen),
+		Devices:  make([]*chat.DevicePresence, 0, len(p.Devices)),
+	}
+
+	for _, d := range p.Devices {
+		resp.Devices = append(resp.Devices, &chat.DevicePresence{
+			Device:       string(d.Device),
+			AppVersion:   d.AppVersion,
+			Status:       string(d.Status),
+			LastActivity: timestamppb.New(d.LastActivity),
+		})
+	}
+
+	return resp
+}
@@ -0,0 +1,58 @@
+package jwtverify
+
+import (
+	"context"
+	"errors"
+	"shared-auth/revoked"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RevocationChecker — список отозванных токенов. Его ведёт profile_service
+// (Logout, RevokeSession, RevokeAllSessions); без проверки отозванный токен действовал бы до exp.
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, userId int64, sessionId int64, jti string, issuedAt time.Time) (bool, error)
+}
+
+type redisRevocations struct {
+	rdb *redis.Client
+}
+
+// NewRedisRevocations читает ключи profile_service, поэтому оба сервиса
+// должны смотреть в один Redis и одну базу.
+func NewRedisRevocations(rdb *redis.Client) RevocationChecker {
+	return &redisRevocations{rdb: rdb}
+}
+
+func (r *redisRevocations) IsRevoked(ctx context.Context, userId int64, sessionId int64, jti string, issuedAt time.Time) (bool, error) {
+	pipe := r.rdb.Pipeline()
+	var keys []string
+	if jti != "" {
+		keys = append(keys, revoked.JtiKey(jti))
+	}
+	if sessionId != 0 {
+		keys = append(keys, revoked.SessionKey(sessionId))
+	}
+	var listed *redis.IntCmd
+	if len(keys) > 0 {
+		listed = pipe.Exists(ctx, keys...)
+	}
+	watermark := pipe.Get(ctx, revoked.UserKey(userId))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	if listed != nil && listed.Val() > 0 {
+		return true, nil
+	}
+
+	before, err := watermark.Int64()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return revoked.IssuedBefore(issuedAt, before), nil
+}
@@ -0,0 +1,279 @@
+package jwtverify
+
+import (
+	"chat_service/pkg/grpc_generated/profile"
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"shared-auth/revoked"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// refreshInterval — плановое обновление ключей: так удалённый из кольца ключ
+	// перестаёт приниматься без рестарта сервиса.
+	refreshInterval = 5 * time.Minute
+	// minRefreshGap ограничивает внеплановые запросы за JWKS при неизвестном kid,
+	// чтобы токены с мусорным kid не превращались в нагрузку на profile_service.
+	minRefreshGap = 30 * time.Second
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrRevokedToken = errors.New("token revoked")
+)
+
+// Claims — то, что chat_service берёт из access-токена.
+type Claims struct {
+	UserId    int64
+	Jti       string
+	SessionId int64
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// Verifier проверяет access-токены локально по открытым ключам profile_service
+// (/.well-known/jwks.json) вместо gRPC-вызова ValidateToken на каждый запрос.
+type Verifier struct {
+	jwksURL     string
+	httpClient  *http.Client
+	revocations RevocationChecker
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+
+	// refreshMu схлопывает параллельные обновления в одно
+	refreshMu sync.Mutex
+}
+
+func NewVerifier(jwksURL string, revocations RevocationChecker) *Verifier {
+	return &Verifier{
+		jwksURL:     jwksURL,
+		httpClient:  &http.Client{Timeout: 5 * time.Second},
+		revocations: revocations,
+		keys:        make(map[string]*rsa.PublicKey),
+	}
+}
+
+// Run загружает ключи и обновляет их раз в refreshInterval до отмены ctx.
+func (v *Verifier) Run(ctx context.Context) {
+	if err := v.Refresh(ctx); err != nil {
+		log.Printf("[jwks] initial fetch failed: %v", err)
+	}
+
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := v.Refresh(ctx); err != nil {
+				log.Printf("[jwks] refresh failed: %v", err)
+			}
+		}
+	}
+}
+
+// Refresh перечитывает JWKS. При ошибке прежний набор ключей сохраняется.
+func (v *Verifier) Refresh(ctx context.Context) error {
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+	return v.fetch(ctx)
+}
+
+func (v *Verifier) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.jwksURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	var set jwkSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || k.Kid == "" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			log.Printf("[jwks] skip key %s: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+	return nil
+}
+
+// key ищет ключ по kid. Неизвестный kid означает, что profile_service перешёл
+// на новый ключ раньше планового обновления, — тогда JWKS перечитывается сразу.
+func (v *Verifier) key(ctx context.Context, kid string) (*rsa.PublicKey, bool) {
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	v.mu.RUnlock()
+	if ok {
+		return key, true
+	}
+
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+
+	v.mu.RLock()
+	key, ok = v.keys[kid]
+	fresh := time.Since(v.fetchedAt) < minRefreshGap
+	v.mu.RUnlock()
+	if ok || fresh {
+		return key, ok
+	}
+
+	if err := v.fetch(ctx); err != nil {
+		log.Printf("[jwks] refresh for kid %q failed: %v", kid, err)
+		return nil, false
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	key, ok = v.keys[kid]
+	return key, ok
+}
+
+// Verify проверяет подпись, срок действия и отзыв токена.
+func (v *Verifier) Verify(ctx context.Context, raw string) (*Claims, error) {
+	token, err := jwt.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		key, ok := v.key(ctx, kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return key, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	mc, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	claims, err := parseClaims(mc)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.revocations != nil {
+		revoked, err := v.revocations.IsRevoked(ctx, claims.UserId, claims.SessionId, claims.Jti, claims.IssuedAt)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrRevokedToken
+		}
+	}
+
+	return claims, nil
+}
+
+// ValidateToken повторяет контракт gRPC AuthService.ValidateToken, поэтому
+// Verifier подставляется везде, где ожидается middleware_chat.ProfileClient.
+func (v *Verifier) ValidateToken(ctx context.Context, req *profile.TokenRequest) (*profile.TokenResponse, error) {
+	claims, err := v.Verify(ctx, req.Token)
+	if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrRevokedToken) {
+		return &profile.TokenResponse{Valid: false, Error: err.Error()}, nil
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, "revocation check failed")
+	}
+
+	return &profile.TokenResponse{
+		Valid:     true,
+		UserId:    strconv.FormatInt(claims.UserId, 10),
+		ExpiresAt: claims.ExpiresAt.Unix(),
+		SessionId: claims.SessionId,
+	}, nil
+}
+
+func parseClaims(mc jwt.MapClaims) (*Claims, error) {
+	rawId, _ := mc["user_id"].(string)
+	userId, err := strconv.ParseInt(rawId, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad user_id", ErrInvalidToken)
+	}
+	exp, ok := mc["exp"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+
+	claims := &Claims{
+		UserId:    userId,
+		ExpiresAt: time.Unix(int64(exp), 0),
+	}
+	claims.Jti, _ = mc["jti"].(string)
+	if iat, ok := mc["iat"].(float64); ok {
+		claims.IssuedAt = revoked.IssuedAt(iat)
+	}
+	// sid есть только у токенов, выданных вместе с refresh-сессией
+	if sid, ok := mc["sid"].(string); ok {
+		claims.SessionId, _ = strconv.ParseInt(sid, 10, 64)
+	}
+	return claims, nil
+}
+
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (k jwk) publicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
@@ -0,0 +1,222 @@
+package jwtverify
+
+import (
+	"chat_service/pkg/grpc_generated/profile"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"shared-auth/revoked"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/redis/go-redis/v9"
+)
+
+// jwksServer — profile_service в миниатюре: кольцо ключей и счётчик запросов.
+type jwksServer struct {
+	mu      sync.Mutex
+	keys    map[string]*rsa.PrivateKey
+	fetches atomic.Int32
+	srv     *httptest.Server
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	s := &jwksServer{keys: make(map[string]*rsa.PrivateKey)}
+	s.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		set := jwkSet{}
+		for kid, key := range s.keys {
+			set.Keys = append(set.Keys, jwk{
+				Kty: "RSA",
+				Kid: kid,
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(s.srv.Close)
+	return s
+}
+
+func (s *jwksServer) addKey(t *testing.T, kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	s.keys[kid] = key
+	s.mu.Unlock()
+}
+
+func (s *jwksServer) sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	s.mu.Lock()
+	key := s.keys[kid]
+	s.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func validClaims(jti string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"user_id": "42",
+		"jti":     jti,
+		"iat":     revoked.NumericDate(now),
+		"exp":     now.Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerifyPicksUpRotatedKey(t *testing.T) {
+	s := newJWKSServer(t)
+	s.addKey(t, "k1")
+	v := NewVerifier(s.srv.URL, nil)
+	ctx := context.Background()
+
+	claims, err := v.Verify(ctx, s.sign(t, "k1", validClaims("a")))
+	if err != nil || claims.UserId != 42 {
+		t.Fatalf("unexpected result %+v %v", claims, err)
+	}
+
+	// ротация: новый ключ появился раньше планового обновления
+	v.mu.Lock()
+	v.fetchedAt = time.Now().Add(-minRefreshGap)
+	v.mu.Unlock()
+	s.addKey(t, "k2")
+
+	if _, err := v.Verify(ctx, s.sign(t, "k2", validClaims("b"))); err != nil {
+		t.Fatalf("token on rotated key must verify: %v", err)
+	}
+	if got := s.fetches.Load(); got != 2 {
+		t.Fatalf("expected 2 jwks fetches, got %d", got)
+	}
+}
+
+func TestUnknownKidDoesNotHammerJWKS(t *testing.T) {
+	s := newJWKSServer(t)
+	s.addKey(t, "k1")
+	v := NewVerifier(s.srv.URL, nil)
+	ctx := context.Background()
+
+	if err := v.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	token := signDetached(t)
+	for i := 0; i < 5; i++ {
+		if _, err := v.Verify(ctx, token); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("expected invalid token, got %v", err)
+		}
+	}
+	if got := s.fetches.Load(); got != 1 {
+		t.Fatalf("unknown kid must not trigger refresh within %s, got %d fetches", minRefreshGap, got)
+	}
+}
+
+// signDetached подписывает токен ключом, которого нет в JWKS.
+func signDetached(t *testing.T) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims("x"))
+	token.Header["kid"] = "unknown"
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestVerifyRejectsExpiredAndHMAC(t *testing.T) {
+	s := newJWKSServer(t)
+	s.addKey(t, "k1")
+	v := NewVerifier(s.srv.URL, nil)
+	ctx := context.Background()
+
+	expired := validClaims("a")
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	if _, err := v.Verify(ctx, s.sign(t, "k1", expired)); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expired token must be rejected, got %v", err)
+	}
+
+	hmac, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims("b")).SignedString([]byte("secret"))
+	if _, err := v.Verify(ctx, hmac); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("HS256 token must be rejected, got %v", err)
+	}
+}
+
+func TestValidateTokenChecksRevocation(t *testing.T) {
+	s := newJWKSServer(t)
+	s.addKey(t, "k1")
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	v := NewVerifier(s.srv.URL, NewRedisRevocations(rdb))
+	ctx := context.Background()
+
+	mr.Set(revoked.JtiKey("a"), "1")
+	resp, err := v.ValidateToken(ctx, tokenRequest(s.sign(t, "k1", validClaims("a"))))
+	if err != nil || resp.Valid {
+		t.Fatalf("revoked jti must be invalid: %+v %v", resp, err)
+	}
+
+	resp, err = v.ValidateToken(ctx, tokenRequest(s.sign(t, "k1", validClaims("b"))))
+	if err != nil || !resp.Valid || resp.UserId != "42" {
+		t.Fatalf("token b must be valid: %+v %v", resp, err)
+	}
+
+	// завершение одной сессии в /me/sessions
+	mr.Set(revoked.SessionKey(7), "1")
+	revokedSession := validClaims("s")
+	revokedSession["sid"] = "7"
+	if resp, _ := v.ValidateToken(ctx, tokenRequest(s.sign(t, "k1", revokedSession))); resp.Valid {
+		t.Fatal("token of revoked session must be invalid")
+	}
+
+	// «выйти везде» в profile_service: граница проходит внутри секунды
+	revokedAt := time.Now()
+	mr.Set(revoked.UserKey(42), strconv.FormatInt(revoked.Watermark(revokedAt), 10))
+	before := validClaims("c")
+	before["iat"] = revoked.NumericDate(revokedAt.Add(-time.Millisecond))
+	if resp, _ := v.ValidateToken(ctx, tokenRequest(s.sign(t, "k1", before))); resp.Valid {
+		t.Fatal("token issued before watermark must be invalid")
+	}
+	after := validClaims("e")
+	after["iat"] = revoked.NumericDate(revokedAt.Add(time.Millisecond))
+	if resp, _ := v.ValidateToken(ctx, tokenRequest(s.sign(t, "k1", after))); !resp.Valid {
+		t.Fatal("token issued after watermark must stay valid")
+	}
+
+	// Redis недоступен: отказ, а не пропуск непроверенного токена
+	mr.Close()
+	if _, err := v.ValidateToken(ctx, tokenRequest(s.sign(t, "k1", validClaims("d")))); err == nil {
+		t.Fatal("expected error when revocation store is down")
+	}
+}
+
+func tokenRequest(raw string) *profile.TokenRequest {
+	return &profile.TokenRequest{Token: raw}
+}
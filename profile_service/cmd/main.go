@@ -9,9 +9,18 @@ import (
 	"os/signal"
 	_ "profile_service/docs"
 	transport "profile_service/http"
+	"profile_service/internal/auth/keys"
+	"profile_service/internal/auth/mfa"
+	"profile_service/internal/auth/password"
+	authRepo "profile_service/internal/auth/repository"
+	"profile_service/internal/auth/revocation"
+	authService "profile_service/internal/auth/service"
+	"profile_service/internal/auth/sso"
+	"profile_service/internal/auth/throttle"
 	"profile_service/internal/config"
 	"profile_service/internal/config/db"
 	"profile_service/internal/kafka/friendship_producer"
+	"profile_service/internal/mailer"
 	relRepo "profile_service/internal/relation/repository"
 	relService "profile_service/internal/relation/service"
 	"profile_service/internal/user/cache"
@@ -25,6 +34,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 
@@ -80,6 +90,18 @@ func main() {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
+	// Подключение к Redis (список отозванных токенов, счётчики попыток входа)
+	redisCfg, err := config.RedisCfgLoad()
+	if err != nil {
+		log.Fatal("Ошибка получения конфигурации Redis: ", err)
+	}
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     redisCfg.Addr,
+		Password: redisCfg.Password,
+		DB:       redisCfg.RedisDb,
+	})
+	defer rdb.Close()
+
 	// Инициализация gRPC-клиента (PresenceClient)
 	presenceAddr := os.Getenv("CHAT_PRESENCE_GRPC_ADDR")
 	if presenceAddr == "" {
@@ -95,18 +117,18 @@ func main() {
 		}
 	}()
 
-	// Инициализация кэширования статусов
-	presenceCache := cache.NewPresenceCache(3 * time.Second)
+	// Инициализация кэширования статусов (обновляется подпиской WatchPresence)
+	presenceCache := cache.NewPresenceCache(presenceClient, log)
 	defer presenceCache.Stop()
 
-	// Инициализация user-репозитория и сервисов
-	userRepo := userRepo.NewProfileRepo(database.DB, log)
-	userService := userService.NewUserService(userRepo, presenceClient, presenceCache, log)
-
 	// Инициализация репозиториев для дружбы
 	friendshipRepo := relRepo.NewFriendshipRepository(database.DB, log.WithField("component", "friendship_repo"))
 	txManager := relRepo.NewTransactionManager(database.DB, log)
 
+	// Инициализация user-репозитория и сервисов
+	userRepo := userRepo.NewProfileRepo(database.DB, log)
+	userService := userService.NewUserService(userRepo, friendshipRepo, presenceClient, presenceCache, log)
+
 	// Инициализация Kafka friendship_producer
 	kafkaProducer, err := friendship_producer.NewKafkaProducer(kafkaCfg.Brokers, kafkaCfg.Topic)
 	if err != nil {
@@ -128,10 +150,104 @@ func main() {
 		log.WithField("component", "friendship_service"),
 	)
 
+	// Инициализация сессий входа (refresh-токены)
+	sessionRepo := authRepo.NewSessionRepository(database.DB, log.WithField("component", "session_repo"))
+	sessionService := authService.NewSessionService(sessionRepo, log.WithField("component", "session_service"))
+
+	// Политика паролей и хэширование argon2id; старые bcrypt-хэши пересчитываются при входе
+	passwordCfg, err := config.PasswordCfgLoad()
+	if err != nil {
+		log.Fatal("Ошибка получения конфигурации паролей: ", err)
+	}
+	if passwordCfg.BreachedFile != "" {
+		passwordCfg.Policy.Breached, err = password.LoadBreachedList(passwordCfg.BreachedFile)
+		if err != nil {
+			log.Fatalf("Failed to load breached passwords list: %v", err)
+		}
+	} else {
+		log.Warn("PASSWORD_BREACHED_FILE is not set, leaked passwords are not rejected")
+	}
+	passwordHasher := password.NewHasher(passwordCfg.Hash)
+	passwordPolicy := &passwordCfg.Policy
+
+	// Инициализация почты (подтверждение email, сброс пароля)
+	mailCfg, err := config.MailCfgLoad()
+	if err != nil {
+		log.Fatal("Ошибка получения конфигурации почты: ", err)
+	}
+	var mail mailer.Mailer
+	switch {
+	case mailCfg.SMTPAddr != "":
+		mail = mailer.NewSMTPMailer(mailCfg.SMTPAddr, mailCfg.From, mailCfg.SMTPUser, mailCfg.SMTPPassword)
+	case mailCfg.File != "":
+		mail = mailer.NewFileMailer(mailCfg.File, mailCfg.From)
+	default:
+		log.Warn("MAIL_SMTP_ADDR is not set, emails are written to log")
+		mail = mailer.NewLogMailer(log)
+	}
+	// письма, которые не ждёт ответ на запрос, уходят через ограниченную очередь
+	mailQueue := mailer.NewQueue(mail, 100, 4, 15*time.Second, log.WithField("component", "mail_queue"))
+	defer mailQueue.Close()
+	loginLimiter := throttle.NewRedisLoginLimiter(rdb, throttle.DefaultUserPolicy, throttle.DefaultIPPolicy)
+	resetLimiter := throttle.NewRedisPasswordResetLimiter(rdb, throttle.DefaultResetEmailPolicy, throttle.DefaultResetIPPolicy)
+	userTokenRepo := authRepo.NewUserTokenRepository(database.DB, log.WithField("component", "user_token_repo"))
+	accountService := authService.NewAccountService(userTokenRepo, userRepo, mail, mailQueue, resetLimiter, loginLimiter, outboxProducer,
+		passwordHasher, passwordPolicy, mailCfg.LinkBaseURL, log.WithField("component", "account_service"))
+
+	// Инициализация 2FA: TOTP-секреты шифруются ключом MFA_ENCRYPTION_KEY.
+	// Без ключа подключить 2FA нельзя, а если у кого-то она уже включена,
+	// сервис не стартует: их секреты было бы нечем расшифровать.
+	mfaRepo := authRepo.NewMfaRepository(database.DB, log.WithField("component", "mfa_repo"))
+	var mfaCipher *mfa.Cipher
+	if cfg.MfaKey != "" {
+		mfaKey, err := mfa.ParseKey(cfg.MfaKey)
+		if err != nil {
+			log.Fatalf("Failed to load mfa key: %v", err)
+		}
+		mfaCipher, err = mfa.NewCipher(mfaKey)
+		if err != nil {
+			log.Fatalf("Failed to init mfa cipher: %v", err)
+		}
+	} else {
+		enabled, err := mfaRepo.CountEnabled(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to check mfa users: %v", err)
+		}
+		if enabled > 0 {
+			log.Fatalf("MFA_ENCRYPTION_KEY is not set, but %d users have two-factor authentication enabled", enabled)
+		}
+		log.Warn("MFA_ENCRYPTION_KEY is not set, two-factor enrollment is disabled")
+	}
+	mfaService := authService.NewMfaService(mfaRepo, userRepo, passwordHasher, loginLimiter, mfaCipher, outboxProducer,
+		log.WithField("component", "mfa_service"))
+
+	// Инициализация входа через внешних OIDC-провайдеров
+	oidcProviders, err := config.OidcCfgLoad()
+	if err != nil {
+		log.Fatal("Ошибка получения конфигурации OIDC: ", err)
+	}
+	identityRepo := authRepo.NewIdentityRepository(database.DB, log.WithField("component", "identity_repo"))
+	externalAuthService := authService.NewExternalAuthService(sso.NewRegistry(oidcProviders), sso.NewRedisStateStore(rdb),
+		identityRepo, userRepo, passwordHasher, outboxProducer, log.WithField("component", "external_auth_service"))
+
+	// Загрузка ключей подписи access-токенов
+	var keyRing *keys.KeyRing
+	if cfg.JwtKeysDir != "" {
+		keyRing, err = keys.Load(cfg.JwtKeysDir, cfg.JwtActiveKid)
+	} else {
+		log.Warn("JWT_KEYS_DIR is not set, using ephemeral signing key")
+		keyRing, err = keys.Generate()
+	}
+	if err != nil {
+		log.Fatalf("Failed to load signing keys: %v", err)
+	}
+
 	// Инициализация gRPC-серверов
-	authServer := grpc_server.NewAuthServer(log, userService, cfg.Jwt)
+	revocationStore := revocation.NewRedisStore(rdb)
+	authServer := grpc_server.NewAuthServer(log, userService, sessionService, revocationStore, loginLimiter, outboxProducer,
+		passwordHasher, passwordPolicy, mfaService, mfa.NewRedisChallengeStore(rdb), externalAuthService, presenceClient, keyRing, cfg.Jwt)
 	directoryServer := grpc_server.NewDirectoryServer(log, userService)
-	authzServer := grpc_server.NewAuthorizationServer(relationChecker)
+	authzServer := grpc_server.NewAuthorizationServer(relationChecker, userService)
 
 	// Запуск gRPC серверов
 	log.Info("Starting gRPC servers...")
@@ -180,19 +296,26 @@ func main() {
 	time.Sleep(5 * time.Second)
 
 	// Инициализация хэндлера
-	userHandler := transport.NewUserHandler(userService, authServer, log)
+	userHandler := transport.NewUserHandler(userService, authServer, accountService, mfaService, externalAuthService, log)
 	friendshipHandler := transport.NewFriendshipHandler(
 		userService,
 		friendshipService,
 		relationChecker,
 		log,
 	)
+	jwksHandler := transport.NewJWKSHandler(keyRing)
 
 	// Подключение auth-middleware
 	authMiddleware := middleware_profile.NewAuthMiddleware(authServer, log)
 
 	// Создание gin-роутера
 	router := gin.Default()
+	// ClientIP идёт в лимит попыток входа и в список сессий, поэтому
+	// X-Forwarded-For принимается только от прокси из TRUSTED_PROXIES (IP или CIDR
+	// через запятую); без них берётся адрес TCP-соединения.
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	router.Use(
 		gin.Recovery(),
 		middleware_profile.ErrorMiddleware(log),
@@ -211,7 +334,18 @@ func main() {
 		authUser := api.Group("/auth")
 		{
 			authUser.POST("/login", userHandler.PostLogin)
+			authUser.POST("/login/mfa", userHandler.PostLoginMfa)
+			authUser.GET("/oidc/providers", userHandler.GetOidcProviders)
+			authUser.GET("/oidc/:provider/login", userHandler.GetOidcLogin)
+			authUser.GET("/oidc/:provider/callback", userHandler.GetOidcCallback)
 			authUser.POST("/register", userHandler.PostUser)
+			authUser.POST("/refresh", userHandler.PostRefresh)
+			authUser.POST("/logout", userHandler.PostLogout)
+			authUser.POST("/logout-all", authMiddleware, userHandler.PostLogoutAll)
+			authUser.POST("/verify-email", userHandler.PostVerifyEmail)
+			authUser.POST("/verify-email/resend", authMiddleware, userHandler.PostResendVerification)
+			authUser.POST("/password/forgot", userHandler.PostForgotPassword)
+			authUser.POST("/password/reset", userHandler.PostResetPassword)
 		}
 		users := api.Group("/users")
 		users.Use(authMiddleware)
@@ -221,6 +355,21 @@ func main() {
 			users.PUT("/:id", userHandler.PutUser)
 			users.DELETE("/:id", userHandler.DeleteUser)
 		}
+		me := api.Group("/me")
+		me.Use(authMiddleware)
+		{
+			me.GET("/privacy", userHandler.GetPrivacySettings)
+			me.PUT("/privacy", userHandler.PutPrivacySettings)
+			me.PUT("/password", userHandler.PutPassword)
+			me.POST("/email", userHandler.PostEmailChange)
+			me.POST("/email/confirm", userHandler.PostEmailConfirm)
+			me.GET("/mfa", userHandler.GetMfa)
+			me.POST("/mfa/enroll", userHandler.PostMfaEnroll)
+			me.POST("/mfa/confirm", userHandler.PostMfaConfirm)
+			me.POST("/mfa/disable", userHandler.PostMfaDisable)
+			me.GET("/sessions", userHandler.GetSessions)
+			me.DELETE("/sessions/:id", userHandler.DeleteSession)
+		}
 		friendRequests := api.Group("/friends/requests")
 		friendRequests.Use(authMiddleware)
 		{
@@ -244,6 +393,7 @@ func main() {
 			block.GET("/:blocked_id", friendshipHandler.GetBlockInfo)
 		}
 	}
+	router.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Объявление параметров запуска сервера + graceful shutdown
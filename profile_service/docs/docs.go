@@ -17,7 +17,7 @@ const docTemplate = `{
     "paths": {
         "/auth/login": {
             "post": {
-                "description": "Аутентифицирует пользователя и возвращает JWT",
+                "description": "Аутентифицирует пользователя и возвращает JWT. При включённой 2FA токены не выдаются: ответ содержит mfa_required и mfa_token для /auth/login/mfa",
                 "consumes": [
                     "application/json"
                 ],
@@ -57,13 +57,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
+                    },
+                    "429": {
+                        "description": "Слишком много попыток, см. Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/auth/register": {
+        "/auth/login/mfa": {
             "post": {
-                "description": "Создает нового пользователя с указанными данными",
+                "description": "Завершает вход с 2FA: принимает mfa_token из /auth/login и код из приложения или код восстановления",
                 "consumes": [
                     "application/json"
                 ],
@@ -73,27 +79,73 @@ const docTemplate = `{
                 "tags": [
                     "Auth"
                 ],
-                "summary": "Создать нового пользователя",
+                "summary": "Второй шаг входа",
                 "parameters": [
                     {
-                        "description": "Данные для создания пользователя",
+                        "description": "Токен входа и код",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/profile_service_http_api_dto.CreateUserRequest"
+                            "$ref": "#/definitions/profile_service_http_api_dto.LoginMfaRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "ID созданного пользователя",
+                    "200": {
+                        "description": "Успешный вход",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/profile_service_http_api_dto.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный код или истёкший mfa_token",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много попыток, см. Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "description": "Завершает сессию, которой принадлежит refresh-токен. Если передан заголовок Authorization, access-токен отзывается сразу",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Выход",
+                "parameters": [
+                    {
+                        "description": "Refresh-токен",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.LogoutRequest"
                         }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Сессия завершена"
                     },
                     "400": {
-                        "description": "Неверные данные пользователя",
+                        "description": "Неверные данные",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
@@ -107,61 +159,162 @@ const docTemplate = `{
                 }
             }
         },
-        "/block/{blocked_id}": {
-            "get": {
+        "/auth/logout-all": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Проверяет, заблокирован ли пользователь",
-                "consumes": [
+                "description": "Отзывает все токены и сессии текущего пользователя и закрывает его WebSocket-соединения",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Выход на всех устройствах",
+                "responses": {
+                    "204": {
+                        "description": "Все сессии завершены"
+                    },
+                    "401": {
+                        "description": "Неверные учетные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/providers": {
+            "get": {
+                "description": "Имена настроенных OIDC-провайдеров для /auth/oidc/{provider}/login",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Внешние провайдеры входа",
+                "responses": {
+                    "200": {
+                        "description": "Список провайдеров",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.OidcProvidersResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/{provider}/callback": {
+            "get": {
+                "description": "Обменивает код авторизации и выдаёт токены. Аккаунт находится по привязке, привязывается по подтверждённому email или создаётся",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Block"
+                    "Auth"
                 ],
-                "summary": "Получить информацию о блокировке",
+                "summary": "Возврат от внешнего провайдера",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Id проверяемого пользователя",
-                        "name": "blocked_id",
+                        "type": "string",
+                        "description": "Имя провайдера",
+                        "name": "provider",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Код авторизации",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "state из начала входа",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Информация о блокировке",
+                        "description": "Успешный вход или mfa_required",
                         "schema": {
-                            "$ref": "#/definitions/profile_service_http_api_dto.BlockInfoResponse"
+                            "$ref": "#/definitions/profile_service_http_api_dto.LoginResponse"
                         }
                     },
                     "400": {
-                        "description": "Неверные данные",
+                        "description": "Провайдер вернул ошибку",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Не авторизован",
+                        "description": "Недействительный state или код",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Неизвестный провайдер",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Аккаунт нельзя привязать автоматически",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     }
                 }
-            },
-            "post": {
-                "security": [
+            }
+        },
+        "/auth/oidc/{provider}/login": {
+            "get": {
+                "description": "Перенаправляет на страницу входа провайдера (authorization code flow с PKCE)",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Вход через внешний провайдер",
+                "parameters": [
                     {
-                        "BearerAuth": []
+                        "type": "string",
+                        "description": "Имя провайдера",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
                     }
                 ],
-                "description": "Блокирует указанного пользователя",
+                "responses": {
+                    "302": {
+                        "description": "Редирект на провайдера"
+                    },
+                    "404": {
+                        "description": "Неизвестный провайдер",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Провайдер недоступен",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/forgot": {
+            "post": {
+                "description": "Высылает ссылку для сброса пароля. Ответ одинаков для известных и неизвестных адресов",
                 "consumes": [
                     "application/json"
                 ],
@@ -169,33 +322,125 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Block"
+                    "Auth"
                 ],
-                "summary": "Заблокировать пользователя",
+                "summary": "Запрос сброса пароля",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Id пользователя для блокировки",
-                        "name": "blocked_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Email аккаунта",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.ForgotPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Запрос принят",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
                     },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/reset": {
+            "post": {
+                "description": "Устанавливает новый пароль по токену из письма и завершает все сессии пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Сброс пароля",
+                "parameters": [
                     {
-                        "description": "Причина блокировки (опционально)",
+                        "description": "Токен и новый пароль",
                         "name": "request",
                         "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/profile_service_http_api_dto.BlockUserRequest"
+                            "$ref": "#/definitions/profile_service_http_api_dto.ResetPasswordRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Пользователь заблокирован",
+                        "description": "Пароль изменён",
                         "schema": {
                             "$ref": "#/definitions/profile_service_http_api_dto.SuccessResponse"
                         }
                     },
+                    "400": {
+                        "description": "Недействительный токен или пароль не соответствует политике",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Обменивает refresh-токен на новую пару токенов. Каждый refresh-токен одноразовый: повторное использование завершает сессию",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Обновление токенов",
+                "parameters": [
+                    {
+                        "description": "Refresh-токен",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Новая пара токенов",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.LoginResponse"
+                        }
+                    },
                     "400": {
                         "description": "Неверные данные",
                         "schema": {
@@ -203,38 +448,984 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Не авторизован",
+                        "description": "Недействительный refresh-токен",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "Создает нового пользователя и отправляет письмо для подтверждения email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Создать нового пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные для создания пользователя",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "ID созданного пользователя",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные или пароль не соответствует политике",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/verify-email": {
+            "post": {
+                "description": "Подтверждает email по одноразовому токену из письма",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Подтверждение email",
+                "parameters": [
+                    {
+                        "description": "Токен из письма",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.VerifyEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email подтверждён",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Недействительный или просроченный токен",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/verify-email/resend": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Высылает новую ссылку подтверждения; предыдущие ссылки перестают действовать",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Повторная отправка письма подтверждения",
+                "responses": {
+                    "200": {
+                        "description": "Письмо отправлено",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные учетные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email уже подтверждён",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/block/{blocked_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Проверяет, заблокирован ли пользователь",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Block"
+                ],
+                "summary": "Получить информацию о блокировке",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Id проверяемого пользователя",
+                        "name": "blocked_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Информация о блокировке",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.BlockInfoResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Блокирует указанного пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Block"
+                ],
+                "summary": "Заблокировать пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Id пользователя для блокировки",
+                        "name": "blocked_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Причина блокировки (опционально)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.BlockUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь заблокирован",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Попытка заблокировать себя",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Пользователь уже заблокирован",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Разблокирует ранее заблокированного пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Block"
+                ],
+                "summary": "Разблокировать пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Id пользователя для разблокировки",
+                        "name": "blocked_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь разблокирован",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Попытка разблокировать себя",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден или блокировка отсутствует",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/friends": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает список друзей пользователя с пагинацией",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Friends"
+                ],
+                "summary": "Получить список друзей",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список друзей",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.FriendListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/friends/check": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Проверяет, являются ли пользователи друзьями",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Friends"
+                ],
+                "summary": "Проверить статус дружбы",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Первый пользователь",
+                        "name": "user_id1",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Второй пользователь",
+                        "name": "user_id2",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Результат проверки",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.AreFriendsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/friends/requests/state": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Проверяет статус запроса между текущим пользователем и указанным",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Friends"
+                ],
+                "summary": "Проверить статус запроса в друзья",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Id целевого пользователя",
+                        "name": "target_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Статус запроса",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.RequestStateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/friends/requests/{receiver_id}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отправляет запрос на добавление в друзья другому пользователю",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Friends"
+                ],
+                "summary": "Отправить запрос в друзья",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Id получателя",
+                        "name": "receiver_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Сообщение (опционально)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.SendFriendRequestRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Запрос успешно отправлен",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.SendFriendRequestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Пользователь заблокирован или email не подтверждён",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Конфликт (уже друзья или есть запрос)",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/friends/requests/{request_id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Принимает или отклоняет запрос в друзья",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Friends"
+                ],
+                "summary": "Ответить на запрос в друзья",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Id запроса",
+                        "name": "request_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Ответ на запрос",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.AnswerFriendRequestRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ответ успешно обработан",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Запрос не найден",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отменяет отправленный запрос в друзья",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Friends"
+                ],
+                "summary": "Отменить запрос в друзья",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Id запроса",
+                        "name": "request_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Запрос успешно отменен",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Запрос не найден",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/friends/{friend_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет пользователя из списка друзей",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Friends"
+                ],
+                "summary": "Удалить из друзей",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Id друга",
+                        "name": "friend_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Друг успешно удален",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден или не являются друзьями",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/email": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Проверяет текущий пароль и отправляет ссылку подтверждения на новый адрес. Email меняется только после подтверждения",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Me"
+                ],
+                "summary": "Запрос смены email",
+                "parameters": [
+                    {
+                        "description": "Текущий пароль и новый email",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.ChangeEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Письмо подтверждения отправлено",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные учетные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Неверный текущий пароль",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email уже занят",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много попыток, см. Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/email/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Применяет смену email по токену из письма. Остальные сессии завершаются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Me"
+                ],
+                "summary": "Подтверждение смены email",
+                "parameters": [
+                    {
+                        "description": "Токен из письма",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.ConfirmEmailChangeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email изменён",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Недействительный или просроченный токен",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные учетные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email уже занят",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/mfa": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Включена ли двухфакторная аутентификация и сколько осталось кодов восстановления",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Me"
+                ],
+                "summary": "Состояние 2FA",
+                "responses": {
+                    "200": {
+                        "description": "Состояние 2FA",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.MfaStatusResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные учетные данные",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/mfa/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Включает 2FA по коду из приложения и возвращает коды восстановления — они показываются один раз. Остальные сессии завершаются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Me"
+                ],
+                "summary": "Подтверждение подключения 2FA",
+                "parameters": [
+                    {
+                        "description": "Код из приложения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.MfaCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Коды восстановления",
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.RecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный код или подключение не начато",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные учетные данные",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     },
-                    "403": {
-                        "description": "Попытка заблокировать себя",
+                    "409": {
+                        "description": "2FA уже включена",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Пользователь не найден",
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     },
-                    "409": {
-                        "description": "Пользователь уже заблокирован",
+                    "503": {
+                        "description": "2FA не настроена на сервере",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/me/mfa/disable": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Разблокирует ранее заблокированного пользователя",
+                "description": "Отключает 2FA; нужны текущий пароль и код из приложения или код восстановления",
                 "consumes": [
                     "application/json"
                 ],
@@ -242,45 +1433,53 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Block"
+                    "Me"
                 ],
-                "summary": "Разблокировать пользователя",
+                "summary": "Отключение 2FA",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Id пользователя для разблокировки",
-                        "name": "blocked_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Пароль и код",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.DisableMfaRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Пользователь разблокирован",
+                        "description": "2FA отключена",
                         "schema": {
                             "$ref": "#/definitions/profile_service_http_api_dto.SuccessResponse"
                         }
                     },
                     "400": {
-                        "description": "Неверные данные",
+                        "description": "Неверный код или 2FA не включена",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Не авторизован",
+                        "description": "Неверные учетные данные",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     },
                     "403": {
-                        "description": "Попытка разблокировать себя",
+                        "description": "Неверный текущий пароль",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Пользователь не найден или блокировка отсутствует",
+                    "429": {
+                        "description": "Слишком много попыток, см. Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
@@ -288,99 +1487,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/friends": {
-            "get": {
+        "/me/mfa/enroll": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает список друзей пользователя с пагинацией",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Выдаёт TOTP-секрет и otpauth:// URI для QR-кода. 2FA включается только после подтверждения кодом через /me/mfa/confirm",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Friends"
-                ],
-                "summary": "Получить список друзей",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "default": 20,
-                        "description": "Limit",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 0,
-                        "description": "Offset",
-                        "name": "offset",
-                        "in": "query"
-                    }
+                    "Me"
                 ],
+                "summary": "Начало подключения 2FA",
                 "responses": {
                     "200": {
-                        "description": "Список друзей",
+                        "description": "Секрет для приложения-аутентификатора",
                         "schema": {
-                            "$ref": "#/definitions/profile_service_http_api_dto.FriendListResponse"
+                            "$ref": "#/definitions/profile_service_http_api_dto.MfaEnrollResponse"
                         }
                     },
                     "401": {
-                        "description": "Не авторизован",
+                        "description": "Неверные учетные данные",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/friends/check": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Проверяет, являются ли пользователи друзьями",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Friends"
-                ],
-                "summary": "Проверить статус дружбы",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Первый пользователь",
-                        "name": "user_id1",
-                        "in": "query",
-                        "required": true
                     },
-                    {
-                        "type": "integer",
-                        "description": "Второй пользователь",
-                        "name": "user_id2",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Результат проверки",
+                    "409": {
+                        "description": "2FA уже включена",
                         "schema": {
-                            "$ref": "#/definitions/profile_service_http_api_dto.AreFriendsResponse"
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     },
-                    "400": {
-                        "description": "Неверные данные",
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "2FA не настроена на сервере",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
@@ -388,14 +1536,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/friends/requests/state": {
-            "get": {
+        "/me/password": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Проверяет статус запроса между текущим пользователем и указанным",
+                "description": "Меняет пароль после проверки текущего. Остальные сессии завершаются; текущая сохраняется, но access-токен нужно обновить через /auth/refresh",
                 "consumes": [
                     "application/json"
                 ],
@@ -403,39 +1551,53 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Friends"
+                    "Me"
                 ],
-                "summary": "Проверить статус запроса в друзья",
+                "summary": "Смена пароля",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Id целевого пользователя",
-                        "name": "target_id",
-                        "in": "query",
-                        "required": true
+                        "description": "Текущий и новый пароль",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/profile_service_http_api_dto.ChangePasswordRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Статус запроса",
+                        "description": "Пароль изменён",
                         "schema": {
-                            "$ref": "#/definitions/profile_service_http_api_dto.RequestStateResponse"
+                            "$ref": "#/definitions/profile_service_http_api_dto.SuccessResponse"
                         }
                     },
                     "400": {
-                        "description": "Неверные данные",
+                        "description": "Неверные данные или пароль не соответствует политике",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Не авторизован",
+                        "description": "Неверные учетные данные",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Пользователь не найден",
+                    "403": {
+                        "description": "Неверный текущий пароль",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много попыток, см. Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
@@ -443,14 +1605,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/friends/requests/{receiver_id}": {
-            "post": {
+        "/me/privacy": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Отправляет запрос на добавление в друзья другому пользователю",
+                "description": "Возвращает, кому виден \"last seen\" текущего пользователя",
                 "consumes": [
                     "application/json"
                 ],
@@ -458,74 +1620,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Friends"
-                ],
-                "summary": "Отправить запрос в друзья",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Id получателя",
-                        "name": "receiver_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Сообщение (опционально)",
-                        "name": "request",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/profile_service_http_api_dto.SendFriendRequestRequest"
-                        }
-                    }
+                    "Me"
                 ],
+                "summary": "Получить настройки приватности",
                 "responses": {
                     "200": {
-                        "description": "Запрос успешно отправлен",
-                        "schema": {
-                            "$ref": "#/definitions/profile_service_http_api_dto.SendFriendRequestResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Неверные данные",
+                        "description": "Успешный запрос",
                         "schema": {
-                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                            "$ref": "#/definitions/profile_service_http_api_dto.PrivacySettingsResponse"
                         }
                     },
                     "401": {
-                        "description": "Не авторизован",
-                        "schema": {
-                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
-                        }
-                    },
-                    "403": {
-                        "description": "Пользователь заблокирован",
-                        "schema": {
-                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Пользователь не найден",
+                        "description": "Неверные учетные данные",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     },
-                    "409": {
-                        "description": "Конфликт (уже друзья или есть запрос)",
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/friends/requests/{request_id}": {
+            },
             "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Принимает или отклоняет запрос в друзья",
+                "description": "Задает, кому виден \"last seen\": everyone, friends или nobody",
                 "consumes": [
                     "application/json"
                 ],
@@ -533,101 +1658,78 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Friends"
+                    "Me"
                 ],
-                "summary": "Ответить на запрос в друзья",
+                "summary": "Изменить настройки приватности",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Id запроса",
-                        "name": "request_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Ответ на запрос",
+                        "description": "Настройки приватности",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/profile_service_http_api_dto.AnswerFriendRequestRequest"
+                            "$ref": "#/definitions/profile_service_http_api_dto.PrivacySettingsRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Ответ успешно обработан",
+                        "description": "Настройки сохранены",
                         "schema": {
                             "$ref": "#/definitions/profile_service_http_api_dto.SuccessResponse"
                         }
                     },
                     "400": {
-                        "description": "Неверные данные",
+                        "description": "Неверные данные запроса",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Не авторизован",
+                        "description": "Неверные учетные данные",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Запрос не найден",
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/me/sessions": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Отменяет отправленный запрос в друзья",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Friends"
-                ],
-                "summary": "Отменить запрос в друзья",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Id запроса",
-                        "name": "request_id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
+                "description": "Устройства, на которых выполнен вход: user agent, IP, время входа и последнего обновления токенов. Текущая сессия помечена current",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Me"
+                ],
+                "summary": "Активные сессии",
                 "responses": {
                     "200": {
-                        "description": "Запрос успешно отменен",
-                        "schema": {
-                            "$ref": "#/definitions/profile_service_http_api_dto.SuccessResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Неверные данные",
+                        "description": "Список сессий",
                         "schema": {
-                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                            "$ref": "#/definitions/profile_service_http_api_dto.SessionListResponse"
                         }
                     },
                     "401": {
-                        "description": "Не авторизован",
+                        "description": "Неверные учетные данные",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Запрос не найден",
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
@@ -635,54 +1737,51 @@ const docTemplate = `{
                 }
             }
         },
-        "/friends/{friend_id}": {
+        "/me/sessions/{id}": {
             "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Удаляет пользователя из списка друзей",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Выход на одном устройстве: токены сессии отзываются, её WebSocket-соединения закрываются. Для выхода с текущего устройства используйте /auth/logout",
                 "tags": [
-                    "Friends"
+                    "Me"
                 ],
-                "summary": "Удалить из друзей",
+                "summary": "Завершение сессии",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Id друга",
-                        "name": "friend_id",
+                        "description": "ID сессии",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Друг успешно удален",
-                        "schema": {
-                            "$ref": "#/definitions/profile_service_http_api_dto.SuccessResponse"
-                        }
+                    "204": {
+                        "description": "Сессия завершена"
                     },
                     "400": {
-                        "description": "Неверные данные",
+                        "description": "Неверный ID сессии",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Не авторизован",
+                        "description": "Неверные учетные данные",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Пользователь не найден или не являются друзьями",
+                        "description": "Сессия не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/middleware_profile.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/middleware_profile.ErrorResponse"
                         }
@@ -978,6 +2077,47 @@ const docTemplate = `{
                 }
             }
         },
+        "profile_service_http_api_dto.ChangeEmailRequest": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_email"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "new_email": {
+                    "type": "string"
+                }
+            }
+        },
+        "profile_service_http_api_dto.ChangePasswordRequest": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "profile_service_http_api_dto.ConfirmEmailChangeRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
         "profile_service_http_api_dto.CreateUserRequest": {
             "type": "object",
             "required": [
@@ -999,6 +2139,49 @@ const docTemplate = `{
                 }
             }
         },
+        "profile_service_http_api_dto.DeviceView": {
+            "type": "object",
+            "properties": {
+                "app_version": {
+                    "type": "string"
+                },
+                "device": {
+                    "type": "string"
+                },
+                "last_activity": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "profile_service_http_api_dto.DisableMfaRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "current_password"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "current_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "profile_service_http_api_dto.ForgotPasswordRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
         "profile_service_http_api_dto.FriendListResponse": {
             "type": "object",
             "properties": {
@@ -1030,6 +2213,21 @@ const docTemplate = `{
                 }
             }
         },
+        "profile_service_http_api_dto.LoginMfaRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "mfa_token"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "mfa_token": {
+                    "type": "string"
+                }
+            }
+        },
         "profile_service_http_api_dto.LoginRequest": {
             "type": "object",
             "required": [
@@ -1048,6 +2246,21 @@ const docTemplate = `{
         "profile_service_http_api_dto.LoginResponse": {
             "type": "object",
             "properties": {
+                "expires_at": {
+                    "type": "integer"
+                },
+                "mfa_required": {
+                    "type": "boolean"
+                },
+                "mfa_token": {
+                    "type": "string"
+                },
+                "refresh_expires_at": {
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
                 "token": {
                     "type": "string"
                 },
@@ -1056,6 +2269,107 @@ const docTemplate = `{
                 }
             }
         },
+        "profile_service_http_api_dto.LogoutRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "profile_service_http_api_dto.MfaCodeRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "profile_service_http_api_dto.MfaEnrollResponse": {
+            "type": "object",
+            "properties": {
+                "provisioning_uri": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "profile_service_http_api_dto.MfaStatusResponse": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "recovery_codes_left": {
+                    "type": "integer"
+                }
+            }
+        },
+        "profile_service_http_api_dto.OidcProvidersResponse": {
+            "type": "object",
+            "properties": {
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "profile_service_http_api_dto.PrivacySettingsRequest": {
+            "type": "object",
+            "required": [
+                "last_seen"
+            ],
+            "properties": {
+                "last_seen": {
+                    "type": "string",
+                    "enum": [
+                        "everyone",
+                        "friends",
+                        "nobody"
+                    ]
+                }
+            }
+        },
+        "profile_service_http_api_dto.PrivacySettingsResponse": {
+            "type": "object",
+            "properties": {
+                "last_seen": {
+                    "type": "string"
+                }
+            }
+        },
+        "profile_service_http_api_dto.RecoveryCodesResponse": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "profile_service_http_api_dto.RefreshRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
         "profile_service_http_api_dto.RequestStateResponse": {
             "type": "object",
             "properties": {
@@ -1068,6 +2382,21 @@ const docTemplate = `{
                 }
             }
         },
+        "profile_service_http_api_dto.ResetPasswordRequest": {
+            "type": "object",
+            "required": [
+                "new_password",
+                "token"
+            ],
+            "properties": {
+                "new_password": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
         "profile_service_http_api_dto.SendFriendRequestRequest": {
             "type": "object",
             "properties": {
@@ -1091,6 +2420,41 @@ const docTemplate = `{
                 }
             }
         },
+        "profile_service_http_api_dto.SessionListResponse": {
+            "type": "object",
+            "properties": {
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/profile_service_http_api_dto.SessionResponse"
+                    }
+                }
+            }
+        },
+        "profile_service_http_api_dto.SessionResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "current": {
+                    "description": "Current — сессия, с которой выполнен запрос",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
         "profile_service_http_api_dto.SuccessResponse": {
             "type": "object",
             "properties": {
@@ -1105,9 +2469,6 @@ const docTemplate = `{
         "profile_service_http_api_dto.UpdateUserRequest": {
             "type": "object",
             "properties": {
-                "email": {
-                    "type": "string"
-                },
                 "name": {
                     "type": "string",
                     "maxLength": 50,
@@ -1141,9 +2502,18 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
+                "devices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/profile_service_http_api_dto.DeviceView"
+                    }
+                },
                 "email": {
                     "type": "string"
                 },
+                "email_verified": {
+                    "type": "boolean"
+                },
                 "id": {
                     "type": "integer"
                 },
@@ -1157,6 +2527,17 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "profile_service_http_api_dto.VerifyEmailRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
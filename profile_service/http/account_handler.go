@@ -0,0 +1,282 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"profile_service/http/api_dto"
+	"profile_service/internal/auth/password"
+	authService "profile_service/internal/auth/service"
+	"profile_service/middleware_profile"
+	pb "profile_service/pkg/grpc_generated/profile"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// PostVerifyEmail
+// @Summary Подтверждение email
+// @Description Подтверждает email по одноразовому токену из письма
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body api_dto.VerifyEmailRequest true "Токен из письма"
+// @Success 200 {object} api_dto.SuccessResponse "Email подтверждён"
+// @Failure 400 {object} middleware_profile.ErrorResponse "Недействительный или просроченный токен"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/verify-email [post]
+func (h *UserHandler) PostVerifyEmail(ctx *gin.Context) {
+	var req *api_dto.VerifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn("Invalid verify email request")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid verify email request", err), h.log)
+		return
+	}
+	if _, err := h.accounts.VerifyEmail(ctx.Request.Context(), req.Token); err != nil {
+		h.handleAccountError(ctx, err, "Failed to verify email")
+		return
+	}
+	ctx.JSON(http.StatusOK, api_dto.SuccessResponse{
+		Success: true,
+		Message: "Email verified",
+	})
+}
+
+// PostResendVerification
+// @Summary Повторная отправка письма подтверждения
+// @Description Высылает новую ссылку подтверждения; предыдущие ссылки перестают действовать
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} api_dto.SuccessResponse "Письмо отправлено"
+// @Failure 401 {object} middleware_profile.ErrorResponse "Неверные учетные данные"
+// @Failure 409 {object} middleware_profile.ErrorResponse "Email уже подтверждён"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/verify-email/resend [post]
+func (h *UserHandler) PostResendVerification(ctx *gin.Context) {
+	userId, err := strconv.ParseInt(ctx.GetString("user_id"), 10, 64)
+	if err != nil {
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusUnauthorized, "Invalid user", err), h.log)
+		return
+	}
+	if err := h.accounts.SendVerification(ctx.Request.Context(), userId); err != nil {
+		h.handleAccountError(ctx, err, "Failed to send verification email")
+		return
+	}
+	ctx.JSON(http.StatusOK, api_dto.SuccessResponse{
+		Success: true,
+		Message: "Verification email sent",
+	})
+}
+
+// PostForgotPassword
+// @Summary Запрос сброса пароля
+// @Description Высылает ссылку для сброса пароля. Ответ одинаков для известных и неизвестных адресов
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body api_dto.ForgotPasswordRequest true "Email аккаунта"
+// @Success 202 {object} api_dto.SuccessResponse "Запрос принят"
+// @Failure 400 {object} middleware_profile.ErrorResponse "Неверные данные"
+// @Failure 429 {object} middleware_profile.ErrorResponse "Слишком много запросов, см. Retry-After"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/password/forgot [post]
+func (h *UserHandler) PostForgotPassword(ctx *gin.Context) {
+	var req *api_dto.ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn("Invalid forgot password request")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid forgot password request", err), h.log)
+		return
+	}
+	if err := h.accounts.RequestPasswordReset(ctx.Request.Context(), req.Email, ctx.ClientIP()); err != nil {
+		h.handleAccountError(ctx, err, "Failed to request password reset")
+		return
+	}
+	ctx.JSON(http.StatusAccepted, api_dto.SuccessResponse{
+		Success: true,
+		Message: "If the email is registered, a reset link has been sent",
+	})
+}
+
+// PostResetPassword
+// @Summary Сброс пароля
+// @Description Устанавливает новый пароль по токену из письма и завершает все сессии пользователя
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body api_dto.ResetPasswordRequest true "Токен и новый пароль"
+// @Success 200 {object} api_dto.SuccessResponse "Пароль изменён"
+// @Failure 400 {object} middleware_profile.ErrorResponse "Недействительный токен или пароль не соответствует политике"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/password/reset [post]
+func (h *UserHandler) PostResetPassword(ctx *gin.Context) {
+	var req *api_dto.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn("Invalid reset password request")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid reset password request", err), h.log)
+		return
+	}
+	userId, err := h.accounts.ResetPassword(ctx.Request.Context(), req.Token, req.NewPassword)
+	if err != nil {
+		h.handleAccountError(ctx, err, "Failed to reset password")
+		return
+	}
+	h.revokeSessions(ctx, userId, 0, authService.SecurityPasswordReset)
+	ctx.JSON(http.StatusOK, api_dto.SuccessResponse{
+		Success: true,
+		Message: "Password changed",
+	})
+}
+
+// PutPassword
+// @Summary Смена пароля
+// @Description Меняет пароль после проверки текущего. Остальные сессии завершаются; текущая сохраняется, но access-токен нужно обновить через /auth/refresh
+// @Tags Me
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body api_dto.ChangePasswordRequest true "Текущий и новый пароль"
+// @Success 200 {object} api_dto.SuccessResponse "Пароль изменён"
+// @Failure 400 {object} middleware_profile.ErrorResponse "Неверные данные или пароль не соответствует политике"
+// @Failure 401 {object} middleware_profile.ErrorResponse "Неверные учетные данные"
+// @Failure 403 {object} middleware_profile.ErrorResponse "Неверный текущий пароль"
+// @Failure 429 {object} middleware_profile.ErrorResponse "Слишком много попыток, см. Retry-After"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /me/password [put]
+func (h *UserHandler) PutPassword(ctx *gin.Context) {
+	userId, err := strconv.ParseInt(ctx.GetString("user_id"), 10, 64)
+	if err != nil {
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusUnauthorized, "Invalid user", err), h.log)
+		return
+	}
+	var req *api_dto.ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn("Invalid change password request")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid change password request", err), h.log)
+		return
+	}
+	if err := h.accounts.ChangePassword(ctx.Request.Context(), userId, req.CurrentPassword, req.NewPassword); err != nil {
+		h.handleAccountError(ctx, err, "Failed to change password")
+		return
+	}
+	h.revokeSessions(ctx, userId, ctx.GetInt64("session_id"), authService.SecurityPasswordChanged)
+	ctx.JSON(http.StatusOK, api_dto.SuccessResponse{
+		Success: true,
+		Message: "Password changed",
+	})
+}
+
+// PostEmailChange
+// @Summary Запрос смены email
+// @Description Проверяет текущий пароль и отправляет ссылку подтверждения на новый адрес. Email меняется только после подтверждения
+// @Tags Me
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body api_dto.ChangeEmailRequest true "Текущий пароль и новый email"
+// @Success 202 {object} api_dto.SuccessResponse "Письмо подтверждения отправлено"
+// @Failure 400 {object} middleware_profile.ErrorResponse "Неверные данные"
+// @Failure 401 {object} middleware_profile.ErrorResponse "Неверные учетные данные"
+// @Failure 403 {object} middleware_profile.ErrorResponse "Неверный текущий пароль"
+// @Failure 409 {object} middleware_profile.ErrorResponse "Email уже занят"
+// @Failure 429 {object} middleware_profile.ErrorResponse "Слишком много попыток, см. Retry-After"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /me/email [post]
+func (h *UserHandler) PostEmailChange(ctx *gin.Context) {
+	userId, err := strconv.ParseInt(ctx.GetString("user_id"), 10, 64)
+	if err != nil {
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusUnauthorized, "Invalid user", err), h.log)
+		return
+	}
+	var req *api_dto.ChangeEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn("Invalid change email request")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid change email request", err), h.log)
+		return
+	}
+	if err := h.accounts.RequestEmailChange(ctx.Request.Context(), userId, req.CurrentPassword, req.NewEmail); err != nil {
+		h.handleAccountError(ctx, err, "Failed to request email change")
+		return
+	}
+	ctx.JSON(http.StatusAccepted, api_dto.SuccessResponse{
+		Success: true,
+		Message: "Confirmation link sent to the new email",
+	})
+}
+
+// PostEmailConfirm
+// @Summary Подтверждение смены email
+// @Description Применяет смену email по токену из письма. Остальные сессии завершаются
+// @Tags Me
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body api_dto.ConfirmEmailChangeRequest true "Токен из письма"
+// @Success 200 {object} api_dto.SuccessResponse "Email изменён"
+// @Failure 400 {object} middleware_profile.ErrorResponse "Недействительный или просроченный токен"
+// @Failure 401 {object} middleware_profile.ErrorResponse "Неверные учетные данные"
+// @Failure 409 {object} middleware_profile.ErrorResponse "Email уже занят"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /me/email/confirm [post]
+func (h *UserHandler) PostEmailConfirm(ctx *gin.Context) {
+	userId, err := strconv.ParseInt(ctx.GetString("user_id"), 10, 64)
+	if err != nil {
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusUnauthorized, "Invalid user", err), h.log)
+		return
+	}
+	var req *api_dto.ConfirmEmailChangeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn("Invalid confirm email request")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid confirm email request", err), h.log)
+		return
+	}
+	if err := h.accounts.ConfirmEmailChange(ctx.Request.Context(), userId, req.Token); err != nil {
+		h.handleAccountError(ctx, err, "Failed to confirm email change")
+		return
+	}
+	h.revokeSessions(ctx, userId, ctx.GetInt64("session_id"), authService.SecurityEmailChanged)
+	ctx.JSON(http.StatusOK, api_dto.SuccessResponse{
+		Success: true,
+		Message: "Email changed",
+	})
+}
+
+// revokeSessions завершает сессии пользователя, кроме exceptSessionId. Изменение
+// уже сохранено, поэтому неудачный отзыв только логируется.
+func (h *UserHandler) revokeSessions(ctx *gin.Context, userId, exceptSessionId int64, reason string) {
+	_, err := h.authServer.RevokeAllSessions(ctx.Request.Context(), &pb.RevokeAllSessionsRequest{
+		UserId:          strconv.FormatInt(userId, 10),
+		Reason:          reason,
+		ExceptSessionId: exceptSessionId,
+	})
+	if err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "user_id": userId, "reason": reason}).Error("Failed to revoke sessions")
+	}
+}
+
+func (h *UserHandler) handleAccountError(ctx *gin.Context, err error, msg string) {
+	h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn(msg)
+	var throttled *authService.ThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		ctx.Header("Retry-After", strconv.Itoa(int((throttled.Wait+time.Second-1)/time.Second)))
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusTooManyRequests, "Too many requests", err), h.log)
+	case errors.Is(err, authService.ErrInvalidAccountToken):
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid or expired token", err), h.log)
+	case errors.Is(err, authService.ErrEmailAlreadyVerified):
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusConflict, "Email already verified", err), h.log)
+	case errors.Is(err, authService.ErrAccountNotFound):
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusNotFound, "User not found", err), h.log)
+	case errors.Is(err, authService.ErrWrongPassword):
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusForbidden, "Current password is incorrect", err), h.log)
+	case errors.Is(err, authService.ErrEmailTaken):
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusConflict, "Email already taken", err), h.log)
+	case errors.Is(err, authService.ErrSameEmail):
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "New email matches the current one", err), h.log)
+	case errors.Is(err, password.ErrWeakPassword):
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, err.Error(), err), h.log)
+	default:
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusInternalServerError, msg, err), h.log)
+	}
+}
@@ -14,12 +14,65 @@ type CreateUserRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// UpdateUserRequest — email меняется только через /me/email с подтверждением пароля.
 type UpdateUserRequest struct {
-	Name  *string `json:"name" binding:"omitempty,min=2,max=50"`
-	Email *string `json:"email" binding:"omitempty,email"`
+	Name *string `json:"name" binding:"omitempty,min=2,max=50"`
+}
+
+type PrivacySettingsRequest struct {
+	LastSeen string `json:"last_seen" binding:"required,oneof=everyone friends nobody"`
 }
 
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 }
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type ChangeEmailRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewEmail        string `json:"new_email" binding:"required,email"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type LoginMfaRequest struct {
+	MfaToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type MfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableMfaRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	Code            string `json:"code" binding:"required"`
+}
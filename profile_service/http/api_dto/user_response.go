@@ -10,12 +10,22 @@ type UserCreateResponse struct {
 }
 
 type UserViewResponse struct {
-	Id        int64     `json:"id"`
-	Name      string    `json:"username"`
-	Email     string    `json:"email"`
-	Status    string    `json:"status"`
-	LastSeen  time.Time `json:"last_seen"`
-	CreatedAt time.Time `json:"created_at"`
+	Id        int64        `json:"id"`
+	Name      string       `json:"username"`
+	Email     string       `json:"email"`
+	Status    string       `json:"status"`
+	LastSeen  time.Time    `json:"last_seen"`
+	Devices   []DeviceView `json:"devices,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+
+	EmailVerified bool `json:"email_verified"`
+}
+
+type DeviceView struct {
+	Device       string    `json:"device"`
+	AppVersion   string    `json:"app_version"`
+	Status       string    `json:"status"`
+	LastActivity time.Time `json:"last_activity"`
 }
 
 type UserViewListResponse struct {
@@ -25,7 +35,48 @@ type UserViewListResponse struct {
 	UserList []*UserViewResponse `json:"users"`
 }
 
+type PrivacySettingsResponse struct {
+	LastSeen string `json:"last_seen"`
+}
+
 type LoginResponse struct {
-	Token  string `json:"token"`
-	UserId string `json:"user_id"`
+	Token            string `json:"token"`
+	UserId           string `json:"user_id"`
+	ExpiresAt        int64  `json:"expires_at"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresAt int64  `json:"refresh_expires_at"`
+	MfaRequired      bool   `json:"mfa_required,omitempty"`
+	MfaToken         string `json:"mfa_token,omitempty"`
+}
+
+type MfaStatusResponse struct {
+	Enabled           bool  `json:"enabled"`
+	RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+}
+
+type MfaEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type OidcProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+type SessionResponse struct {
+	Id         int64     `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	Ip         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	// Current — сессия, с которой выполнен запрос
+	Current bool `json:"current"`
+}
+
+type SessionListResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
 }
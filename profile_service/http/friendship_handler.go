@@ -53,7 +53,7 @@ func NewFriendshipHandler(userService userService.UserServiceInterface, friendsh
 // @Success 200 {object} api_dto.SendFriendRequestResponse "Запрос успешно отправлен"
 // @Failure 400 {object} middleware_profile.ErrorResponse "Неверные данные"
 // @Failure 401 {object} middleware_profile.ErrorResponse "Не авторизован"
-// @Failure 403 {object} middleware_profile.ErrorResponse "Пользователь заблокирован"
+// @Failure 403 {object} middleware_profile.ErrorResponse "Пользователь заблокирован или email не подтверждён"
 // @Failure 404 {object} middleware_profile.ErrorResponse "Пользователь не найден"
 // @Failure 409 {object} middleware_profile.ErrorResponse "Конфликт (уже друзья или есть запрос)"
 // @Router /friends/requests/{receiver_id} [post]
@@ -78,7 +78,7 @@ func (h *FriendshipHandler) PostFriendRequest(ctx *gin.Context) {
 			middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusNotFound, "User not found", err), h.log)
 		case errors.Is(err, helpers.ErrAlreadyFriends), errors.Is(err, helpers.ErrFriendRequestExists):
 			middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusConflict, err.Error(), err), h.log)
-		case errors.Is(err, helpers.ErrBlockedByUser):
+		case errors.Is(err, helpers.ErrBlockedByUser), errors.Is(err, helpers.ErrEmailNotVerified):
 			middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusForbidden, err.Error(), err), h.log)
 		case errors.Is(err, helpers.ErrCannotFriendYourself):
 			middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, err.Error(), err), h.log)
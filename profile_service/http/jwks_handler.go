@@ -0,0 +1,26 @@
+package http
+
+import (
+	"net/http"
+	"profile_service/internal/auth/keys"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jwksMaxAge — сколько клиенты могут кэшировать ключи. Новый ключ публикуется
+// заранее, до того как станет активным, поэтому кэш не мешает ротации.
+const jwksMaxAge = "public, max-age=300"
+
+type JWKSHandler struct {
+	keys *keys.KeyRing
+}
+
+func NewJWKSHandler(keys *keys.KeyRing) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GetJWKS отдаёт открытые ключи проверки access-токенов (RFC 7517).
+func (h *JWKSHandler) GetJWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", jwksMaxAge)
+	ctx.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"profile_service/http/api_dto"
+	authService "profile_service/internal/auth/service"
+	"profile_service/middleware_profile"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetMfa
+// @Summary Состояние 2FA
+// @Description Включена ли двухфакторная аутентификация и сколько осталось кодов восстановления
+// @Tags Me
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} api_dto.MfaStatusResponse "Состояние 2FA"
+// @Failure 401 {object} middleware_profile.ErrorResponse "Неверные учетные данные"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /me/mfa [get]
+func (h *UserHandler) GetMfa(ctx *gin.Context) {
+	userId, err := strconv.ParseInt(ctx.GetString("user_id"), 10, 64)
+	if err != nil {
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusUnauthorized, "Invalid user", err), h.log)
+		return
+	}
+	status, err := h.mfa.Status(ctx.Request.Context(), userId)
+	if err != nil {
+		h.handleMfaError(ctx, err, "Failed to get mfa status")
+		return
+	}
+	ctx.JSON(http.StatusOK, api_dto.MfaStatusResponse{
+		Enabled:           status.Enabled,
+		RecoveryCodesLeft: status.RecoveryCodesLeft,
+	})
+}
+
+// PostMfaEnroll
+// @Summary Начало подключения 2FA
+// @Description Выдаёт TOTP-секрет и otpauth:// URI для QR-кода. 2FA включается только после подтверждения кодом через /me/mfa/confirm
+// @Tags Me
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} api_dto.MfaEnrollResponse "Секрет для приложения-аутентификатора"
+// @Failure 401 {object} middleware_profile.ErrorResponse "Неверные учетные данные"
+// @Failure 409 {object} middleware_profile.ErrorResponse "2FA уже включена"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Failure 503 {object} middleware_profile.ErrorResponse "2FA не настроена на сервере"
+// @Router /me/mfa/enroll [post]
+func (h *UserHandler) PostMfaEnroll(ctx *gin.Context) {
+	userId, err := strconv.ParseInt(ctx.GetString("user_id"), 10, 64)
+	if err != nil {
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusUnauthorized, "Invalid user", err), h.log)
+		return
+	}
+	enrollment, err := h.mfa.Enroll(ctx.Request.Context(), userId)
+	if err != nil {
+		h.handleMfaError(ctx, err, "Failed to start mfa enrollment")
+		return
+	}
+	ctx.JSON(http.StatusOK, api_dto.MfaEnrollResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	})
+}
+
+// PostMfaConfirm
+// @Summary Подтверждение подключения 2FA
+// @Description Включает 2FA по коду из приложения и возвращает коды восстановления — они показываются один раз. Остальные сессии завершаются
+// @Tags Me
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body api_dto.MfaCodeRequest true "Код из приложения"
+// @Success 200 {object} api_dto.RecoveryCodesResponse "Коды восстановления"
+// @Failure 400 {object} middleware_profile.ErrorResponse "Неверный код или подключение не начато"
+// @Failure 401 {object} middleware_profile.ErrorResponse "Неверные учетные данные"
+// @Failure 409 {object} middleware_profile.ErrorResponse "2FA уже включена"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Failure 503 {object} middleware_profile.ErrorResponse "2FA не настроена на сервере"
+// @Router /me/mfa/confirm [post]
+func (h *UserHandler) PostMfaConfirm(ctx *gin.Context) {
+	userId, err := strconv.ParseInt(ctx.GetString("user_id"), 10, 64)
+	if err != nil {
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusUnauthorized, "Invalid user", err), h.log)
+		return
+	}
+	var req *api_dto.MfaCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn("Invalid mfa confirm request")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid mfa confirm request", err), h.log)
+		return
+	}
+	codes, err := h.mfa.Confirm(ctx.Request.Context(), userId, req.Code)
+	if err != nil {
+		h.handleMfaError(ctx, err, "Failed to confirm mfa")
+		return
+	}
+	h.revokeSessions(ctx, userId, ctx.GetInt64("session_id"), authService.SecurityMfaEnabled)
+	ctx.JSON(http.StatusOK, api_dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// PostMfaDisable
+// @Summary Отключение 2FA
+// @Description Отключает 2FA; нужны текущий пароль и код из приложения или код восстановления
+// @Tags Me
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body api_dto.DisableMfaRequest true "Пароль и код"
+// @Success 200 {object} api_dto.SuccessResponse "2FA отключена"
+// @Failure 400 {object} middleware_profile.ErrorResponse "Неверный код или 2FA не включена"
+// @Failure 401 {object} middleware_profile.ErrorResponse "Неверные учетные данные"
+// @Failure 403 {object} middleware_profile.ErrorResponse "Неверный текущий пароль"
+// @Failure 429 {object} middleware_profile.ErrorResponse "Слишком много попыток, см. Retry-After"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /me/mfa/disable [post]
+func (h *UserHandler) PostMfaDisable(ctx *gin.Context) {
+	userId, err := strconv.ParseInt(ctx.GetString("user_id"), 10, 64)
+	if err != nil {
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusUnauthorized, "Invalid user", err), h.log)
+		return
+	}
+	var req *api_dto.DisableMfaRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn("Invalid mfa disable request")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid mfa disable request", err), h.log)
+		return
+	}
+	if err := h.mfa.Disable(ctx.Request.Context(), userId, req.CurrentPassword, req.Code); err != nil {
+		h.handleMfaError(ctx, err, "Failed to disable mfa")
+		return
+	}
+	ctx.JSON(http.StatusOK, api_dto.SuccessResponse{
+		Success: true,
+		Message: "Two-factor authentication disabled",
+	})
+}
+
+func (h *UserHandler) handleMfaError(ctx *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, authService.ErrInvalidMfaCode):
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn(msg)
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid two-factor code", err), h.log)
+	case errors.Is(err, authService.ErrMfaNotEnrolled), errors.Is(err, authService.ErrMfaNotEnabled):
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn(msg)
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, err.Error(), err), h.log)
+	case errors.Is(err, authService.ErrMfaAlreadyEnabled):
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn(msg)
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusConflict, "Two-factor authentication already enabled", err), h.log)
+	case errors.Is(err, authService.ErrMfaUnavailable):
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn(msg)
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusServiceUnavailable, "Two-factor authentication is not available", err), h.log)
+	default:
+		h.handleAccountError(ctx, err, msg)
+	}
+}
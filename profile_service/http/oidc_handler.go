@@ -0,0 +1,83 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"profile_service/http/api_dto"
+	"profile_service/http/user_mapper"
+	authService "profile_service/internal/auth/service"
+	"profile_service/middleware_profile"
+	pb "profile_service/pkg/grpc_generated/profile"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetOidcProviders
+// @Summary Внешние провайдеры входа
+// @Description Имена настроенных OIDC-провайдеров для /auth/oidc/{provider}/login
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} api_dto.OidcProvidersResponse "Список провайдеров"
+// @Router /auth/oidc/providers [get]
+func (h *UserHandler) GetOidcProviders(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, api_dto.OidcProvidersResponse{Providers: h.external.Providers()})
+}
+
+// GetOidcLogin
+// @Summary Вход через внешний провайдер
+// @Description Перенаправляет на страницу входа провайдера (authorization code flow с PKCE)
+// @Tags Auth
+// @Param provider path string true "Имя провайдера"
+// @Success 302 "Редирект на провайдера"
+// @Failure 404 {object} middleware_profile.ErrorResponse "Неизвестный провайдер"
+// @Failure 502 {object} middleware_profile.ErrorResponse "Провайдер недоступен"
+// @Router /auth/oidc/{provider}/login [get]
+func (h *UserHandler) GetOidcLogin(ctx *gin.Context) {
+	authURL, err := h.external.Begin(ctx.Request.Context(), ctx.Param("provider"))
+	if err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "provider": ctx.Param("provider")}).Warn("Failed to start external login")
+		if errors.Is(err, authService.ErrUnknownProvider) {
+			middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusNotFound, "Unknown identity provider", err), h.log)
+			return
+		}
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadGateway, "Identity provider is unavailable", err), h.log)
+		return
+	}
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+// GetOidcCallback
+// @Summary Возврат от внешнего провайдера
+// @Description Обменивает код авторизации и выдаёт токены. Аккаунт находится по привязке, привязывается по подтверждённому email или создаётся
+// @Tags Auth
+// @Produce json
+// @Param provider path string true "Имя провайдера"
+// @Param code query string true "Код авторизации"
+// @Param state query string true "state из начала входа"
+// @Success 200 {object} api_dto.LoginResponse "Успешный вход или mfa_required"
+// @Failure 400 {object} middleware_profile.ErrorResponse "Провайдер вернул ошибку"
+// @Failure 401 {object} middleware_profile.ErrorResponse "Недействительный state или код"
+// @Failure 404 {object} middleware_profile.ErrorResponse "Неизвестный провайдер"
+// @Failure 409 {object} middleware_profile.ErrorResponse "Аккаунт нельзя привязать автоматически"
+// @Router /auth/oidc/{provider}/callback [get]
+func (h *UserHandler) GetOidcCallback(ctx *gin.Context) {
+	if providerErr := ctx.Query("error"); providerErr != "" {
+		h.log.WithFields(logrus.Fields{"provider": ctx.Param("provider"), "error": providerErr}).Warn("Identity provider returned error")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Identity provider error: "+providerErr, nil), h.log)
+		return
+	}
+
+	resp, err := h.authServer.ExternalLogin(ctx.Request.Context(), &pb.ExternalLoginRequest{
+		Provider:  ctx.Param("provider"),
+		Code:      ctx.Query("code"),
+		State:     ctx.Query("state"),
+		UserAgent: ctx.Request.UserAgent(),
+		Ip:        ctx.ClientIP(),
+	})
+	if err != nil {
+		h.handleLoginError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, user_mapper.ConvertToLoginResponse(resp))
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"net/http"
+	"profile_service/http/api_dto"
+	"profile_service/middleware_profile"
+	pb "profile_service/pkg/grpc_generated/profile"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetSessions
+// @Summary Активные сессии
+// @Description Устройства, на которых выполнен вход: user agent, IP, время входа и последнего обновления токенов. Текущая сессия помечена current
+// @Tags Me
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} api_dto.SessionListResponse "Список сессий"
+// @Failure 401 {object} middleware_profile.ErrorResponse "Неверные учетные данные"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /me/sessions [get]
+func (h *UserHandler) GetSessions(ctx *gin.Context) {
+	resp, err := h.authServer.ListSessions(ctx.Request.Context(), &pb.ListSessionsRequest{
+		UserId: ctx.GetString("user_id"),
+	})
+	if err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Error("Failed to list sessions")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusInternalServerError, "Failed to list sessions", err), h.log)
+		return
+	}
+
+	current := ctx.GetInt64("session_id")
+	sessions := make([]api_dto.SessionResponse, 0, len(resp.Sessions))
+	for _, s := range resp.Sessions {
+		sessions = append(sessions, api_dto.SessionResponse{
+			Id:         s.Id,
+			UserAgent:  s.UserAgent,
+			Ip:         s.Ip,
+			CreatedAt:  time.Unix(s.CreatedAt, 0).UTC(),
+			LastUsedAt: time.Unix(s.LastUsedAt, 0).UTC(),
+			Current:    s.Id == current,
+		})
+	}
+	ctx.JSON(http.StatusOK, api_dto.SessionListResponse{Sessions: sessions})
+}
+
+// DeleteSession
+// @Summary Завершение сессии
+// @Description Выход на одном устройстве: токены сессии отзываются, её WebSocket-соединения закрываются. Для выхода с текущего устройства используйте /auth/logout
+// @Tags Me
+// @Security BearerAuth
+// @Param id path int true "ID сессии"
+// @Success 204 "Сессия завершена"
+// @Failure 400 {object} middleware_profile.ErrorResponse "Неверный ID сессии"
+// @Failure 401 {object} middleware_profile.ErrorResponse "Неверные учетные данные"
+// @Failure 404 {object} middleware_profile.ErrorResponse "Сессия не найдена"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /me/sessions/{id} [delete]
+func (h *UserHandler) DeleteSession(ctx *gin.Context) {
+	sessionId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || sessionId <= 0 {
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid session id", err), h.log)
+		return
+	}
+
+	_, err = h.authServer.RevokeSession(ctx.Request.Context(), &pb.RevokeSessionRequest{
+		UserId:    ctx.GetString("user_id"),
+		SessionId: sessionId,
+		Reason:    "session_revoked",
+	})
+	if err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "session_id": sessionId}).Warn("Failed to revoke session")
+		if status.Code(err) == codes.NotFound {
+			middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusNotFound, "Session not found", err), h.log)
+			return
+		}
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusInternalServerError, "Failed to revoke session", err), h.log)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
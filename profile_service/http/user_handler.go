@@ -5,22 +5,33 @@ import (
 	"os"
 	"profile_service/http/api_dto"
 	"profile_service/http/user_mapper"
+	authService "profile_service/internal/auth/service"
 	"profile_service/internal/user/service"
 	"profile_service/middleware_profile"
 	pb "profile_service/pkg/grpc_generated/profile"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type UserHandler struct {
 	userService service.UserServiceInterface
 	authServer  pb.AuthServiceServer
+	accounts    authService.AccountServiceInterface
+	mfa         authService.MfaServiceInterface
+	external    authService.ExternalAuthServiceInterface
 	log         *logrus.Logger
 }
 
-func NewUserHandler(userService service.UserServiceInterface, authServer pb.AuthServiceServer, log *logrus.Logger) *UserHandler {
+func NewUserHandler(userService service.UserServiceInterface, authServer pb.AuthServiceServer,
+	accounts authService.AccountServiceInterface, mfa authService.MfaServiceInterface,
+	external authService.ExternalAuthServiceInterface, log *logrus.Logger) *UserHandler {
 	if log == nil {
 		log = logrus.New()
 		log.SetFormatter(&logrus.JSONFormatter{})
@@ -30,13 +41,16 @@ func NewUserHandler(userService service.UserServiceInterface, authServer pb.Auth
 	return &UserHandler{
 		userService: userService,
 		authServer:  authServer,
+		accounts:    accounts,
+		mfa:         mfa,
+		external:    external,
 		log:         log,
 	}
 }
 
 // PostLogin
 // @Summary Вход пользователя
-// @Description Аутентифицирует пользователя и возвращает JWT
+// @Description Аутентифицирует пользователя и возвращает JWT. При включённой 2FA токены не выдаются: ответ содержит mfa_required и mfa_token для /auth/login/mfa
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -44,6 +58,7 @@ func NewUserHandler(userService service.UserServiceInterface, authServer pb.Auth
 // @Success 200 {object} api_dto.LoginResponse "Успешный вход"
 // @Failure 400 {object} middleware_profile.ErrorResponse "Неверные данные"
 // @Failure 401 {object} middleware_profile.ErrorResponse "Неверные учетные данные"
+// @Failure 429 {object} middleware_profile.ErrorResponse "Слишком много попыток, см. Retry-After"
 // @Router /auth/login [post]
 func (h *UserHandler) PostLogin(ctx *gin.Context) {
 	var req *api_dto.LoginRequest
@@ -52,24 +67,150 @@ func (h *UserHandler) PostLogin(ctx *gin.Context) {
 		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid login request", err), h.log)
 		return
 	}
-	resp, err := h.authServer.Login(ctx.Request.Context(), user_mapper.ConvertToLoginRequest(req))
+	resp, err := h.authServer.Login(ctx.Request.Context(),
+		user_mapper.ConvertToLoginRequest(req, ctx.Request.UserAgent(), ctx.ClientIP()))
 	if err != nil {
-		h.log.WithError(err).Error("Failed to login")
-		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusUnauthorized, "Invalid credentials", err), h.log)
+		h.handleLoginError(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, user_mapper.ConvertToLoginResponse(resp))
 }
 
+// PostLoginMfa
+// @Summary Второй шаг входа
+// @Description Завершает вход с 2FA: принимает mfa_token из /auth/login и код из приложения или код восстановления
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body api_dto.LoginMfaRequest true "Токен входа и код"
+// @Success 200 {object} api_dto.LoginResponse "Успешный вход"
+// @Failure 400 {object} middleware_profile.ErrorResponse "Неверные данные"
+// @Failure 401 {object} middleware_profile.ErrorResponse "Неверный код или истёкший mfa_token"
+// @Failure 429 {object} middleware_profile.ErrorResponse "Слишком много попыток, см. Retry-After"
+// @Router /auth/login/mfa [post]
+func (h *UserHandler) PostLoginMfa(ctx *gin.Context) {
+	var req *api_dto.LoginMfaRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn("Invalid mfa login request")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid mfa login request", err), h.log)
+		return
+	}
+	resp, err := h.authServer.VerifyMfa(ctx.Request.Context(), &pb.VerifyMfaRequest{
+		MfaToken: req.MfaToken,
+		Code:     req.Code,
+	})
+	if err != nil {
+		h.handleLoginError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, user_mapper.ConvertToLoginResponse(resp))
+}
+
+func (h *UserHandler) handleLoginError(ctx *gin.Context, err error) {
+	h.log.WithError(err).Error("Failed to login")
+	st, _ := status.FromError(err)
+	switch st.Code() {
+	case codes.ResourceExhausted:
+		setRetryAfter(ctx, st)
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusTooManyRequests, "Too many login attempts", err), h.log)
+	case codes.Unauthenticated:
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusUnauthorized, st.Message(), err), h.log)
+	case codes.NotFound:
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusNotFound, st.Message(), err), h.log)
+	case codes.FailedPrecondition:
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusConflict, st.Message(), err), h.log)
+	default:
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusServiceUnavailable, "Login is temporarily unavailable", err), h.log)
+	}
+}
+
+// PostRefresh
+// @Summary Обновление токенов
+// @Description Обменивает refresh-токен на новую пару токенов. Каждый refresh-токен одноразовый: повторное использование завершает сессию
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body api_dto.RefreshRequest true "Refresh-токен"
+// @Success 200 {object} api_dto.LoginResponse "Новая пара токенов"
+// @Failure 400 {object} middleware_profile.ErrorResponse "Неверные данные"
+// @Failure 401 {object} middleware_profile.ErrorResponse "Недействительный refresh-токен"
+// @Router /auth/refresh [post]
+func (h *UserHandler) PostRefresh(ctx *gin.Context) {
+	var req *api_dto.RefreshRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn("Invalid refresh request")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid refresh request", err), h.log)
+		return
+	}
+	resp, err := h.authServer.Refresh(ctx.Request.Context(), &pb.RefreshRequest{RefreshToken: req.RefreshToken})
+	if err != nil {
+		h.log.WithError(err).Warn("Failed to refresh token")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusUnauthorized, "Invalid refresh token", err), h.log)
+		return
+	}
+	ctx.JSON(http.StatusOK, user_mapper.ConvertToLoginResponse(resp))
+}
+
+// PostLogout
+// @Summary Выход
+// @Description Завершает сессию, которой принадлежит refresh-токен. Если передан заголовок Authorization, access-токен отзывается сразу
+// @Tags Auth
+// @Accept json
+// @Param request body api_dto.LogoutRequest true "Refresh-токен"
+// @Success 204 "Сессия завершена"
+// @Failure 400 {object} middleware_profile.ErrorResponse "Неверные данные"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/logout [post]
+func (h *UserHandler) PostLogout(ctx *gin.Context) {
+	var req *api_dto.LogoutRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn("Invalid logout request")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid logout request", err), h.log)
+		return
+	}
+	_, err := h.authServer.Logout(ctx.Request.Context(), &pb.LogoutRequest{
+		RefreshToken: req.RefreshToken,
+		AccessToken:  strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer "),
+	})
+	if err != nil {
+		h.log.WithError(err).Error("Failed to logout")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusInternalServerError, "Failed to logout", err), h.log)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// PostLogoutAll
+// @Summary Выход на всех устройствах
+// @Description Отзывает все токены и сессии текущего пользователя и закрывает его WebSocket-соединения
+// @Tags Auth
+// @Security BearerAuth
+// @Success 204 "Все сессии завершены"
+// @Failure 401 {object} middleware_profile.ErrorResponse "Неверные учетные данные"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/logout-all [post]
+func (h *UserHandler) PostLogoutAll(ctx *gin.Context) {
+	_, err := h.authServer.RevokeAllSessions(ctx.Request.Context(), &pb.RevokeAllSessionsRequest{
+		UserId: ctx.GetString("user_id"),
+		Reason: "logout_all",
+	})
+	if err != nil {
+		h.log.WithError(err).Error("Failed to revoke sessions")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusInternalServerError, "Failed to logout", err), h.log)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
 // PostUser
 // @Summary Создать нового пользователя
-// @Description Создает нового пользователя с указанными данными
+// @Description Создает нового пользователя и отправляет письмо для подтверждения email
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param request body api_dto.CreateUserRequest true "Данные для создания пользователя"
 // @Success 201 {integer} int "ID созданного пользователя"
-// @Failure 400 {object} middleware_profile.ErrorResponse "Неверные данные пользователя"
+// @Failure 400 {object} middleware_profile.ErrorResponse "Неверные данные или пароль не соответствует политике"
 // @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
 // @Router /auth/register [post]
 func (h *UserHandler) PostUser(ctx *gin.Context) {
@@ -83,9 +224,19 @@ func (h *UserHandler) PostUser(ctx *gin.Context) {
 	id, err := h.authServer.Register(ctx.Request.Context(), mappedReq)
 	if err != nil {
 		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Error("Failed to create user")
+		if st, ok := status.FromError(err); ok && st.Code() == codes.InvalidArgument {
+			middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, st.Message(), err), h.log)
+			return
+		}
 		middleware_profile.HandleError(ctx, err, h.log)
 		return
 	}
+	// аккаунт уже создан: письмо можно запросить повторно
+	if userId, err := strconv.ParseInt(id.UserId, 10, 64); err == nil {
+		if err := h.accounts.SendVerification(ctx.Request.Context(), userId); err != nil {
+			h.log.WithFields(logrus.Fields{"error": err, "user_id": userId}).Error("Failed to send verification email")
+		}
+	}
 	ctx.JSON(http.StatusCreated, id)
 }
 
@@ -109,7 +260,9 @@ func (h *UserHandler) GetUserById(ctx *gin.Context) {
 		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid user ID", err), h.log)
 		return
 	}
-	user, err := h.userService.GetUserById(ctx.Request.Context(), id)
+	// без авторизации viewerId = 0
+	viewerId, _ := strconv.ParseInt(ctx.GetString("user_id"), 10, 64)
+	user, err := h.userService.GetUserByIdForViewer(ctx.Request.Context(), viewerId, id)
 	if err != nil {
 		h.log.WithFields(logrus.Fields{"error": err, "user_id": id, "path": ctx.Request.URL.Path}).Error("Failed to get user")
 		middleware_profile.HandleError(ctx, err, h.log)
@@ -216,5 +369,86 @@ func (h *UserHandler) DeleteUser(ctx *gin.Context) {
 		middleware_profile.HandleError(ctx, err, h.log)
 		return
 	}
+	// пользователь уже удалён: неудачный отзыв токенов только логируется
+	_, err = h.authServer.RevokeAllSessions(ctx.Request.Context(), &pb.RevokeAllSessionsRequest{
+		UserId: strconv.FormatInt(id, 10),
+		Reason: "account_deleted",
+	})
+	if err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "user_id": id}).Error("Failed to revoke sessions of deleted user")
+	}
 	ctx.JSON(http.StatusNoContent, id)
 }
+
+// GetPrivacySettings
+// @Summary Получить настройки приватности
+// @Description Возвращает, кому виден "last seen" текущего пользователя
+// @Tags Me
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Success 200 {object} api_dto.PrivacySettingsResponse "Успешный запрос"
+// @Failure 401 {object} middleware_profile.ErrorResponse "Неверные учетные данные"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /me/privacy [get]
+func (h *UserHandler) GetPrivacySettings(ctx *gin.Context) {
+	userId, err := strconv.ParseInt(ctx.GetString("user_id"), 10, 64)
+	if err != nil {
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusUnauthorized, "Invalid user", err), h.log)
+		return
+	}
+	settings, err := h.userService.GetPrivacySettings(ctx.Request.Context(), userId)
+	if err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Error("Failed to get privacy settings")
+		middleware_profile.HandleError(ctx, err, h.log)
+		return
+	}
+	ctx.JSON(http.StatusOK, user_mapper.ConvertToPrivacyResponse(settings))
+}
+
+// PutPrivacySettings
+// @Summary Изменить настройки приватности
+// @Description Задает, кому виден "last seen": everyone, friends или nobody
+// @Tags Me
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body api_dto.PrivacySettingsRequest true "Настройки приватности"
+// @Success 200 {object} api_dto.SuccessResponse "Настройки сохранены"
+// @Failure 400 {object} middleware_profile.ErrorResponse "Неверные данные запроса"
+// @Failure 401 {object} middleware_profile.ErrorResponse "Неверные учетные данные"
+// @Failure 500 {object} middleware_profile.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /me/privacy [put]
+func (h *UserHandler) PutPrivacySettings(ctx *gin.Context) {
+	var req *api_dto.PrivacySettingsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Warn("Invalid privacy request")
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusBadRequest, "Invalid privacy request", err), h.log)
+		return
+	}
+	userId, err := strconv.ParseInt(ctx.GetString("user_id"), 10, 64)
+	if err != nil {
+		middleware_profile.HandleError(ctx, middleware_profile.NewCustomError(http.StatusUnauthorized, "Invalid user", err), h.log)
+		return
+	}
+	if err := h.userService.UpdatePrivacySettings(ctx.Request.Context(), userId, user_mapper.ConvertToServicePrivacy(req)); err != nil {
+		h.log.WithFields(logrus.Fields{"error": err, "path": ctx.Request.URL.Path}).Error("Failed to update privacy settings")
+		middleware_profile.HandleError(ctx, err, h.log)
+		return
+	}
+	ctx.JSON(http.StatusOK, api_dto.SuccessResponse{
+		Success: true,
+		Message: "Privacy settings updated",
+	})
+}
+
+// setRetryAfter переносит задержку из RetryInfo в заголовок Retry-After (секунды, с округлением вверх).
+func setRetryAfter(ctx *gin.Context, st *status.Status) {
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			wait := info.GetRetryDelay().AsDuration()
+			ctx.Header("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+			return
+		}
+	}
+}
@@ -23,16 +23,23 @@ func ConvertToRegisterRequest(u *hDto.CreateUserRequest) *profile.RegisterReques
 	}
 }
 
+func ConvertToServicePrivacy(p *hDto.PrivacySettingsRequest) *service_dto.PrivacySettings {
+	return &service_dto.PrivacySettings{
+		LastSeenVisibility: p.LastSeen,
+	}
+}
+
 func ConvertToServiceUpdate(u *hDto.UpdateUserRequest) *service_dto.UpdateUserRequest {
 	return &service_dto.UpdateUserRequest{
 		Username: u.Name,
-		Email:    u.Email,
 	}
 }
 
-func ConvertToLoginRequest(u *hDto.LoginRequest) *profile.LoginRequest {
+func ConvertToLoginRequest(u *hDto.LoginRequest, userAgent, ip string) *profile.LoginRequest {
 	return &profile.LoginRequest{
-		Username: u.Username,
-		Password: u.Password,
+		Username:  u.Username,
+		Password:  u.Password,
+		UserAgent: userAgent,
+		Ip:        ip,
 	}
 }
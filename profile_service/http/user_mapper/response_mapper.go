@@ -13,8 +13,28 @@ func ConvertToServiceUser(u *sDto.GetUserResponse) *hDto.UserViewResponse {
 		Email:     u.Email,
 		Status:    u.Status,
 		LastSeen:  u.LastSeen,
+		Devices:   convertDevices(u.Devices),
 		CreatedAt: u.CreatedAt,
+
+		EmailVerified: u.EmailVerified,
+	}
+}
+
+func convertDevices(devices []sDto.DevicePresence) []hDto.DeviceView {
+	if len(devices) == 0 {
+		return nil
+	}
+
+	result := make([]hDto.DeviceView, 0, len(devices))
+	for _, d := range devices {
+		result = append(result, hDto.DeviceView{
+			Device:       d.Device,
+			AppVersion:   d.AppVersion,
+			Status:       d.Status,
+			LastActivity: d.LastActivity,
+		})
 	}
+	return result
 }
 
 func ConvertToServiceList(u *sDto.GetUserViewListResponse) *hDto.UserViewListResponse {
@@ -37,9 +57,20 @@ func ConvertToServiceList(u *sDto.GetUserViewListResponse) *hDto.UserViewListRes
 	return resp
 }
 
+func ConvertToPrivacyResponse(p *sDto.PrivacySettings) *hDto.PrivacySettingsResponse {
+	return &hDto.PrivacySettingsResponse{
+		LastSeen: p.LastSeenVisibility,
+	}
+}
+
 func ConvertToLoginResponse(g *profile.LoginResponse) *hDto.LoginResponse {
 	return &hDto.LoginResponse{
-		Token:  g.Token,
-		UserId: g.UserId,
+		Token:            g.Token,
+		UserId:           g.UserId,
+		ExpiresAt:        g.ExpiresAt,
+		RefreshToken:     g.RefreshToken,
+		RefreshExpiresAt: g.RefreshExpiresAt,
+		MfaRequired:      g.MfaRequired,
+		MfaToken:         g.MfaToken,
 	}
 }
@@ -0,0 +1,150 @@
+package keys
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const rsaKeyBits = 2048
+
+// KeyRing — ключи подписи access-токенов (RS256). Подписывает активный ключ,
+// проверяет любой ключ кольца: после ротации токены на старом ключе действуют,
+// пока его файл не удалят (не раньше, чем истечёт срок жизни токена).
+type KeyRing struct {
+	active string
+	keys   map[string]*rsa.PrivateKey
+}
+
+// JWK — открытый ключ в формате RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Load читает ключи из dir: каждый файл <kid>.pem — закрытый RSA-ключ (PKCS#1 или PKCS#8).
+// Если activeKid не задан, активным становится ключ с наибольшим kid,
+// поэтому kid удобно называть датой выпуска: 2026-10-01.pem.
+func Load(dir, activeKid string) (*KeyRing, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no signing keys in %s", dir)
+	}
+	sort.Strings(files)
+
+	ring := &KeyRing{keys: make(map[string]*rsa.PrivateKey, len(files))}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parsePrivateKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+
+		kid := strings.TrimSuffix(filepath.Base(f), ".pem")
+		ring.keys[kid] = key
+		ring.active = kid
+	}
+
+	if activeKid != "" {
+		if _, ok := ring.keys[activeKid]; !ok {
+			return nil, fmt.Errorf("active key %q not found in %s", activeKid, dir)
+		}
+		ring.active = activeKid
+	}
+
+	return ring, nil
+}
+
+// Generate создаёт кольцо из одного случайного ключа. Только для локального
+// запуска: ключ не переживает рестарт и у каждого инстанса свой.
+func Generate() (*KeyRing, error) {
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return nil, err
+	}
+
+	kid := fmt.Sprintf("ephemeral-%x", key.PublicKey.N.Bytes()[:4])
+	return &KeyRing{
+		active: kid,
+		keys:   map[string]*rsa.PrivateKey{kid: key},
+	}, nil
+}
+
+// Sign подписывает claims активным ключом и указывает его kid в заголовке.
+func (r *KeyRing) Sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = r.active
+	return token.SignedString(r.keys[r.active])
+}
+
+// PublicKey возвращает ключ проверки по kid.
+func (r *KeyRing) PublicKey(kid string) (*rsa.PublicKey, bool) {
+	key, ok := r.keys[kid]
+	if !ok {
+		return nil, false
+	}
+	return &key.PublicKey, true
+}
+
+// JWKS — открытые ключи всех ключей кольца для /.well-known/jwks.json.
+func (r *KeyRing) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(r.keys))}
+	for kid, key := range r.keys {
+		set.Keys = append(set.Keys, JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			Kid: kid,
+			N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		})
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an RSA key")
+	}
+	return rsaKey, nil
+}
@@ -0,0 +1,74 @@
+package keys
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func writeKey(t *testing.T, dir, kid string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRotationKeepsOldKeysVerifiable(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "2026-01-01")
+
+	old, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, _ := old.Sign(jwt.MapClaims{"user_id": "1"})
+
+	// ротация: новый ключ становится активным, старый остаётся в кольце
+	writeKey(t, dir, "2026-02-01")
+	ring, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newToken, _ := ring.Sign(jwt.MapClaims{"user_id": "1"})
+	for name, raw := range map[string]string{"old": oldToken, "new": newToken} {
+		_, err := jwt.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+			key, _ := ring.PublicKey(token.Header["kid"].(string))
+			return key, nil
+		})
+		if err != nil {
+			t.Fatalf("%s token must verify: %v", name, err)
+		}
+	}
+
+	parsed, _, _ := new(jwt.Parser).ParseUnverified(newToken, jwt.MapClaims{})
+	if parsed.Header["kid"] != "2026-02-01" {
+		t.Fatalf("newest key must be active, got kid %v", parsed.Header["kid"])
+	}
+
+	set := ring.JWKS()
+	if len(set.Keys) != 2 || set.Keys[0].Kid != "2026-01-01" || set.Keys[1].Alg != "RS256" {
+		t.Fatalf("unexpected jwks %+v", set)
+	}
+}
+
+func TestLoadRejectsUnknownActiveKid(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "a")
+
+	if _, err := Load(dir, "b"); err == nil {
+		t.Fatal("unknown active kid must fail")
+	}
+}
@@ -0,0 +1,126 @@
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// ChallengeTTL — сколько живёт вход, ожидающий второго фактора.
+	ChallengeTTL = 5 * time.Minute
+	// MaxChallengeAttempts — неверных кодов на один вход, после чего нужно
+	// заново ввести пароль.
+	MaxChallengeAttempts = 5
+)
+
+// Challenge — вход, прошедший проверку пароля и ожидающий TOTP-кода.
+type Challenge struct {
+	UserId    int64
+	Username  string
+	UserAgent string
+	Ip        string
+}
+
+type ChallengeStore interface {
+	// Create сохраняет вход и возвращает одноразовый токен для второго шага.
+	Create(ctx context.Context, c *Challenge) (string, error)
+	// Get возвращает вход по токену; nil, если токен неизвестен или истёк.
+	Get(ctx context.Context, token string) (*Challenge, error)
+	// Fail учитывает неверный код; false — попытки исчерпаны, токен удалён.
+	Fail(ctx context.Context, token string) (bool, error)
+	// Consume удаляет токен; false — его уже использовал параллельный запрос.
+	Consume(ctx context.Context, token string) (bool, error)
+}
+
+type redisChallengeStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisChallengeStore(rdb *redis.Client) ChallengeStore {
+	return &redisChallengeStore{rdb: rdb}
+}
+
+func (s *redisChallengeStore) Create(ctx context.Context, c *Challenge) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	key := challengeKey(token)
+	pipe := s.rdb.TxPipeline()
+	pipe.HSet(ctx, key, map[string]interface{}{
+		"user_id":    c.UserId,
+		"username":   c.Username,
+		"user_agent": c.UserAgent,
+		"ip":         c.Ip,
+		"attempts":   0,
+	})
+	pipe.Expire(ctx, key, ChallengeTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *redisChallengeStore) Get(ctx context.Context, token string) (*Challenge, error) {
+	fields, err := s.rdb.HGetAll(ctx, challengeKey(token)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	userId, err := strconv.ParseInt(fields["user_id"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("mfa: bad challenge user_id: %w", err)
+	}
+	return &Challenge{
+		UserId:    userId,
+		Username:  fields["username"],
+		UserAgent: fields["user_agent"],
+		Ip:        fields["ip"],
+	}, nil
+}
+
+func (s *redisChallengeStore) Fail(ctx context.Context, token string) (bool, error) {
+	key := challengeKey(token)
+	pipe := s.rdb.TxPipeline()
+	attempts := pipe.HIncrBy(ctx, key, "attempts", 1)
+	// если токен успел истечь, HINCRBY создал бы ключ без TTL
+	pipe.ExpireNX(ctx, key, ChallengeTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	if attempts.Val() < MaxChallengeAttempts {
+		return true, nil
+	}
+	if err := s.rdb.Del(ctx, key).Err(); err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+	return false, nil
+}
+
+func (s *redisChallengeStore) Consume(ctx context.Context, token string) (bool, error) {
+	n, err := s.rdb.Del(ctx, challengeKey(token)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// в Redis лежит только хэш токена, как и для refresh-токенов в БД
+func challengeKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return fmt.Sprintf("auth:mfa:challenge:%s", hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,80 @@
+package mfa
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// KeySize — длина ключа AES-256.
+const KeySize = 32
+
+var errCiphertextTooShort = errors.New("mfa: ciphertext too short")
+
+// Cipher шифрует TOTP-секреты перед записью в БД (AES-256-GCM). id
+// пользователя идёт в additional data: секрет, переписанный в чужую
+// строку, не расшифруется.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("mfa: key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// ParseKey разбирает ключ из MFA_ENCRYPTION_KEY (base64, 32 байта).
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("mfa: decode key: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("mfa: key must be %d bytes, got %d", KeySize, len(key))
+	}
+	return key, nil
+}
+
+// GenerateKey — случайный ключ, например для тестов.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Seal возвращает nonce || ciphertext.
+func (c *Cipher) Seal(userId int64, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, additionalData(userId)), nil
+}
+
+func (c *Cipher) Open(userId int64, sealed []byte) ([]byte, error) {
+	n := c.aead.NonceSize()
+	if len(sealed) < n {
+		return nil, errCiphertextTooShort
+	}
+	return c.aead.Open(nil, sealed[:n], sealed[n:], additionalData(userId))
+}
+
+func additionalData(userId int64) []byte {
+	return []byte("user_mfa:" + strconv.FormatInt(userId, 10))
+}
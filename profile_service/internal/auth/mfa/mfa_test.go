@@ -0,0 +1,76 @@
+package mfa
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestCipherBindsSecretToUser(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := c.Seal(1, []byte("JBSWY3DPEHPK3PXP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := c.Open(1, sealed)
+	if err != nil || string(plain) != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("roundtrip failed: %q %v", plain, err)
+	}
+	if _, err := c.Open(2, sealed); err == nil {
+		t.Fatal("secret of user 1 must not open for user 2")
+	}
+}
+
+func TestChallengeAttemptsAndConsume(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	s := NewRedisChallengeStore(rdb)
+	ctx := context.Background()
+
+	token, err := s.Create(ctx, &Challenge{UserId: 7, Username: "alice", Ip: "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.Get(ctx, token)
+	if err != nil || c == nil || c.UserId != 7 || c.Username != "alice" {
+		t.Fatalf("unexpected challenge %+v %v", c, err)
+	}
+
+	for i := 1; i < MaxChallengeAttempts; i++ {
+		if ok, _ := s.Fail(ctx, token); !ok {
+			t.Fatalf("attempt %d must be allowed", i)
+		}
+	}
+	if ok, _ := s.Fail(ctx, token); ok {
+		t.Fatal("attempts must be exhausted")
+	}
+	if c, _ := s.Get(ctx, token); c != nil {
+		t.Fatal("exhausted challenge must be deleted")
+	}
+
+	token, _ = s.Create(ctx, &Challenge{UserId: 7})
+	if ok, _ := s.Consume(ctx, token); !ok {
+		t.Fatal("first consume must succeed")
+	}
+	if ok, _ := s.Consume(ctx, token); ok {
+		t.Fatal("challenge must be single-use")
+	}
+
+	token, _ = s.Create(ctx, &Challenge{UserId: 7})
+	mr.FastForward(ChallengeTTL + time.Second)
+	if c, _ := s.Get(ctx, token); c != nil {
+		t.Fatal("challenge must expire")
+	}
+}
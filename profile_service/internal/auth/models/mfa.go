@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// UserMfa — TOTP-секрет пользователя, зашифрованный на уровне приложения.
+// EnabledAt == nil — подключение начато, но ещё не подтверждено кодом.
+type UserMfa struct {
+	UserId       int64      `gorm:"primaryKey;column:user_id"`
+	SecretEnc    []byte     `gorm:"column:secret_enc;type:bytea;not null"`
+	EnabledAt    *time.Time `gorm:"column:enabled_at;type:timestamp with time zone"`
+	LastUsedStep int64      `gorm:"column:last_used_step;not null;default:0"`
+	CreatedAt    time.Time  `gorm:"column:created_at;type:timestamp with time zone;default:now()"`
+}
+
+func (UserMfa) TableName() string {
+	return "user_mfa"
+}
+
+// RecoveryCode — одноразовый код восстановления; хранится только SHA-256.
+type RecoveryCode struct {
+	Id        int64      `gorm:"primaryKey;autoIncrement;column:id"`
+	UserId    int64      `gorm:"column:user_id;not null;index:idx_user_recovery_codes_user"`
+	CodeHash  string     `gorm:"column:code_hash;type:char(64);not null"`
+	UsedAt    *time.Time `gorm:"column:used_at;type:timestamp with time zone"`
+	CreatedAt time.Time  `gorm:"column:created_at;type:timestamp with time zone;default:now()"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Session — вход пользователя с одного устройства. Все refresh-токены,
+// полученные цепочкой ротаций от одного Login, принадлежат одной сессии.
+type Session struct {
+	Id         int64      `gorm:"primaryKey;autoIncrement;column:id"`
+	UserId     int64      `gorm:"column:user_id;not null;index:idx_auth_sessions_user"`
+	UserAgent  string     `gorm:"column:user_agent;type:text;not null;default:''"`
+	Ip         string     `gorm:"column:ip;type:varchar(64);not null;default:''"`
+	CreatedAt  time.Time  `gorm:"column:created_at;type:timestamp with time zone;default:now()"`
+	LastUsedAt time.Time  `gorm:"column:last_used_at;type:timestamp with time zone;default:now()"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at;type:timestamp with time zone"`
+}
+
+func (Session) TableName() string {
+	return "auth_sessions"
+}
+
+// RefreshToken хранит только SHA-256 токена. UsedAt проставляется при ротации:
+// повторное предъявление такого токена означает утечку.
+type RefreshToken struct {
+	Id        int64      `gorm:"primaryKey;autoIncrement;column:id"`
+	SessionId int64      `gorm:"column:session_id;not null;index:idx_refresh_tokens_session"`
+	TokenHash string     `gorm:"column:token_hash;type:char(64);not null;uniqueIndex:idx_refresh_tokens_hash"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;type:timestamp with time zone;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at;type:timestamp with time zone"`
+	CreatedAt time.Time  `gorm:"column:created_at;type:timestamp with time zone;default:now()"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// UserIdentity — аккаунт пользователя у внешнего OIDC-провайдера.
+type UserIdentity struct {
+	Id        int64     `gorm:"primaryKey;autoIncrement;column:id"`
+	UserId    int64     `gorm:"column:user_id;not null;index:idx_user_identities_user"`
+	Provider  string    `gorm:"column:provider;type:varchar(64);not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject   string    `gorm:"column:subject;type:text;not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Email     string    `gorm:"column:email;type:text;not null;default:''"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamp with time zone;default:now()"`
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
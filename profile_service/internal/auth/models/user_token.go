@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposePasswordReset = "password_reset"
+	TokenPurposeChangeEmail   = "change_email"
+)
+
+// UserToken — одноразовый токен из письма. Хранится только SHA-256;
+// Email фиксирует адрес, который подтверждает токен верификации или смены email.
+type UserToken struct {
+	Id        int64      `gorm:"primaryKey;autoIncrement;column:id"`
+	UserId    int64      `gorm:"column:user_id;not null;index:idx_user_tokens_user_purpose"`
+	Purpose   string     `gorm:"column:purpose;type:varchar(32);not null;index:idx_user_tokens_user_purpose"`
+	TokenHash string     `gorm:"column:token_hash;type:char(64);not null;uniqueIndex:idx_user_tokens_hash"`
+	Email     string     `gorm:"column:email;type:text;not null;default:''"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;type:timestamp with time zone;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at;type:timestamp with time zone"`
+	CreatedAt time.Time  `gorm:"column:created_at;type:timestamp with time zone;default:now()"`
+}
+
+func (UserToken) TableName() string {
+	return "user_tokens"
+}
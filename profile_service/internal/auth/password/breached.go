@@ -0,0 +1,58 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PrefixLen — длина префикса SHA-1 в k-anonymity запросе, как в range API Have I Been Pwned.
+const PrefixLen = 5
+
+// BreachedList отдаёт суффиксы SHA-1 утёкших паролей с заданным префиксом.
+// Интерфейс повторяет range API HIBP, так что локальный файл можно заменить
+// на удалённый сервис без изменений в политике.
+type BreachedList interface {
+	Range(ctx context.Context, prefix string) ([]string, error)
+}
+
+type fileBreachedList struct {
+	ranges map[string][]string
+}
+
+// LoadBreachedList читает файл в формате выгрузки HIBP: по строке "SHA1HEX[:count]".
+// Пустые строки и строки с # пропускаются.
+func LoadBreachedList(path string) (BreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	list := &fileBreachedList{ranges: make(map[string][]string)}
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(text, ":")
+		if len(hash) != 40 {
+			return nil, fmt.Errorf("%s:%d: expected SHA-1 hex hash", path, line)
+		}
+		hash = strings.ToUpper(hash)
+		list.ranges[hash[:PrefixLen]] = append(list.ranges[hash[:PrefixLen]], hash[PrefixLen:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (l *fileBreachedList) Range(ctx context.Context, prefix string) ([]string, error) {
+	return l.ranges[strings.ToUpper(prefix)], nil
+}
@@ -0,0 +1,117 @@
+package password
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var cheap = Params{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func TestHasherVerifiesArgon2id(t *testing.T) {
+	h := NewHasher(cheap)
+	hash, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("unexpected hash format %q", hash)
+	}
+
+	if ok, rehash, err := h.Verify(hash, "correct horse"); !ok || rehash || err != nil {
+		t.Fatalf("expected valid hash without rehash, got %v %v %v", ok, rehash, err)
+	}
+	if ok, _, _ := h.Verify(hash, "wrong horse"); ok {
+		t.Fatal("wrong password accepted")
+	}
+
+	// параметры подняли — старый хэш по-прежнему подходит, но его пора пересчитать
+	stronger := NewHasher(Params{Memory: 128, Iterations: 1, Parallelism: 1})
+	if ok, rehash, _ := stronger.Verify(hash, "correct horse"); !ok || !rehash {
+		t.Fatalf("expected rehash after params change, got %v %v", ok, rehash)
+	}
+
+	if _, _, err := h.Verify("plain-text", "plain-text"); !errors.Is(err, ErrUnknownHash) {
+		t.Fatalf("expected unknown hash, got %v", err)
+	}
+}
+
+func TestHasherAcceptsLegacyBcrypt(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("old secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHasher(cheap)
+
+	if ok, rehash, err := h.Verify(string(legacy), "old secret"); !ok || !rehash || err != nil {
+		t.Fatalf("bcrypt hash must verify and ask for rehash, got %v %v %v", ok, rehash, err)
+	}
+	if ok, rehash, _ := h.Verify(string(legacy), "other"); ok || rehash {
+		t.Fatal("wrong password accepted")
+	}
+}
+
+func writeBreached(t *testing.T, passwords ...string) string {
+	var b strings.Builder
+	b.WriteString("# test list\n")
+	for _, p := range passwords {
+		sum := sha1.Sum([]byte(p))
+		b.WriteString(strings.ToUpper(hex.EncodeToString(sum[:])) + ":42\n")
+	}
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPolicy(t *testing.T) {
+	breached, err := LoadBreachedList(writeBreached(t, "password123", "qwertyuiop"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := DefaultPolicy
+	p.Breached = breached
+	ctx := context.Background()
+
+	cases := []struct {
+		password string
+		want     error
+	}{
+		{"short", ErrTooShort},
+		{strings.Repeat("x", 129), ErrTooLong},
+		{"password123", ErrBreached},
+		{"QwertyUiop", nil}, // SHA-1 считается от пароля как есть
+		{"alice-rocks", ErrSimilar},
+		{"skcor-ecila", ErrSimilar},
+		{"al1ce.smith", ErrSimilar},
+		{"Alice.Smith", ErrSimilar},
+		{"mountain river 7", nil},
+	}
+	for _, c := range cases {
+		err := p.Validate(ctx, c.password, "alice.smith", "alice@example.com")
+		if !errors.Is(err, c.want) || (c.want == nil) != (err == nil) {
+			t.Errorf("%q: expected %v, got %v", c.password, c.want, err)
+		}
+		if c.want != nil && !errors.Is(err, ErrWeakPassword) {
+			t.Errorf("%q: policy errors must wrap ErrWeakPassword", c.password)
+		}
+	}
+}
+
+func TestLoadBreachedListRejectsGarbage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.txt")
+	if err := os.WriteFile(path, []byte("not-a-hash\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBreachedList(path); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
@@ -0,0 +1,130 @@
+package password
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrWeakPassword = errors.New("password does not meet the policy")
+	ErrTooShort     = fmt.Errorf("%w: too short", ErrWeakPassword)
+	ErrTooLong      = fmt.Errorf("%w: too long", ErrWeakPassword)
+	ErrBreached     = fmt.Errorf("%w: found in a list of leaked passwords", ErrWeakPassword)
+	ErrSimilar      = fmt.Errorf("%w: too similar to the username or email", ErrWeakPassword)
+)
+
+// minHintLen — слишком короткие имена не проверяются на сходство:
+// иначе "ann" запретило бы половину паролей.
+const minHintLen = 3
+
+type Policy struct {
+	MinLength int
+	MaxLength int
+	// RejectSimilar — запрещать пароли, содержащие имя пользователя или email
+	RejectSimilar bool
+	// Breached — список утёкших паролей; nil отключает проверку
+	Breached BreachedList
+}
+
+var DefaultPolicy = Policy{MinLength: 8, MaxLength: 128, RejectSimilar: true}
+
+// Validate проверяет новый пароль. related — имя пользователя, email и т.п.,
+// на которые пароль не должен быть похож.
+func (p *Policy) Validate(ctx context.Context, password string, related ...string) error {
+	n := utf8.RuneCountInString(password)
+	if n < p.MinLength {
+		return fmt.Errorf("%w (minimum %d characters)", ErrTooShort, p.MinLength)
+	}
+	if p.MaxLength > 0 && n > p.MaxLength {
+		return fmt.Errorf("%w (maximum %d characters)", ErrTooLong, p.MaxLength)
+	}
+
+	if p.RejectSimilar && similar(password, related) {
+		return ErrSimilar
+	}
+
+	if p.Breached != nil {
+		breached, err := isBreached(ctx, p.Breached, password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			return ErrBreached
+		}
+	}
+	return nil
+}
+
+// isBreached ищет SHA-1 пароля по k-anonymity схеме: список отдаёт суффиксы
+// хэшей по первым пяти символам, сам пароль и полный хэш никуда не передаются.
+func isBreached(ctx context.Context, list BreachedList, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := list.Range(ctx, hash[:PrefixLen])
+	if err != nil {
+		return false, fmt.Errorf("breached password lookup: %w", err)
+	}
+	for _, suffix := range suffixes {
+		if suffix == hash[PrefixLen:] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func similar(password string, related []string) bool {
+	pw := strings.ToLower(password)
+	for _, hint := range related {
+		hint = strings.ToLower(hint)
+		// у email сравнивается только имя ящика
+		if at := strings.IndexByte(hint, '@'); at >= 0 {
+			hint = hint[:at]
+		}
+		if utf8.RuneCountInString(hint) < minHintLen {
+			continue
+		}
+		if strings.Contains(pw, hint) || strings.Contains(pw, reverse(hint)) || strings.Contains(hint, pw) {
+			return true
+		}
+		// "a1ice" вместо "alice": отличие в пару символов не делает пароль другим
+		if levenshtein(pw, hint) <= utf8.RuneCountInString(hint)/4 {
+			return true
+		}
+	}
+	return false
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"profile_service/internal/auth/models"
+	userModels "profile_service/internal/user/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrIdentityTaken — внешний аккаунт уже привязан к другому пользователю
+// (параллельный первый вход) или email/имя заняты при создании пользователя.
+var ErrIdentityTaken = errors.New("identity or user already exists")
+
+type IdentityRepositoryInterface interface {
+	// Get возвращает привязку; nil, если внешний аккаунт ещё не входил.
+	Get(ctx context.Context, provider, subject string) (*models.UserIdentity, error)
+	Link(ctx context.Context, identity *models.UserIdentity) error
+	// CreateUser заводит пользователя и сразу привязывает к нему внешний аккаунт.
+	CreateUser(ctx context.Context, user *userModels.User, identity *models.UserIdentity) error
+}
+
+type IdentityRepo struct {
+	db  *gorm.DB
+	log *logrus.Entry
+}
+
+func NewIdentityRepository(db *gorm.DB, log *logrus.Entry) IdentityRepositoryInterface {
+	return &IdentityRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *IdentityRepo) Get(ctx context.Context, provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.WithContext(ctx).
+		Joins("JOIN users ON users.id = user_identities.user_id AND users.deleted_at IS NULL").
+		Where("user_identities.provider = ? AND user_identities.subject = ?", provider, subject).
+		First(&identity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		r.log.WithFields(logrus.Fields{"error": err, "provider": provider}).Error("Failed to get identity")
+		return nil, fmt.Errorf("get identity error: %w", err)
+	}
+	return &identity, nil
+}
+
+func (r *IdentityRepo) Link(ctx context.Context, identity *models.UserIdentity) error {
+	if err := r.db.WithContext(ctx).Create(identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrIdentityTaken
+		}
+		r.log.WithFields(logrus.Fields{"error": err, "user_id": identity.UserId}).Error("Failed to link identity")
+		return fmt.Errorf("link identity error: %w", err)
+	}
+	return nil
+}
+
+func (r *IdentityRepo) CreateUser(ctx context.Context, user *userModels.User, identity *models.UserIdentity) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserId = user.Id
+		return tx.Create(identity).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrIdentityTaken
+		}
+		r.log.WithFields(logrus.Fields{"error": err, "provider": identity.Provider}).Error("Failed to create user from identity")
+		return fmt.Errorf("create user from identity error: %w", err)
+	}
+	return nil
+}
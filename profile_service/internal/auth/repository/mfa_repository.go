@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"profile_service/internal/auth/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MfaRepositoryInterface interface {
+	// Get возвращает настройки 2FA пользователя; nil, если подключение не начиналось.
+	Get(ctx context.Context, userId int64) (*models.UserMfa, error)
+	SavePending(ctx context.Context, userId int64, secretEnc []byte) error
+	Enable(ctx context.Context, userId int64, step int64, codeHashes []string) error
+	Disable(ctx context.Context, userId int64) error
+	AdvanceStep(ctx context.Context, userId int64, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userId int64, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userId int64) (int64, error)
+	// CountEnabled — сколько пользователей уже включили 2FA.
+	CountEnabled(ctx context.Context) (int64, error)
+}
+
+type MfaRepo struct {
+	db  *gorm.DB
+	log *logrus.Entry
+}
+
+func NewMfaRepository(db *gorm.DB, log *logrus.Entry) MfaRepositoryInterface {
+	return &MfaRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r *MfaRepo) Get(ctx context.Context, userId int64) (*models.UserMfa, error) {
+	var mfa models.UserMfa
+	err := r.db.WithContext(ctx).Where("user_id = ?", userId).First(&mfa).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		r.log.WithFields(logrus.Fields{"error": err, "user_id": userId}).Error("Failed to get mfa settings")
+		return nil, fmt.Errorf("get mfa settings error: %w", err)
+	}
+	return &mfa, nil
+}
+
+// SavePending начинает подключение заново: новый секрет ещё не действует при входе.
+// Уже включённую 2FA так не перезаписать — сервис сначала требует отключения.
+func (r *MfaRepo) SavePending(ctx context.Context, userId int64, secretEnc []byte) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"secret_enc": secretEnc, "last_used_step": 0, "created_at": time.Now().UTC()}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "user_mfa.enabled_at IS NULL"}}},
+	}).Create(&models.UserMfa{UserId: userId, SecretEnc: secretEnc}).Error
+	if err != nil {
+		r.log.WithFields(logrus.Fields{"error": err, "user_id": userId}).Error("Failed to save mfa secret")
+		return fmt.Errorf("save mfa secret error: %w", err)
+	}
+	return nil
+}
+
+// Enable включает 2FA и заменяет коды восстановления. step — шаг кода,
+// которым подтвердили подключение: повторно его при входе не принять.
+func (r *MfaRepo) Enable(ctx context.Context, userId int64, step int64, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.UserMfa{}).
+			Where("user_id = ? AND enabled_at IS NULL", userId).
+			Updates(map[string]interface{}{"enabled_at": time.Now().UTC(), "last_used_step": step})
+		if res.Error != nil {
+			r.log.WithFields(logrus.Fields{"error": res.Error, "user_id": userId}).Error("Failed to enable mfa")
+			return fmt.Errorf("enable mfa error: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Where("user_id = ?", userId).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return fmt.Errorf("delete recovery codes error: %w", err)
+		}
+		codes := make([]models.RecoveryCode, 0, len(codeHashes))
+		for _, h := range codeHashes {
+			codes = append(codes, models.RecoveryCode{UserId: userId, CodeHash: h})
+		}
+		if err := tx.Create(&codes).Error; err != nil {
+			r.log.WithFields(logrus.Fields{"error": err, "user_id": userId}).Error("Failed to save recovery codes")
+			return fmt.Errorf("save recovery codes error: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *MfaRepo) Disable(ctx context.Context, userId int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&models.RecoveryCode{}).Error; err != nil {
+			r.log.WithFields(logrus.Fields{"error": err, "user_id": userId}).Error("Failed to delete recovery codes")
+			return fmt.Errorf("delete recovery codes error: %w", err)
+		}
+		if err := tx.Where("user_id = ?", userId).Delete(&models.UserMfa{}).Error; err != nil {
+			r.log.WithFields(logrus.Fields{"error": err, "user_id": userId}).Error("Failed to disable mfa")
+			return fmt.Errorf("disable mfa error: %w", err)
+		}
+		return nil
+	})
+}
+
+// AdvanceStep запоминает принятый шаг TOTP. false — код этого или более
+// позднего шага уже использован (повтор перехваченного кода).
+func (r *MfaRepo) AdvanceStep(ctx context.Context, userId int64, step int64) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&models.UserMfa{}).
+		Where("user_id = ? AND last_used_step < ?", userId, step).
+		Update("last_used_step", step)
+	if res.Error != nil {
+		r.log.WithFields(logrus.Fields{"error": res.Error, "user_id": userId}).Error("Failed to update mfa step")
+		return false, fmt.Errorf("update mfa step error: %w", res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// UseRecoveryCode гасит код восстановления; false — кода нет или он уже использован.
+func (r *MfaRepo) UseRecoveryCode(ctx context.Context, userId int64, codeHash string) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userId, codeHash).
+		Update("used_at", time.Now().UTC())
+	if res.Error != nil {
+		r.log.WithFields(logrus.Fields{"error": res.Error, "user_id": userId}).Error("Failed to use recovery code")
+		return false, fmt.Errorf("use recovery code error: %w", res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *MfaRepo) CountRecoveryCodes(ctx context.Context, userId int64) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).
+		Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userId).
+		Count(&n).Error
+	if err != nil {
+		return 0, fmt.Errorf("count recovery codes error: %w", err)
+	}
+	return n, nil
+}
+
+func (r *MfaRepo) CountEnabled(ctx context.Context) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).
+		Model(&models.UserMfa{}).
+		Where("enabled_at IS NOT NULL").
+		Count(&n).Error
+	if err != nil {
+		r.log.WithField("error", err).Error("Failed to count enabled mfa")
+		return 0, fmt.Errorf("count enabled mfa error: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"profile_service/internal/auth/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTokenNotFound    = errors.New("refresh token not found")
+	ErrTokenAlreadyUsed = errors.New("refresh token already used")
+	ErrSessionNotFound  = errors.New("session not found")
+)
+
+type SessionRepositoryInterface interface {
+	Create(ctx context.Context, session *models.Session, token *models.RefreshToken) error
+	GetToken(ctx context.Context, tokenHash string) (*models.RefreshToken, *models.Session, error)
+	Rotate(ctx context.Context, used *models.RefreshToken, next *models.RefreshToken) error
+	Revoke(ctx context.Context, sessionId int64) error
+	RevokeAllForUser(ctx context.Context, userId int64, exceptSessionId int64) (int64, error)
+	ListActive(ctx context.Context, userId int64) ([]models.Session, error)
+	RevokeForUser(ctx context.Context, userId int64, sessionId int64) error
+}
+
+type SessionRepo struct {
+	db  *gorm.DB
+	log *logrus.Entry
+}
+
+func NewSessionRepository(db *gorm.DB, log *logrus.Entry) SessionRepositoryInterface {
+	return &SessionRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Create сохраняет новую сессию вместе с первым refresh-токеном.
+func (r *SessionRepo) Create(ctx context.Context, session *models.Session, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			r.log.WithFields(logrus.Fields{"error": err, "user_id": session.UserId}).Error("Failed to create session")
+			return fmt.Errorf("create session error: %w", err)
+		}
+
+		token.SessionId = session.Id
+		if err := tx.Create(token).Error; err != nil {
+			r.log.WithFields(logrus.Fields{"error": err, "session_id": session.Id}).Error("Failed to create refresh token")
+			return fmt.Errorf("create refresh token error: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// GetToken находит токен по хэшу вместе с сессией. Токены удалённых
+// пользователей не находятся: users удаляются мягко, и каскад не срабатывает.
+func (r *SessionRepo) GetToken(ctx context.Context, tokenHash string) (*models.RefreshToken, *models.Session, error) {
+	var token models.RefreshToken
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ?", tokenHash).
+		First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrTokenNotFound
+		}
+		r.log.WithFields(logrus.Fields{"error": err}).Error("Failed to get refresh token")
+		return nil, nil, fmt.Errorf("get refresh token error: %w", err)
+	}
+
+	var session models.Session
+	err = r.db.WithContext(ctx).
+		Joins("JOIN users ON users.id = auth_sessions.user_id AND users.deleted_at IS NULL").
+		Where("auth_sessions.id = ?", token.SessionId).
+		First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrTokenNotFound
+		}
+		r.log.WithFields(logrus.Fields{"error": err, "session_id": token.SessionId}).Error("Failed to get session")
+		return nil, nil, fmt.Errorf("get session error: %w", err)
+	}
+
+	return &token, &session, nil
+}
+
+// Rotate помечает used использованным и сохраняет next. Если used уже
+// использован параллельным запросом, возвращает ErrTokenAlreadyUsed.
+func (r *SessionRepo) Rotate(ctx context.Context, used *models.RefreshToken, next *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+
+		res := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", used.Id).
+			Update("used_at", now)
+		if res.Error != nil {
+			r.log.WithFields(logrus.Fields{"error": res.Error, "token_id": used.Id}).Error("Failed to mark refresh token used")
+			return fmt.Errorf("mark refresh token used error: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return ErrTokenAlreadyUsed
+		}
+
+		next.SessionId = used.SessionId
+		if err := tx.Create(next).Error; err != nil {
+			r.log.WithFields(logrus.Fields{"error": err, "session_id": used.SessionId}).Error("Failed to create refresh token")
+			return fmt.Errorf("create refresh token error: %w", err)
+		}
+
+		if err := tx.Model(&models.Session{}).
+			Where("id = ?", used.SessionId).
+			Update("last_used_at", now).Error; err != nil {
+			return fmt.Errorf("update session error: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// Revoke отзывает сессию целиком: все её refresh-токены перестают действовать.
+func (r *SessionRepo) Revoke(ctx context.Context, sessionId int64) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionId).
+		Update("revoked_at", time.Now().UTC()).Error
+	if err != nil {
+		r.log.WithFields(logrus.Fields{"error": err, "session_id": sessionId}).Error("Failed to revoke session")
+		return fmt.Errorf("revoke session error: %w", err)
+	}
+	return nil
+}
+
+// RevokeAllForUser отзывает все активные сессии пользователя, кроме exceptSessionId,
+// и возвращает их число.
+func (r *SessionRepo) RevokeAllForUser(ctx context.Context, userId int64, exceptSessionId int64) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userId, exceptSessionId).
+		Update("revoked_at", time.Now().UTC())
+	if res.Error != nil {
+		r.log.WithFields(logrus.Fields{"error": res.Error, "user_id": userId}).Error("Failed to revoke user sessions")
+		return 0, fmt.Errorf("revoke user sessions error: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+// ListActive возвращает сессии, которые ещё можно продолжить: не отозваны
+// и имеют неиспользованный refresh-токен с неистёкшим сроком.
+func (r *SessionRepo) ListActive(ctx context.Context, userId int64) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Where("EXISTS (SELECT 1 FROM refresh_tokens t WHERE t.session_id = auth_sessions.id AND t.used_at IS NULL AND t.expires_at > ?)", time.Now().UTC()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		r.log.WithFields(logrus.Fields{"error": err, "user_id": userId}).Error("Failed to list sessions")
+		return nil, fmt.Errorf("list sessions error: %w", err)
+	}
+	return sessions, nil
+}
+
+// RevokeForUser отзывает сессию, только если она принадлежит userId;
+// чужая, несуществующая и уже отозванная сессия — ErrSessionNotFound.
+func (r *SessionRepo) RevokeForUser(ctx context.Context, userId int64, sessionId int64) error {
+	res := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionId, userId).
+		Update("revoked_at", time.Now().UTC())
+	if res.Error != nil {
+		r.log.WithFields(logrus.Fields{"error": res.Error, "session_id": sessionId}).Error("Failed to revoke session")
+		return fmt.Errorf("revoke session error: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
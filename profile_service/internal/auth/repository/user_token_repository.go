@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"profile_service/internal/auth/models"
+	userModels "profile_service/internal/user/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrUserTokenInvalid — токена нет, он истёк, уже использован или вытеснен более новым.
+var ErrUserTokenInvalid = errors.New("user token is invalid or expired")
+
+// ErrEmailTaken — новый адрес успели занять, пока письмо шло.
+var ErrEmailTaken = errors.New("email already taken")
+
+type UserTokenRepositoryInterface interface {
+	Create(ctx context.Context, token *models.UserToken) error
+	VerifyEmail(ctx context.Context, tokenHash string) (int64, error)
+	ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (int64, error)
+	ChangeEmail(ctx context.Context, tokenHash string, userId int64) (string, error)
+}
+
+type UserTokenRepo struct {
+	db  *gorm.DB
+	log *logrus.Entry
+}
+
+func NewUserTokenRepository(db *gorm.DB, log *logrus.Entry) UserTokenRepositoryInterface {
+	return &UserTokenRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Create сохраняет токен; ранее выданные неиспользованные токены того же
+// назначения гасятся, так что действует только ссылка из последнего письма.
+func (r *UserTokenRepo) Create(ctx context.Context, token *models.UserToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.invalidate(tx, token.UserId, token.Purpose); err != nil {
+			return err
+		}
+		if err := tx.Create(token).Error; err != nil {
+			r.log.WithFields(logrus.Fields{"error": err, "user_id": token.UserId}).Error("Failed to create user token")
+			return fmt.Errorf("create user token error: %w", err)
+		}
+		return nil
+	})
+}
+
+// VerifyEmail гасит токен и отмечает email подтверждённым. Если пользователь
+// успел сменить адрес, токен к новому адресу не подходит.
+func (r *UserTokenRepo) VerifyEmail(ctx context.Context, tokenHash string) (int64, error) {
+	var userId int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		token, err := r.consume(tx, tokenHash, models.TokenPurposeVerifyEmail)
+		if err != nil {
+			return err
+		}
+
+		res := tx.Model(&userModels.User{}).
+			Where("id = ? AND email = ?", token.UserId, token.Email).
+			Updates(map[string]interface{}{
+				"email_verified_at": time.Now().UTC(),
+				"email_verified_by": userModels.EmailVerifiedLink,
+			})
+		if res.Error != nil {
+			r.log.WithFields(logrus.Fields{"error": res.Error, "user_id": token.UserId}).Error("Failed to verify email")
+			return fmt.Errorf("verify email error: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return ErrUserTokenInvalid
+		}
+
+		userId = token.UserId
+		return nil
+	})
+	return userId, err
+}
+
+// ResetPassword гасит токен и сохраняет новый хэш пароля.
+func (r *UserTokenRepo) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (int64, error) {
+	var userId int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		token, err := r.consume(tx, tokenHash, models.TokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		res := tx.Model(&userModels.User{}).
+			Where("id = ?", token.UserId).
+			Update("password", passwordHash)
+		if res.Error != nil {
+			r.log.WithFields(logrus.Fields{"error": res.Error, "user_id": token.UserId}).Error("Failed to reset password")
+			return fmt.Errorf("reset password error: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return ErrUserTokenInvalid
+		}
+
+		userId = token.UserId
+		return nil
+	})
+	return userId, err
+}
+
+// ChangeEmail гасит токен смены email пользователя userId и переносит на аккаунт
+// подтверждённый новый адрес. Возвращает новый адрес.
+func (r *UserTokenRepo) ChangeEmail(ctx context.Context, tokenHash string, userId int64) (string, error) {
+	var email string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		token, err := r.consume(tx, tokenHash, models.TokenPurposeChangeEmail)
+		if err != nil {
+			return err
+		}
+		if token.UserId != userId {
+			return ErrUserTokenInvalid
+		}
+
+		res := tx.Model(&userModels.User{}).
+			Where("id = ?", token.UserId).
+			Updates(map[string]interface{}{
+				"email":             token.Email,
+				"email_verified_at": time.Now().UTC(),
+				"email_verified_by": userModels.EmailVerifiedLink,
+			})
+		if errors.Is(res.Error, gorm.ErrDuplicatedKey) {
+			return ErrEmailTaken
+		}
+		if res.Error != nil {
+			r.log.WithFields(logrus.Fields{"error": res.Error, "user_id": token.UserId}).Error("Failed to change email")
+			return fmt.Errorf("change email error: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return ErrUserTokenInvalid
+		}
+
+		email = token.Email
+		return nil
+	})
+	return email, err
+}
+
+// consume атомарно помечает токен использованным: из двух параллельных
+// запросов с одним токеном успешен только один.
+func (r *UserTokenRepo) consume(tx *gorm.DB, tokenHash string, purpose string) (*models.UserToken, error) {
+	now := time.Now().UTC()
+
+	var token models.UserToken
+	res := tx.Model(&token).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", tokenHash, purpose, now).
+		Update("used_at", now)
+	if res.Error != nil {
+		r.log.WithFields(logrus.Fields{"error": res.Error, "purpose": purpose}).Error("Failed to consume user token")
+		return nil, fmt.Errorf("consume user token error: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrUserTokenInvalid
+	}
+	return &token, nil
+}
+
+func (r *UserTokenRepo) invalidate(tx *gorm.DB, userId int64, purpose string) error {
+	err := tx.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userId, purpose).
+		Update("used_at", time.Now().UTC()).Error
+	if err != nil {
+		r.log.WithFields(logrus.Fields{"error": err, "user_id": userId}).Error("Failed to invalidate user tokens")
+		return fmt.Errorf("invalidate user tokens error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package revocation
+
+import (
+	"context"
+	"errors"
+	"shared-auth/revoked"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store — отзыв access-токенов до истечения exp. Записи живут не дольше
+// самих токенов: после exp токен отвергается и без списка.
+type Store interface {
+	// Revoke отзывает один токен по jti до момента его истечения.
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	// RevokeAllBefore делает недействительными все токены пользователя,
+	// выпущенные не позже issuedBefore (с точностью до миллисекунды);
+	// ttl — срок жизни access-токена.
+	RevokeAllBefore(ctx context.Context, userId int64, issuedBefore time.Time, ttl time.Duration) error
+	// RevokeSession отзывает все токены одной сессии входа (claim sid);
+	// ttl — срок жизни access-токена: новых токенов отозванная сессия не получит.
+	RevokeSession(ctx context.Context, sessionId int64, ttl time.Duration) error
+	// IsRevoked проверяет токен по всем признакам за один запрос к Redis;
+	// sessionId 0 — токен без sid.
+	IsRevoked(ctx context.Context, userId int64, sessionId int64, jti string, issuedAt time.Time) (bool, error)
+}
+
+type redisStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisStore(rdb *redis.Client) Store {
+	return &redisStore{rdb: rdb}
+}
+
+func (s *redisStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	return s.rdb.Set(ctx, revoked.JtiKey(jti), 1, ttl).Err()
+}
+
+func (s *redisStore) RevokeAllBefore(ctx context.Context, userId int64, issuedBefore time.Time, ttl time.Duration) error {
+	return s.rdb.Set(ctx, revoked.UserKey(userId), revoked.Watermark(issuedBefore), ttl).Err()
+}
+
+func (s *redisStore) RevokeSession(ctx context.Context, sessionId int64, ttl time.Duration) error {
+	return s.rdb.Set(ctx, revoked.SessionKey(sessionId), 1, ttl).Err()
+}
+
+func (s *redisStore) IsRevoked(ctx context.Context, userId int64, sessionId int64, jti string, issuedAt time.Time) (bool, error) {
+	pipe := s.rdb.Pipeline()
+	// у токенов, выпущенных до появления jti и sid, проверяется только watermark
+	var keys []string
+	if jti != "" {
+		keys = append(keys, revoked.JtiKey(jti))
+	}
+	if sessionId != 0 {
+		keys = append(keys, revoked.SessionKey(sessionId))
+	}
+	var listed *redis.IntCmd
+	if len(keys) > 0 {
+		listed = pipe.Exists(ctx, keys...)
+	}
+	watermark := pipe.Get(ctx, revoked.UserKey(userId))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	if listed != nil && listed.Val() > 0 {
+		return true, nil
+	}
+
+	before, err := watermark.Int64()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return revoked.IssuedBefore(issuedAt, before), nil
+}
@@ -0,0 +1,99 @@
+package revocation
+
+import (
+	"context"
+	"shared-auth/revoked"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestStore(t *testing.T) (Store, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	return NewRedisStore(rdb), mr
+}
+
+func TestRevokeSingleToken(t *testing.T) {
+	s, mr := newTestStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	if err := s.Revoke(ctx, "a", now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if revoked, err := s.IsRevoked(ctx, 1, 0, "a", now); err != nil || !revoked {
+		t.Fatalf("token a must be revoked: %v %v", revoked, err)
+	}
+	if revoked, _ := s.IsRevoked(ctx, 1, 0, "b", now); revoked {
+		t.Fatal("token b must stay valid")
+	}
+
+	// запись не переживает сам токен
+	mr.FastForward(time.Minute)
+	if revoked, _ := s.IsRevoked(ctx, 1, 0, "a", now); revoked {
+		t.Fatal("revocation entry must expire with the token")
+	}
+}
+
+func TestRevokeAllBefore(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	if err := s.RevokeAllBefore(ctx, 1, now, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	if revoked, _ := s.IsRevoked(ctx, 1, 0, "old", now.Add(-time.Minute)); !revoked {
+		t.Fatal("token issued before watermark must be revoked")
+	}
+	if revoked, _ := s.IsRevoked(ctx, 1, 0, "new", now.Add(time.Second)); revoked {
+		t.Fatal("token issued after watermark must stay valid")
+	}
+	if revoked, _ := s.IsRevoked(ctx, 2, 0, "other", now.Add(-time.Minute)); revoked {
+		t.Fatal("watermark must not affect other users")
+	}
+}
+
+func TestRevokeAllBeforeWithinSecond(t *testing.T) {
+	s, mr := newTestStore(t)
+	ctx := context.Background()
+	revokedAt := time.UnixMilli(1_700_000_000_500)
+
+	if err := s.RevokeAllBefore(ctx, 1, revokedAt, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	// chat_service читает тот же ключ
+	if got, _ := mr.Get(revoked.UserKey(1)); got != "1700000000500" {
+		t.Fatalf("unexpected watermark %q", got)
+	}
+
+	if ok, _ := s.IsRevoked(ctx, 1, 0, "same-second", revokedAt.Add(-400*time.Millisecond)); !ok {
+		t.Fatal("token issued earlier in the revocation second must be revoked")
+	}
+	if ok, _ := s.IsRevoked(ctx, 1, 0, "after", revokedAt.Add(time.Millisecond)); ok {
+		t.Fatal("token issued right after the watermark must stay valid")
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	if err := s.RevokeSession(ctx, 10, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := s.IsRevoked(ctx, 1, 10, "a", now); !revoked {
+		t.Fatal("token of revoked session must be revoked")
+	}
+	if revoked, _ := s.IsRevoked(ctx, 1, 11, "b", now); revoked {
+		t.Fatal("other session must stay valid")
+	}
+}
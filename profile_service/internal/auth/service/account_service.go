@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"profile_service/internal/auth/models"
+	"profile_service/internal/auth/password"
+	"profile_service/internal/auth/repository"
+	"profile_service/internal/auth/throttle"
+	"profile_service/internal/kafka/friendship_producer"
+	"profile_service/internal/mailer"
+	userModels "profile_service/internal/user/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	VerificationTTL  = 24 * time.Hour
+	PasswordResetTTL = time.Hour
+	EmailChangeTTL   = 24 * time.Hour
+
+	// AccountEventsTopic — топик событий безопасности аккаунта
+	AccountEventsTopic = "account-events"
+)
+
+// Изменения, после которых отзываются сессии; значения уходят в событие
+// account_security_changed и в reason RevokeAllSessions.
+const (
+	SecurityPasswordChanged = "password_changed"
+	SecurityPasswordReset   = "password_reset"
+	SecurityEmailChanged    = "email_changed"
+	SecurityMfaEnabled      = "mfa_enabled"
+	SecurityMfaDisabled     = "mfa_disabled"
+)
+
+var (
+	ErrInvalidAccountToken  = errors.New("invalid or expired token")
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+	ErrAccountNotFound      = errors.New("account not found")
+	ErrWrongPassword        = errors.New("current password is incorrect")
+	ErrEmailTaken           = errors.New("email already taken")
+	ErrSameEmail            = errors.New("new email matches the current one")
+	ErrTooManyRequests      = errors.New("too many requests")
+)
+
+// ThrottledError — запрос отклонён лимитом; Wait — через сколько можно повторить.
+type ThrottledError struct {
+	Wait time.Duration
+}
+
+func (e *ThrottledError) Error() string { return ErrTooManyRequests.Error() }
+
+func (e *ThrottledError) Unwrap() error { return ErrTooManyRequests }
+
+// AccountUsers — доступ к пользователям, нужный сервису аккаунта.
+// Реализуется user-репозиторием.
+type AccountUsers interface {
+	GetById(ctx context.Context, id int64) (*userModels.User, error)
+	GetByEmail(ctx context.Context, email string) (*userModels.User, error)
+	UpdatePassword(ctx context.Context, id int64, passwordHash string) error
+}
+
+// EventSender — запись события в outbox; реализуется friendship_producer.OutboxProducer.
+type EventSender interface {
+	SendEvent(ctx context.Context, topic, key string, value interface{}) error
+}
+
+type AccountServiceInterface interface {
+	SendVerification(ctx context.Context, userId int64) error
+	VerifyEmail(ctx context.Context, token string) (int64, error)
+	RequestPasswordReset(ctx context.Context, email, ip string) error
+	ResetPassword(ctx context.Context, token string, newPassword string) (int64, error)
+
+	ChangePassword(ctx context.Context, userId int64, currentPassword, newPassword string) error
+	RequestEmailChange(ctx context.Context, userId int64, currentPassword, newEmail string) error
+	ConfirmEmailChange(ctx context.Context, userId int64, token string) error
+}
+
+type AccountService struct {
+	tokens      repository.UserTokenRepositoryInterface
+	users       AccountUsers
+	mailer      mailer.Mailer
+	queue       mailer.Mailer
+	resets      throttle.LoginLimiter
+	logins      throttle.LoginLimiter
+	events      EventSender
+	hasher      *password.Hasher
+	policy      *password.Policy
+	linkBaseURL string
+	log         *logrus.Entry
+}
+
+// NewAccountService: queue отправляет письма в фоне (см. mailer.Queue), resets
+// ограничивает запросы сброса пароля по email и IP, logins — лимит входа, которым
+// ограничены и проверки текущего пароля.
+func NewAccountService(tokens repository.UserTokenRepositoryInterface, users AccountUsers, mailer, queue mailer.Mailer,
+	resets, logins throttle.LoginLimiter, events EventSender, hasher *password.Hasher, policy *password.Policy, linkBaseURL string,
+	log *logrus.Entry) AccountServiceInterface {
+	return &AccountService{
+		tokens:      tokens,
+		users:       users,
+		mailer:      mailer,
+		queue:       queue,
+		resets:      resets,
+		logins:      logins,
+		events:      events,
+		hasher:      hasher,
+		policy:      policy,
+		linkBaseURL: linkBaseURL,
+		log:         log,
+	}
+}
+
+// SendVerification высылает ссылку подтверждения на текущий адрес пользователя.
+func (s *AccountService) SendVerification(ctx context.Context, userId int64) error {
+	user, err := s.users.GetById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrAccountNotFound
+	}
+	// аккаунты с подтверждением от миграции могут подтвердить адрес письмом,
+	// например чтобы привязать вход через провайдера
+	if user.EmailVerifiedAt != nil && user.EmailVerifiedBy != userModels.EmailVerifiedLegacy {
+		return ErrEmailAlreadyVerified
+	}
+
+	raw, err := s.issue(ctx, user, models.TokenPurposeVerifyEmail, VerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Подтвердите email",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы подтвердить адрес, перейдите по ссылке:\n%s\n\nСсылка действует %s.",
+			user.Username, s.link("/verify-email", raw), VerificationTTL),
+	})
+}
+
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) (int64, error) {
+	userId, err := s.tokens.VerifyEmail(ctx, hashToken(token))
+	if errors.Is(err, repository.ErrUserTokenInvalid) {
+		return 0, ErrInvalidAccountToken
+	}
+	return userId, err
+}
+
+// RequestPasswordReset высылает ссылку сброса пароля. Результат не зависит от того,
+// есть ли такой адрес: по ответу нельзя узнать, зарегистрирован ли email. Запрос
+// учитывается лимитом до поиска пользователя, иначе лимит выдал бы и это.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email, ip string) error {
+	wait, _, err := s.resets.Reserve(ctx, email, ip)
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		return &ThrottledError{Wait: wait}
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		s.log.Debug("Password reset requested for unknown email")
+		return nil
+	}
+
+	raw, err := s.issue(ctx, user, models.TokenPurposePasswordReset, PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	// письмо уходит в фоне, иначе время ответа выдаёт существование адреса
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Сброс пароля",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nДля сброса пароля перейдите по ссылке:\n%s\n\nСсылка действует %s. "+
+			"Если вы не запрашивали сброс, просто проигнорируйте это письмо.",
+			user.Username, s.link("/reset-password", raw), PasswordResetTTL),
+	}
+	if err := s.queue.Send(ctx, msg); err != nil {
+		s.log.WithFields(logrus.Fields{"error": err, "user_id": user.Id}).Error("Failed to queue password reset email")
+	}
+
+	return nil
+}
+
+// ResetPassword меняет пароль по токену из письма и возвращает id пользователя,
+// чтобы вызывающий завершил его сессии. Владелец токена неизвестен до его погашения,
+// поэтому сходство с именем здесь не проверяется.
+func (s *AccountService) ResetPassword(ctx context.Context, token string, newPassword string) (int64, error) {
+	if err := s.policy.Validate(ctx, newPassword); err != nil {
+		return 0, err
+	}
+	hashed, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return 0, err
+	}
+
+	userId, err := s.tokens.ResetPassword(ctx, hashToken(token), hashed)
+	if errors.Is(err, repository.ErrUserTokenInvalid) {
+		return 0, ErrInvalidAccountToken
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	publishSecurityChange(ctx, s.events, s.log, userId, SecurityPasswordReset)
+	return userId, nil
+}
+
+// ChangePassword меняет пароль после проверки текущего.
+func (s *AccountService) ChangePassword(ctx context.Context, userId int64, currentPassword, newPassword string) error {
+	user, err := reauthenticate(ctx, s.users, s.hasher, s.logins, userId, currentPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.policy.Validate(ctx, newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	hashed, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.users.UpdatePassword(ctx, userId, hashed); err != nil {
+		return err
+	}
+
+	publishSecurityChange(ctx, s.events, s.log, userId, SecurityPasswordChanged)
+	return nil
+}
+
+// RequestEmailChange после проверки пароля высылает ссылку подтверждения на новый адрес.
+// Адрес аккаунта меняется только по этой ссылке; старый адрес получает уведомление.
+func (s *AccountService) RequestEmailChange(ctx context.Context, userId int64, currentPassword, newEmail string) error {
+	user, err := reauthenticate(ctx, s.users, s.hasher, s.logins, userId, currentPassword)
+	if err != nil {
+		return err
+	}
+	if newEmail == user.Email {
+		return ErrSameEmail
+	}
+
+	taken, err := s.users.GetByEmail(ctx, newEmail)
+	if err != nil {
+		return err
+	}
+	if taken != nil {
+		return ErrEmailTaken
+	}
+
+	raw, err := randomToken()
+	if err != nil {
+		return err
+	}
+	err = s.tokens.Create(ctx, &models.UserToken{
+		UserId:    user.Id,
+		Purpose:   models.TokenPurposeChangeEmail,
+		TokenHash: hashToken(raw),
+		Email:     newEmail,
+		ExpiresAt: time.Now().Add(EmailChangeTTL).UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      newEmail,
+		Subject: "Подтвердите новый email",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы сделать этот адрес адресом аккаунта, перейдите по ссылке:\n%s\n\nСсылка действует %s.",
+			user.Username, s.link("/confirm-email", raw), EmailChangeTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	// уведомление на старый адрес не обязательно: ссылка уже отправлена
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Запрошена смена email",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nДля вашего аккаунта запрошена смена email на %s. "+
+			"Если это были не вы, срочно смените пароль.", user.Username, newEmail),
+	})
+	if err != nil {
+		s.log.WithFields(logrus.Fields{"error": err, "user_id": user.Id}).Warn("Failed to notify previous email")
+	}
+	return nil
+}
+
+// ConfirmEmailChange применяет смену email по токену из письма. Токен должен
+// принадлежать тому же пользователю, что вошёл в аккаунт.
+func (s *AccountService) ConfirmEmailChange(ctx context.Context, userId int64, token string) error {
+	_, err := s.tokens.ChangeEmail(ctx, hashToken(token), userId)
+	switch {
+	case errors.Is(err, repository.ErrUserTokenInvalid):
+		return ErrInvalidAccountToken
+	case errors.Is(err, repository.ErrEmailTaken):
+		return ErrEmailTaken
+	case err != nil:
+		return err
+	}
+
+	publishSecurityChange(ctx, s.events, s.log, userId, SecurityEmailChanged)
+	return nil
+}
+
+// reauthenticate проверяет текущий пароль в счёт лимита входа по имени пользователя:
+// с украденным access-токеном пароль не подобрать быстрее, чем через логин.
+// Заблокированное имя получает ту же задержку, что и при входе.
+func reauthenticate(ctx context.Context, users AccountUsers, hasher *password.Hasher, limiter throttle.LoginLimiter,
+	userId int64, currentPassword string) (*userModels.User, error) {
+	user, err := users.GetById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	wait, delay, err := limiter.Reserve(ctx, user.Username, "")
+	if err != nil {
+		return nil, err
+	}
+	if wait > 0 {
+		return nil, &ThrottledError{Wait: wait}
+	}
+
+	ok, _, err := hasher.Verify(user.Password, currentPassword)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if delay > 0 {
+			return nil, &ThrottledError{Wait: delay}
+		}
+		return nil, ErrWrongPassword
+	}
+	if err := limiter.Reset(ctx, user.Username); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// publishSecurityChange пишет событие в outbox. Изменение уже сохранено,
+// поэтому ошибка только логируется.
+func publishSecurityChange(ctx context.Context, events EventSender, log *logrus.Entry, userId int64, change string) {
+	event := friendship_producer.NewAccountSecurityChangedEvent(userId, change)
+	if err := events.SendEvent(ctx, AccountEventsTopic, fmt.Sprintf("%d", userId), event); err != nil {
+		log.WithFields(logrus.Fields{"error": err, "user_id": userId}).Error("Failed to publish account security event")
+	}
+}
+
+func (s *AccountService) issue(ctx context.Context, user *userModels.User, purpose string, ttl time.Duration) (string, error) {
+	raw, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	token := &models.UserToken{
+		UserId:    user.Id,
+		Purpose:   purpose,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(ttl).UTC(),
+	}
+	if purpose == models.TokenPurposeVerifyEmail {
+		token.Email = user.Email
+	}
+	if err := s.tokens.Create(ctx, token); err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+func (s *AccountService) link(path, token string) string {
+	return s.linkBaseURL + path + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,454 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"profile_service/internal/auth/models"
+	"profile_service/internal/auth/password"
+	"profile_service/internal/auth/repository"
+	kafkaModels "profile_service/internal/kafka/friendship_producer/models"
+	"profile_service/internal/mailer"
+	userModels "profile_service/internal/user/models"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// memAccounts — пользователи и токены из писем в памяти; повторяет условия SQL-запросов репозитория.
+type memAccounts struct {
+	mu     sync.Mutex
+	users  map[int64]*userModels.User
+	tokens map[string]*models.UserToken
+}
+
+func newMemAccounts(users ...*userModels.User) *memAccounts {
+	m := &memAccounts{
+		users:  make(map[int64]*userModels.User),
+		tokens: make(map[string]*models.UserToken),
+	}
+	for _, u := range users {
+		m.users[u.Id] = u
+	}
+	return m
+}
+
+func (m *memAccounts) GetById(ctx context.Context, id int64) (*userModels.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.users[id], nil
+}
+
+func (m *memAccounts) GetByEmail(ctx context.Context, email string) (*userModels.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, u := range m.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memAccounts) Create(ctx context.Context, token *models.UserToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, t := range m.tokens {
+		if t.UserId == token.UserId && t.Purpose == token.Purpose && t.UsedAt == nil {
+			t.UsedAt = &now
+		}
+	}
+	m.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (m *memAccounts) consume(tokenHash, purpose string) (*models.UserToken, error) {
+	t, ok := m.tokens[tokenHash]
+	if !ok || t.Purpose != purpose || t.UsedAt != nil || time.Now().After(t.ExpiresAt) {
+		return nil, repository.ErrUserTokenInvalid
+	}
+	now := time.Now()
+	t.UsedAt = &now
+	return t, nil
+}
+
+func (m *memAccounts) VerifyEmail(ctx context.Context, tokenHash string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, err := m.consume(tokenHash, models.TokenPurposeVerifyEmail)
+	if err != nil {
+		return 0, err
+	}
+	u := m.users[t.UserId]
+	if u.Email != t.Email {
+		return 0, repository.ErrUserTokenInvalid
+	}
+	now := time.Now()
+	u.EmailVerifiedAt = &now
+	u.EmailVerifiedBy = userModels.EmailVerifiedLink
+	return u.Id, nil
+}
+
+func (m *memAccounts) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, err := m.consume(tokenHash, models.TokenPurposePasswordReset)
+	if err != nil {
+		return 0, err
+	}
+	m.users[t.UserId].Password = passwordHash
+	return t.UserId, nil
+}
+
+func (m *memAccounts) UpdatePassword(ctx context.Context, id int64, passwordHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users[id].Password = passwordHash
+	return nil
+}
+
+func (m *memAccounts) ChangeEmail(ctx context.Context, tokenHash string, userId int64) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, err := m.consume(tokenHash, models.TokenPurposeChangeEmail)
+	if err != nil {
+		return "", err
+	}
+	if t.UserId != userId {
+		return "", repository.ErrUserTokenInvalid
+	}
+	for _, u := range m.users {
+		if u.Email == t.Email {
+			return "", repository.ErrEmailTaken
+		}
+	}
+	now := time.Now()
+	m.users[userId].Email = t.Email
+	m.users[userId].EmailVerifiedAt = &now
+	return t.Email, nil
+}
+
+type recordingEvents struct {
+	mu      sync.Mutex
+	changes []string
+}
+
+func (r *recordingEvents) SendEvent(ctx context.Context, topic, key string, value interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes = append(r.changes, value.(*kafkaModels.AccountSecurityChangedEvent).Change)
+	return nil
+}
+
+type recordingMailer struct {
+	sent chan mailer.Message
+}
+
+func (r *recordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	r.sent <- msg
+	return nil
+}
+
+// memLimiter отклоняет попытки по имени сверх limit; IP не учитывает.
+type memLimiter struct {
+	mu       sync.Mutex
+	limit    int
+	attempts map[string]int
+}
+
+func newMemLimiter(limit int) *memLimiter {
+	return &memLimiter{limit: limit, attempts: make(map[string]int)}
+}
+
+func (l *memLimiter) Reserve(ctx context.Context, username, ip string) (time.Duration, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := strings.ToLower(username)
+	if l.attempts[key] >= l.limit {
+		return time.Minute, 0, nil
+	}
+	l.attempts[key]++
+	return 0, 0, nil
+}
+
+func (l *memLimiter) Release(ctx context.Context, ip string) error { return nil }
+
+func (l *memLimiter) Reset(ctx context.Context, username string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.attempts, strings.ToLower(username))
+	return nil
+}
+
+func newTestAccountService(users ...*userModels.User) (*AccountService, *memAccounts, *recordingMailer) {
+	s, store, mail, _ := newTestAccountServiceWithEvents(users...)
+	return s, store, mail
+}
+
+func newTestAccountServiceWithEvents(users ...*userModels.User) (*AccountService, *memAccounts, *recordingMailer, *recordingEvents) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	store := newMemAccounts(users...)
+	mail := &recordingMailer{sent: make(chan mailer.Message, 4)}
+	events := &recordingEvents{}
+	policy := password.DefaultPolicy
+	s := NewAccountService(store, store, mail, mail, newMemLimiter(3), newMemLimiter(3), events, testHasher, &policy, "http://app",
+		logrus.NewEntry(log)).(*AccountService)
+	return s, store, mail, events
+}
+
+// testHasher — argon2id с минимальными параметрами, чтобы тесты не тратили время на хэширование.
+var testHasher = password.NewHasher(password.Params{Memory: 64, Iterations: 1, Parallelism: 1})
+
+// hashed возвращает bcrypt-хэш, как у аккаунтов, созданных до перехода на argon2id.
+func hashed(t *testing.T, plain string) string {
+	h, err := bcrypt.GenerateFromPassword([]byte(plain), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(h)
+}
+
+// tokenFrom достаёт токен из ссылки в письме.
+func tokenFrom(t *testing.T, mail *recordingMailer) string {
+	select {
+	case msg := <-mail.sent:
+		for _, line := range strings.Split(msg.Body, "\n") {
+			if strings.HasPrefix(line, "http://app/") {
+				u, err := url.Parse(line)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return u.Query().Get("token")
+			}
+		}
+		t.Fatalf("no link in mail: %q", msg.Body)
+	case <-time.After(time.Second):
+		t.Fatal("mail was not sent")
+	}
+	return ""
+}
+
+func TestVerifyEmailTokenIsSingleUse(t *testing.T) {
+	s, store, mail := newTestAccountService(&userModels.User{Id: 1, Username: "alice", Email: "a@example.com"})
+	ctx := context.Background()
+
+	if err := s.SendVerification(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	first := tokenFrom(t, mail)
+
+	// повторная отправка гасит ссылку из первого письма
+	if err := s.SendVerification(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	second := tokenFrom(t, mail)
+
+	if _, err := s.VerifyEmail(ctx, first); !errors.Is(err, ErrInvalidAccountToken) {
+		t.Fatalf("superseded token must be rejected, got %v", err)
+	}
+	if _, err := s.VerifyEmail(ctx, second); err != nil {
+		t.Fatal(err)
+	}
+	if store.users[1].EmailVerifiedAt == nil {
+		t.Fatal("email must be verified")
+	}
+	if _, err := s.VerifyEmail(ctx, second); !errors.Is(err, ErrInvalidAccountToken) {
+		t.Fatalf("token must be single-use, got %v", err)
+	}
+	if err := s.SendVerification(ctx, 1); !errors.Is(err, ErrEmailAlreadyVerified) {
+		t.Fatalf("expected already verified, got %v", err)
+	}
+}
+
+func TestVerifyEmailRejectsTokenForOldAddress(t *testing.T) {
+	s, store, mail := newTestAccountService(&userModels.User{Id: 1, Email: "old@example.com"})
+	ctx := context.Background()
+
+	if err := s.SendVerification(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	token := tokenFrom(t, mail)
+
+	store.users[1].Email = "new@example.com"
+	if _, err := s.VerifyEmail(ctx, token); !errors.Is(err, ErrInvalidAccountToken) {
+		t.Fatalf("token for previous address must be rejected, got %v", err)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	s, store, mail := newTestAccountService(&userModels.User{Id: 1, Email: "a@example.com", Password: "old"})
+	ctx := context.Background()
+
+	// неизвестный адрес: тот же ответ и никаких писем
+	if err := s.RequestPasswordReset(ctx, "nobody@example.com", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-mail.sent:
+		t.Fatal("no mail expected for unknown email")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := s.RequestPasswordReset(ctx, "a@example.com", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	token := tokenFrom(t, mail)
+
+	userId, err := s.ResetPassword(ctx, token, "n3w-passw0rd")
+	if err != nil || userId != 1 {
+		t.Fatalf("unexpected reset result %d %v", userId, err)
+	}
+	if ok, _, _ := testHasher.Verify(store.users[1].Password, "n3w-passw0rd"); !ok {
+		t.Fatal("password hash was not updated")
+	}
+	if _, err := s.ResetPassword(ctx, token, "an0ther-passw0rd"); !errors.Is(err, ErrInvalidAccountToken) {
+		t.Fatalf("reset token must be single-use, got %v", err)
+	}
+}
+
+func TestPasswordResetThrottledPerEmail(t *testing.T) {
+	s, _, mail := newTestAccountService(&userModels.User{Id: 1, Email: "a@example.com"})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := s.RequestPasswordReset(ctx, "a@example.com", "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+		tokenFrom(t, mail)
+	}
+
+	err := s.RequestPasswordReset(ctx, "A@example.com", "10.0.0.2")
+	var throttled *ThrottledError
+	if !errors.As(err, &throttled) || throttled.Wait <= 0 {
+		t.Fatalf("expected throttled request, got %v", err)
+	}
+	select {
+	case <-mail.sent:
+		t.Fatal("throttled request must not send mail")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestExpiredTokenRejected(t *testing.T) {
+	s, store, _ := newTestAccountService(&userModels.User{Id: 1, Email: "a@example.com"})
+
+	raw := "expired"
+	_ = store.Create(context.Background(), &models.UserToken{
+		UserId:    1,
+		Purpose:   models.TokenPurposePasswordReset,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+
+	if _, err := s.ResetPassword(context.Background(), raw, "n3w-passw0rd"); !errors.Is(err, ErrInvalidAccountToken) {
+		t.Fatalf("expired token must be rejected, got %v", err)
+	}
+}
+
+func TestChangePasswordRequiresCurrentPassword(t *testing.T) {
+	s, store, _, events := newTestAccountServiceWithEvents(&userModels.User{Id: 1, Username: "alice", Password: hashed(t, "old-pass")})
+	ctx := context.Background()
+
+	if err := s.ChangePassword(ctx, 1, "wrong", "new-pass"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("expected wrong password, got %v", err)
+	}
+	if err := s.ChangePassword(ctx, 1, "old-pass", "alice2024"); !errors.Is(err, password.ErrSimilar) {
+		t.Fatalf("password similar to username must be rejected, got %v", err)
+	}
+	if len(events.changes) != 0 {
+		t.Fatal("failed change must not emit an event")
+	}
+
+	if err := s.ChangePassword(ctx, 1, "old-pass", "new-pass"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, rehash, _ := testHasher.Verify(store.users[1].Password, "new-pass"); !ok || rehash {
+		t.Fatal("password must be stored as argon2id hash")
+	}
+	if len(events.changes) != 1 || events.changes[0] != SecurityPasswordChanged {
+		t.Fatalf("unexpected events %v", events.changes)
+	}
+}
+
+func TestChangePasswordCountsAgainstLoginLimit(t *testing.T) {
+	s, _, _ := newTestAccountService(&userModels.User{Id: 1, Username: "alice", Password: hashed(t, "old-pass")})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := s.ChangePassword(ctx, 1, "guess", "new-pass"); !errors.Is(err, ErrWrongPassword) {
+			t.Fatalf("attempt %d: expected wrong password, got %v", i, err)
+		}
+	}
+	// заблокированное имя отклоняется до проверки пароля, даже верного
+	err := s.ChangePassword(ctx, 1, "old-pass", "new-pass")
+	var throttled *ThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("expected throttled re-authentication, got %v", err)
+	}
+}
+
+func TestEmailChangeGoesThroughVerification(t *testing.T) {
+	s, store, mail := newTestAccountService(
+		&userModels.User{Id: 1, Email: "old@example.com", Password: hashed(t, "pass")},
+		&userModels.User{Id: 2, Email: "taken@example.com"},
+	)
+	ctx := context.Background()
+
+	if err := s.RequestEmailChange(ctx, 1, "wrong", "new@example.com"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("expected wrong password, got %v", err)
+	}
+	if err := s.RequestEmailChange(ctx, 1, "pass", "taken@example.com"); !errors.Is(err, ErrEmailTaken) {
+		t.Fatalf("expected email taken, got %v", err)
+	}
+
+	if err := s.RequestEmailChange(ctx, 1, "pass", "new@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	token := tokenFrom(t, mail)
+	if notice := <-mail.sent; notice.To != "old@example.com" {
+		t.Fatalf("previous address must be notified, got %q", notice.To)
+	}
+
+	// до перехода по ссылке адрес не меняется
+	if store.users[1].Email != "old@example.com" {
+		t.Fatal("email must not change before confirmation")
+	}
+
+	if err := s.ConfirmEmailChange(ctx, 2, token); !errors.Is(err, ErrInvalidAccountToken) {
+		t.Fatalf("token of another user must be rejected, got %v", err)
+	}
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	s, store, mail, events := newTestAccountServiceWithEvents(
+		&userModels.User{Id: 1, Email: "old@example.com", Password: hashed(t, "pass")},
+	)
+	ctx := context.Background()
+
+	if err := s.RequestEmailChange(ctx, 1, "pass", "new@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	token := tokenFrom(t, mail)
+
+	if err := s.ConfirmEmailChange(ctx, 1, token); err != nil {
+		t.Fatal(err)
+	}
+	if u := store.users[1]; u.Email != "new@example.com" || u.EmailVerifiedAt == nil {
+		t.Fatalf("email must be changed and verified: %+v", u)
+	}
+	if len(events.changes) != 1 || events.changes[0] != SecurityEmailChanged {
+		t.Fatalf("unexpected events %v", events.changes)
+	}
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"profile_service/internal/auth/models"
+	"profile_service/internal/auth/password"
+	"profile_service/internal/auth/repository"
+	"profile_service/internal/auth/sso"
+	userModels "profile_service/internal/user/models"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+// SecurityIdentityLinked — к существующему аккаунту привязан внешний провайдер.
+const SecurityIdentityLinked = "identity_linked"
+
+const maxUsernameLen = 50
+
+var (
+	ErrUnknownProvider      = errors.New("unknown identity provider")
+	ErrInvalidSSOState      = errors.New("invalid or expired sso state")
+	ErrExternalAuthFailed   = errors.New("external authentication failed")
+	ErrProviderEmailMissing = errors.New("provider did not return a verified email")
+	// ErrAccountLinkConflict — аккаунт с таким email есть, но адрес в нём не подтверждён:
+	// привязка отдала бы аккаунт тому, кто зарегистрировал его на чужой адрес.
+	ErrAccountLinkConflict = errors.New("account with this email has an unverified address")
+)
+
+// ExternalUsers — доступ к пользователям, нужный входу через провайдера.
+// Реализуется user-репозиторием.
+type ExternalUsers interface {
+	GetById(ctx context.Context, id int64) (*userModels.User, error)
+	GetByEmail(ctx context.Context, email string) (*userModels.User, error)
+	GetByUsername(ctx context.Context, username string) (*userModels.User, error)
+}
+
+// ExternalLogin — чей аккаунт открыл вход через провайдера.
+type ExternalLogin struct {
+	UserId   int64
+	Username string
+	Created  bool
+	Linked   bool
+}
+
+type ExternalAuthServiceInterface interface {
+	Providers() []string
+	// Begin возвращает адрес страницы входа провайдера.
+	Begin(ctx context.Context, provider string) (string, error)
+	// Complete обменивает код из callback и находит, привязывает или создаёт пользователя.
+	Complete(ctx context.Context, provider, state, code string) (*ExternalLogin, error)
+}
+
+type ExternalAuthService struct {
+	providers  *sso.Registry
+	states     sso.StateStore
+	identities repository.IdentityRepositoryInterface
+	users      ExternalUsers
+	hasher     *password.Hasher
+	events     EventSender
+	log        *logrus.Entry
+}
+
+func NewExternalAuthService(providers *sso.Registry, states sso.StateStore, identities repository.IdentityRepositoryInterface,
+	users ExternalUsers, hasher *password.Hasher, events EventSender, log *logrus.Entry) ExternalAuthServiceInterface {
+	return &ExternalAuthService{
+		providers:  providers,
+		states:     states,
+		identities: identities,
+		users:      users,
+		hasher:     hasher,
+		events:     events,
+		log:        log,
+	}
+}
+
+func (s *ExternalAuthService) Providers() []string {
+	return s.providers.Names()
+}
+
+func (s *ExternalAuthService) Begin(ctx context.Context, provider string) (string, error) {
+	p, err := s.provider(ctx, provider)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	if err := s.states.Save(ctx, state, &sso.AuthState{Provider: provider, Verifier: verifier, Nonce: nonce}); err != nil {
+		return "", err
+	}
+	return p.AuthCodeURL(state, nonce, verifier), nil
+}
+
+func (s *ExternalAuthService) Complete(ctx context.Context, provider, state, code string) (*ExternalLogin, error) {
+	pending, err := s.states.Take(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil || pending.Provider != provider {
+		return nil, ErrInvalidSSOState
+	}
+
+	p, err := s.provider(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := p.Exchange(ctx, code, pending.Verifier, pending.Nonce)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{"error": err, "provider": provider}).Warn("External authentication failed")
+		return nil, ErrExternalAuthFailed
+	}
+
+	linked, err := s.identities.Get(ctx, provider, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if linked != nil {
+		user, err := s.users.GetById(ctx, linked.UserId)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrAccountNotFound
+		}
+		return &ExternalLogin{UserId: user.Id, Username: user.Username}, nil
+	}
+
+	// без подтверждённого провайдером адреса нельзя ни привязать, ни завести аккаунт
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, ErrProviderEmailMissing
+	}
+	link := &models.UserIdentity{Provider: provider, Subject: identity.Subject, Email: identity.Email}
+
+	existing, err := s.users.GetByEmail(ctx, identity.Email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		// подтверждение, проставленное миграцией, не доказывает владение адресом
+		if existing.EmailVerifiedAt == nil || existing.EmailVerifiedBy == userModels.EmailVerifiedLegacy {
+			return nil, ErrAccountLinkConflict
+		}
+		link.UserId = existing.Id
+		if err := s.identities.Link(ctx, link); err != nil {
+			return nil, err
+		}
+		s.log.WithFields(logrus.Fields{"user_id": existing.Id, "provider": provider}).Info("External identity linked")
+		publishSecurityChange(ctx, s.events, s.log, existing.Id, SecurityIdentityLinked)
+		return &ExternalLogin{UserId: existing.Id, Username: existing.Username, Linked: true}, nil
+	}
+
+	user, err := s.newUser(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.identities.CreateUser(ctx, user, link); err != nil {
+		return nil, err
+	}
+	s.log.WithFields(logrus.Fields{"user_id": user.Id, "provider": provider}).Info("User created from external identity")
+	return &ExternalLogin{UserId: user.Id, Username: user.Username, Created: true}, nil
+}
+
+func (s *ExternalAuthService) provider(ctx context.Context, name string) (*sso.Provider, error) {
+	p, err := s.providers.Get(ctx, name)
+	if errors.Is(err, sso.ErrUnknownProvider) {
+		return nil, ErrUnknownProvider
+	}
+	return p, err
+}
+
+// newUser готовит аккаунт для первого входа через провайдера. Пароль случайный
+// и никому не известен: задать свой можно через восстановление пароля.
+func (s *ExternalAuthService) newUser(ctx context.Context, identity *sso.Identity) (*userModels.User, error) {
+	username, err := s.freeUsername(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	hashed, err := s.hasher.Hash(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	return &userModels.User{
+		Username:        username,
+		Email:           identity.Email,
+		Password:        hashed,
+		EmailVerifiedAt: &now,
+		EmailVerifiedBy: userModels.EmailVerifiedProvider,
+	}, nil
+}
+
+// freeUsername берёт preferred_username или начало email; занятое имя
+// дополняется случайным числом.
+func (s *ExternalAuthService) freeUsername(ctx context.Context, identity *sso.Identity) (string, error) {
+	base := sanitizeUsername(identity.PreferredUsername)
+	if base == "" {
+		base = sanitizeUsername(strings.SplitN(identity.Email, "@", 2)[0])
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		taken, err := s.users.GetByUsername(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if taken == nil {
+			return candidate, nil
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s_%04d", base, n.Int64())
+	}
+	return "", repository.ErrIdentityTaken
+}
+
+func sanitizeUsername(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '.' || r == '-' {
+			b.WriteRune(r)
+		}
+	}
+	// место под суффикс _NNNN
+	out := b.String()
+	if len(out) > maxUsernameLen-5 {
+		out = out[:maxUsernameLen-5]
+	}
+	return out
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"profile_service/internal/auth/models"
+	"profile_service/internal/auth/repository"
+	"profile_service/internal/auth/sso"
+	"profile_service/internal/auth/sso/ssotest"
+	userModels "profile_service/internal/user/models"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+func (m *memAccounts) GetByUsername(ctx context.Context, username string) (*userModels.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, u := range m.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+// memIdentities хранит привязки рядом с пользователями memAccounts.
+type memIdentities struct {
+	mu       sync.Mutex
+	accounts *memAccounts
+	links    map[string]*models.UserIdentity
+}
+
+func (r *memIdentities) Get(ctx context.Context, provider, subject string) (*models.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.links[provider+"|"+subject], nil
+}
+
+func (r *memIdentities) Link(ctx context.Context, identity *models.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := identity.Provider + "|" + identity.Subject
+	if _, ok := r.links[key]; ok {
+		return repository.ErrIdentityTaken
+	}
+	r.links[key] = identity
+	return nil
+}
+
+func (r *memIdentities) CreateUser(ctx context.Context, user *userModels.User, identity *models.UserIdentity) error {
+	r.accounts.mu.Lock()
+	user.Id = int64(len(r.accounts.users) + 100)
+	r.accounts.users[user.Id] = user
+	r.accounts.mu.Unlock()
+
+	identity.UserId = user.Id
+	return r.Link(ctx, identity)
+}
+
+func newTestExternalAuth(t *testing.T, users ...*userModels.User) (*ExternalAuthService, *ssotest.Server, *memAccounts) {
+	idp, err := ssotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(idp.Close)
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	registry := sso.NewRegistry([]sso.ProviderConfig{{
+		Name:         "corp",
+		Issuer:       idp.URL,
+		ClientID:     ssotest.ClientID,
+		ClientSecret: ssotest.ClientSecret,
+		RedirectURL:  "http://app/auth/oidc/corp/callback",
+	}})
+	accounts := newMemAccounts(users...)
+	identities := &memIdentities{accounts: accounts, links: make(map[string]*models.UserIdentity)}
+	s := NewExternalAuthService(registry, sso.NewRedisStateStore(rdb), identities, accounts, testHasher, &recordingEvents{},
+		logrus.NewEntry(log)).(*ExternalAuthService)
+	return s, idp, accounts
+}
+
+// login проходит весь путь браузера: Begin -> страница провайдера -> Complete.
+func login(t *testing.T, s *ExternalAuthService, idp *ssotest.Server) (*ExternalLogin, error) {
+	ctx := context.Background()
+	authURL, err := s.Begin(ctx, "corp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state, err := idp.Authorize(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.Complete(ctx, "corp", state, code)
+}
+
+func TestExternalLoginCreatesAndReusesUser(t *testing.T) {
+	s, idp, accounts := newTestExternalAuth(t, &userModels.User{Id: 1, Username: "alice", Email: "other@corp.test"})
+	idp.SetUser(ssotest.User{Subject: "sub-1", Email: "alice@corp.test", EmailVerified: true, PreferredUsername: "Alice"})
+
+	first, err := login(t, s, idp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Created || first.Username == "alice" {
+		t.Fatalf("expected new user with a free username, got %+v", first)
+	}
+	if u := accounts.users[first.UserId]; u.EmailVerifiedAt == nil {
+		t.Fatal("email confirmed by provider must be marked verified")
+	}
+
+	second, err := login(t, s, idp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Created || second.UserId != first.UserId {
+		t.Fatalf("second login must reuse the linked user, got %+v", second)
+	}
+}
+
+func TestExternalLoginLinksVerifiedEmailOnly(t *testing.T) {
+	verified := time.Now()
+	s, idp, _ := newTestExternalAuth(t,
+		&userModels.User{Id: 1, Username: "bob", Email: "bob@corp.test", EmailVerifiedAt: &verified},
+		&userModels.User{Id: 2, Username: "eve", Email: "carol@corp.test"},
+		&userModels.User{Id: 3, Username: "dave", Email: "dave@corp.test",
+			EmailVerifiedAt: &verified, EmailVerifiedBy: userModels.EmailVerifiedLegacy},
+	)
+
+	idp.SetUser(ssotest.User{Subject: "sub-bob", Email: "bob@corp.test", EmailVerified: true})
+	res, err := login(t, s, idp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Linked || res.UserId != 1 {
+		t.Fatalf("expected link to user 1, got %+v", res)
+	}
+
+	// адрес в локальном аккаунте не подтверждён — его мог занять кто угодно
+	idp.SetUser(ssotest.User{Subject: "sub-carol", Email: "carol@corp.test", EmailVerified: true})
+	if _, err := login(t, s, idp); !errors.Is(err, ErrAccountLinkConflict) {
+		t.Fatalf("expected link conflict, got %v", err)
+	}
+
+	// подтверждение задним числом из миграции не доказывает владение адресом
+	idp.SetUser(ssotest.User{Subject: "sub-dave", Email: "dave@corp.test", EmailVerified: true})
+	if _, err := login(t, s, idp); !errors.Is(err, ErrAccountLinkConflict) {
+		t.Fatalf("expected link conflict for backfilled verification, got %v", err)
+	}
+
+	idp.SetUser(ssotest.User{Subject: "sub-x", Email: "x@corp.test", EmailVerified: false})
+	if _, err := login(t, s, idp); !errors.Is(err, ErrProviderEmailMissing) {
+		t.Fatalf("unverified provider email must be rejected, got %v", err)
+	}
+}
+
+func TestExternalLoginStateIsSingleUse(t *testing.T) {
+	s, idp, _ := newTestExternalAuth(t)
+	idp.SetUser(ssotest.User{Subject: "sub-1", Email: "a@corp.test", EmailVerified: true})
+	ctx := context.Background()
+
+	authURL, err := s.Begin(ctx, "corp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state, _ := idp.Authorize(authURL)
+	if _, err := s.Complete(ctx, "corp", state, code); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Complete(ctx, "corp", state, code); !errors.Is(err, ErrInvalidSSOState) {
+		t.Fatalf("state must be single-use, got %v", err)
+	}
+
+	if _, err := s.Begin(ctx, "unknown"); !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("expected unknown provider, got %v", err)
+	}
+}
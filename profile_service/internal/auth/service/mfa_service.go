@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
+	"profile_service/internal/auth/mfa"
+	"profile_service/internal/auth/models"
+	"profile_service/internal/auth/password"
+	"profile_service/internal/auth/repository"
+	"profile_service/internal/auth/throttle"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	mfaIssuer         = "mini-chat"
+	totpPeriod        = 30
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrMfaAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrMfaNotEnrolled    = errors.New("two-factor enrollment not started")
+	ErrMfaNotEnabled     = errors.New("two-factor authentication not enabled")
+	ErrInvalidMfaCode    = errors.New("invalid two-factor code")
+	ErrMfaUnavailable    = errors.New("two-factor authentication is not configured")
+)
+
+// totpOpts — параметры, которые понимают все распространённые приложения-аутентификаторы.
+var totpOpts = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// Enrollment — данные для приложения-аутентификатора; ProvisioningURI
+// (otpauth://) клиент показывает QR-кодом, Secret — для ручного ввода.
+type Enrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+type MfaStatus struct {
+	Enabled           bool
+	RecoveryCodesLeft int64
+}
+
+type MfaServiceInterface interface {
+	Status(ctx context.Context, userId int64) (*MfaStatus, error)
+	Enroll(ctx context.Context, userId int64) (*Enrollment, error)
+	Confirm(ctx context.Context, userId int64, code string) ([]string, error)
+	Disable(ctx context.Context, userId int64, currentPassword, code string) error
+
+	IsEnabled(ctx context.Context, userId int64) (bool, error)
+	// Verify проверяет второй фактор при входе: TOTP-код или код восстановления.
+	Verify(ctx context.Context, userId int64, code string) error
+}
+
+type MfaService struct {
+	repo   repository.MfaRepositoryInterface
+	users  AccountUsers
+	hasher *password.Hasher
+	logins throttle.LoginLimiter
+	cipher *mfa.Cipher
+	events EventSender
+	log    *logrus.Entry
+	now    func() time.Time
+}
+
+// NewMfaService: cipher nil, если ключ шифрования не настроен. Тогда подключить
+// 2FA нельзя — секрет, зашифрованный временным ключом, не пережил бы рестарт.
+func NewMfaService(repo repository.MfaRepositoryInterface, users AccountUsers, hasher *password.Hasher, logins throttle.LoginLimiter,
+	cipher *mfa.Cipher, events EventSender, log *logrus.Entry) MfaServiceInterface {
+	return &MfaService{
+		repo:   repo,
+		users:  users,
+		hasher: hasher,
+		logins: logins,
+		cipher: cipher,
+		events: events,
+		log:    log,
+		now:    time.Now,
+	}
+}
+
+func (s *MfaService) Status(ctx context.Context, userId int64) (*MfaStatus, error) {
+	settings, err := s.repo.Get(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil || settings.EnabledAt == nil {
+		return &MfaStatus{}, nil
+	}
+
+	left, err := s.repo.CountRecoveryCodes(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &MfaStatus{Enabled: true, RecoveryCodesLeft: left}, nil
+}
+
+// Enroll выдаёт новый секрет. До подтверждения кодом он не участвует во входе,
+// а повторный Enroll заменяет его.
+func (s *MfaService) Enroll(ctx context.Context, userId int64) (*Enrollment, error) {
+	if s.cipher == nil {
+		return nil, ErrMfaUnavailable
+	}
+	settings, err := s.repo.Get(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if settings != nil && settings.EnabledAt != nil {
+		return nil, ErrMfaAlreadyEnabled
+	}
+
+	user, err := s.users.GetById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      mfaIssuer,
+		AccountName: user.Username,
+		Period:      totpOpts.Period,
+		Digits:      totpOpts.Digits,
+		Algorithm:   totpOpts.Algorithm,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := s.cipher.Seal(userId, []byte(key.Secret()))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SavePending(ctx, userId, sealed); err != nil {
+		return nil, err
+	}
+
+	return &Enrollment{
+		Secret:          key.Secret(),
+		ProvisioningURI: key.URL(),
+	}, nil
+}
+
+// Confirm включает 2FA по первому коду из приложения и возвращает коды
+// восстановления. Они показываются один раз: в БД хранятся только хэши.
+func (s *MfaService) Confirm(ctx context.Context, userId int64, code string) ([]string, error) {
+	if s.cipher == nil {
+		return nil, ErrMfaUnavailable
+	}
+	settings, err := s.repo.Get(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return nil, ErrMfaNotEnrolled
+	}
+	if settings.EnabledAt != nil {
+		return nil, ErrMfaAlreadyEnabled
+	}
+
+	step, err := s.matchTotp(settings, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.Enable(ctx, userId, step, hashes)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMfaAlreadyEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	publishSecurityChange(ctx, s.events, s.log, userId, SecurityMfaEnabled)
+	return codes, nil
+}
+
+// Disable требует и пароль, и второй фактор: одной украденной сессии недостаточно.
+func (s *MfaService) Disable(ctx context.Context, userId int64, currentPassword, code string) error {
+	if _, err := reauthenticate(ctx, s.users, s.hasher, s.logins, userId, currentPassword); err != nil {
+		return err
+	}
+	if err := s.Verify(ctx, userId, code); err != nil {
+		return err
+	}
+	if err := s.repo.Disable(ctx, userId); err != nil {
+		return err
+	}
+
+	publishSecurityChange(ctx, s.events, s.log, userId, SecurityMfaDisabled)
+	return nil
+}
+
+func (s *MfaService) IsEnabled(ctx context.Context, userId int64) (bool, error) {
+	settings, err := s.repo.Get(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+	return settings != nil && settings.EnabledAt != nil, nil
+}
+
+func (s *MfaService) Verify(ctx context.Context, userId int64, code string) error {
+	settings, err := s.repo.Get(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if settings == nil || settings.EnabledAt == nil {
+		return ErrMfaNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if !isTotpCode(code) {
+		return s.useRecoveryCode(ctx, userId, code)
+	}
+
+	step, err := s.matchTotp(settings, code)
+	if err != nil {
+		return err
+	}
+	// код действует весь свой шаг; принятый однажды, он не принимается снова
+	advanced, err := s.repo.AdvanceStep(ctx, userId, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return ErrInvalidMfaCode
+	}
+	return nil
+}
+
+func (s *MfaService) useRecoveryCode(ctx context.Context, userId int64, code string) error {
+	used, err := s.repo.UseRecoveryCode(ctx, userId, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMfaCode
+	}
+	s.log.WithField("user_id", userId).Info("Recovery code used")
+	return nil
+}
+
+// matchTotp возвращает шаг, которому соответствует код; допускается
+// расхождение часов на один шаг в обе стороны.
+func (s *MfaService) matchTotp(settings *models.UserMfa, code string) (int64, error) {
+	if s.cipher == nil {
+		return 0, ErrMfaUnavailable
+	}
+	secret, err := s.cipher.Open(settings.UserId, settings.SecretEnc)
+	if err != nil {
+		return 0, err
+	}
+
+	current := s.now().Unix() / totpPeriod
+	for _, step := range []int64{current, current - 1, current + 1} {
+		expected, err := totp.GenerateCodeCustom(string(secret), time.Unix(step*totpPeriod, 0), totpOpts)
+		if err != nil {
+			return 0, err
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, nil
+		}
+	}
+	return 0, ErrInvalidMfaCode
+}
+
+func isTotpCode(code string) bool {
+	if len(code) != int(totpOpts.Digits) {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRecoveryCodes — 80 случайных бит на код в виде XXXX-XXXX-XXXX-XXXX;
+// при такой энтропии достаточно SHA-256, как и для токенов из писем.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := base32.StdEncoding.EncodeToString(b)
+		codes = append(codes, raw[0:4]+"-"+raw[4:8]+"-"+raw[8:12]+"-"+raw[12:16])
+		hashes = append(hashes, hashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode прощает регистр, пробелы и дефисы при вводе кода.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"profile_service/internal/auth/mfa"
+	"profile_service/internal/auth/models"
+	userModels "profile_service/internal/user/models"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// memMfaRepo повторяет условия SQL-запросов MfaRepo.
+type memMfaRepo struct {
+	mu       sync.Mutex
+	settings map[int64]*models.UserMfa
+	codes    map[int64]map[string]bool // hash -> использован
+}
+
+func newMemMfaRepo() *memMfaRepo {
+	return &memMfaRepo{
+		settings: make(map[int64]*models.UserMfa),
+		codes:    make(map[int64]map[string]bool),
+	}
+}
+
+func (r *memMfaRepo) Get(ctx context.Context, userId int64) (*models.UserMfa, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m, ok := r.settings[userId]; ok {
+		c := *m
+		return &c, nil
+	}
+	return nil, nil
+}
+
+func (r *memMfaRepo) SavePending(ctx context.Context, userId int64, secretEnc []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m, ok := r.settings[userId]; ok && m.EnabledAt != nil {
+		return nil
+	}
+	r.settings[userId] = &models.UserMfa{UserId: userId, SecretEnc: secretEnc}
+	return nil
+}
+
+func (r *memMfaRepo) Enable(ctx context.Context, userId int64, step int64, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.settings[userId]
+	if !ok || m.EnabledAt != nil {
+		return gorm.ErrRecordNotFound
+	}
+	now := time.Now()
+	m.EnabledAt = &now
+	m.LastUsedStep = step
+	r.codes[userId] = make(map[string]bool)
+	for _, h := range codeHashes {
+		r.codes[userId][h] = false
+	}
+	return nil
+}
+
+func (r *memMfaRepo) Disable(ctx context.Context, userId int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.settings, userId)
+	delete(r.codes, userId)
+	return nil
+}
+
+func (r *memMfaRepo) AdvanceStep(ctx context.Context, userId int64, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.settings[userId]
+	if m.LastUsedStep >= step {
+		return false, nil
+	}
+	m.LastUsedStep = step
+	return true, nil
+}
+
+func (r *memMfaRepo) UseRecoveryCode(ctx context.Context, userId int64, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	used, ok := r.codes[userId][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	r.codes[userId][codeHash] = true
+	return true, nil
+}
+
+func (r *memMfaRepo) CountRecoveryCodes(ctx context.Context, userId int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for _, used := range r.codes[userId] {
+		if !used {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memMfaRepo) CountEnabled(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for _, settings := range r.settings {
+		if settings.EnabledAt != nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func newTestMfaService(t *testing.T, users ...*userModels.User) (*MfaService, *memMfaRepo, *recordingEvents) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	key, err := mfa.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cipher, err := mfa.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := newMemMfaRepo()
+	events := &recordingEvents{}
+	s := NewMfaService(repo, newMemAccounts(users...), testHasher, newMemLimiter(10), cipher, events, logrus.NewEntry(log)).(*MfaService)
+	return s, repo, events
+}
+
+func codeAt(t *testing.T, secret string, at time.Time) string {
+	code, err := totp.GenerateCodeCustom(secret, at, totpOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestMfaEnrollConfirmAndVerify(t *testing.T) {
+	s, _, events := newTestMfaService(t, &userModels.User{Id: 1, Username: "alice"})
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+	s.now = func() time.Time { return now }
+
+	enrollment, err := s.Enroll(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/mini-chat:alice?") {
+		t.Fatalf("unexpected provisioning uri %q", enrollment.ProvisioningURI)
+	}
+
+	// до подтверждения 2FA не действует
+	if enabled, _ := s.IsEnabled(ctx, 1); enabled {
+		t.Fatal("mfa must stay disabled until confirmed")
+	}
+	if _, err := s.Confirm(ctx, 1, "000000"); !errors.Is(err, ErrInvalidMfaCode) {
+		t.Fatalf("expected invalid code, got %v", err)
+	}
+
+	confirmCode := codeAt(t, enrollment.Secret, now)
+	codes, err := s.Confirm(ctx, 1, confirmCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("expected %d recovery codes, got %d", recoveryCodeCount, len(codes))
+	}
+	if len(events.changes) != 1 || events.changes[0] != SecurityMfaEnabled {
+		t.Fatalf("expected mfa_enabled event, got %v", events.changes)
+	}
+
+	// код подтверждения нельзя повторно использовать для входа
+	if err := s.Verify(ctx, 1, confirmCode); !errors.Is(err, ErrInvalidMfaCode) {
+		t.Fatalf("confirmation code must not be replayable, got %v", err)
+	}
+
+	now = now.Add(totpPeriod * time.Second)
+	next := codeAt(t, enrollment.Secret, now)
+	if err := s.Verify(ctx, 1, next); err != nil {
+		t.Fatalf("fresh code must be accepted: %v", err)
+	}
+	if err := s.Verify(ctx, 1, next); !errors.Is(err, ErrInvalidMfaCode) {
+		t.Fatalf("code must be single-use, got %v", err)
+	}
+
+	if _, err := s.Enroll(ctx, 1); !errors.Is(err, ErrMfaAlreadyEnabled) {
+		t.Fatalf("re-enroll must require disable first, got %v", err)
+	}
+}
+
+func TestMfaEnrollmentDisabledWithoutKey(t *testing.T) {
+	s, repo, _ := newTestMfaService(t, &userModels.User{Id: 1, Username: "alice"})
+	s.cipher = nil
+	ctx := context.Background()
+
+	if _, err := s.Enroll(ctx, 1); !errors.Is(err, ErrMfaUnavailable) {
+		t.Fatalf("enroll without persistent key must be refused, got %v", err)
+	}
+	if _, err := s.Confirm(ctx, 1, "123456"); !errors.Is(err, ErrMfaUnavailable) {
+		t.Fatalf("confirm without persistent key must be refused, got %v", err)
+	}
+	if n, _ := repo.CountEnabled(ctx); n != 0 {
+		t.Fatalf("nothing must be stored, got %d enabled", n)
+	}
+}
+
+func TestMfaRecoveryCodeIsSingleUse(t *testing.T) {
+	s, _, _ := newTestMfaService(t, &userModels.User{Id: 1, Username: "alice", Password: hashed(t, "secret")})
+	ctx := context.Background()
+
+	enrollment, _ := s.Enroll(ctx, 1)
+	codes, err := s.Confirm(ctx, 1, codeAt(t, enrollment.Secret, time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// регистр и дефисы при вводе не важны
+	typed := strings.ToLower(strings.ReplaceAll(codes[0], "-", " "))
+	if err := s.Verify(ctx, 1, typed); err != nil {
+		t.Fatalf("recovery code must be accepted: %v", err)
+	}
+	if err := s.Verify(ctx, 1, codes[0]); !errors.Is(err, ErrInvalidMfaCode) {
+		t.Fatalf("recovery code must be single-use, got %v", err)
+	}
+	if status, _ := s.Status(ctx, 1); status.RecoveryCodesLeft != recoveryCodeCount-1 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	if err := s.Disable(ctx, 1, "wrong", codes[1]); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("disable must require password, got %v", err)
+	}
+	if err := s.Disable(ctx, 1, "secret", codes[1]); err != nil {
+		t.Fatal(err)
+	}
+	if enabled, _ := s.IsEnabled(ctx, 1); enabled {
+		t.Fatal("mfa must be disabled")
+	}
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"profile_service/internal/auth/models"
+	"profile_service/internal/auth/repository"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RefreshTTL — срок жизни refresh-токена; каждая ротация выдаёт новый токен на тот же срок.
+const RefreshTTL = 30 * 24 * time.Hour
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+// ClientMeta — устройство, с которого выполнен вход.
+type ClientMeta struct {
+	UserAgent string
+	Ip        string
+}
+
+// IssuedRefresh — выданный клиенту refresh-токен; в БД хранится только его хэш.
+type IssuedRefresh struct {
+	Token     string
+	SessionId int64
+	UserId    int64
+	ExpiresAt time.Time
+}
+
+// ReusedTokenError — повторное предъявление refresh-токена; сессия уже завершена,
+// а вызывающему нужно отозвать её access-токены и закрыть сокеты.
+type ReusedTokenError struct {
+	UserId    int64
+	SessionId int64
+}
+
+func (e *ReusedTokenError) Error() string { return ErrRefreshTokenReused.Error() }
+
+func (e *ReusedTokenError) Unwrap() error { return ErrRefreshTokenReused }
+
+type SessionServiceInterface interface {
+	Start(ctx context.Context, userId int64, meta ClientMeta) (*IssuedRefresh, error)
+	Rotate(ctx context.Context, refreshToken string) (*IssuedRefresh, error)
+	Revoke(ctx context.Context, refreshToken string) (*models.Session, error)
+	RevokeAll(ctx context.Context, userId int64, exceptSessionId int64) (int64, error)
+	List(ctx context.Context, userId int64) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userId int64, sessionId int64) error
+}
+
+type SessionService struct {
+	repo repository.SessionRepositoryInterface
+	log  *logrus.Entry
+}
+
+func NewSessionService(repo repository.SessionRepositoryInterface, log *logrus.Entry) SessionServiceInterface {
+	return &SessionService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// Start открывает сессию для нового входа.
+func (s *SessionService) Start(ctx context.Context, userId int64, meta ClientMeta) (*IssuedRefresh, error) {
+	raw, token, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		UserId:    userId,
+		UserAgent: meta.UserAgent,
+		Ip:        meta.Ip,
+	}
+	if err := s.repo.Create(ctx, session, token); err != nil {
+		return nil, err
+	}
+
+	return &IssuedRefresh{
+		Token:     raw,
+		SessionId: session.Id,
+		UserId:    userId,
+		ExpiresAt: token.ExpiresAt,
+	}, nil
+}
+
+// Rotate обменивает refresh-токен на новый. Повторное предъявление уже
+// использованного токена значит, что его украли: отзывается вся сессия.
+func (s *SessionService) Rotate(ctx context.Context, refreshToken string) (*IssuedRefresh, error) {
+	used, session, err := s.repo.GetToken(ctx, hashToken(refreshToken))
+	if errors.Is(err, repository.ErrTokenNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if session.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if used.UsedAt != nil {
+		return nil, s.revokeReused(ctx, session)
+	}
+	if time.Now().After(used.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	raw, next, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Rotate(ctx, used, next)
+	if errors.Is(err, repository.ErrTokenAlreadyUsed) {
+		return nil, s.revokeReused(ctx, session)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &IssuedRefresh{
+		Token:     raw,
+		SessionId: session.Id,
+		UserId:    session.UserId,
+		ExpiresAt: next.ExpiresAt,
+	}, nil
+}
+
+// Revoke завершает сессию, которой принадлежит токен. Неизвестный токен не ошибка:
+// выход должен быть идемпотентным, для него возвращается nil-сессия.
+func (s *SessionService) Revoke(ctx context.Context, refreshToken string) (*models.Session, error) {
+	_, session, err := s.repo.GetToken(ctx, hashToken(refreshToken))
+	if errors.Is(err, repository.ErrTokenNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Revoke(ctx, session.Id); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// RevokeAll завершает все сессии пользователя, кроме exceptSessionId (0 — все):
+// их refresh-токены больше не действуют.
+func (s *SessionService) RevokeAll(ctx context.Context, userId int64, exceptSessionId int64) (int64, error) {
+	return s.repo.RevokeAllForUser(ctx, userId, exceptSessionId)
+}
+
+// List — активные сессии пользователя, последние использованные первыми.
+func (s *SessionService) List(ctx context.Context, userId int64) ([]models.Session, error) {
+	return s.repo.ListActive(ctx, userId)
+}
+
+// RevokeSession завершает одну сессию пользователя, например с потерянного устройства.
+func (s *SessionService) RevokeSession(ctx context.Context, userId int64, sessionId int64) error {
+	err := s.repo.RevokeForUser(ctx, userId, sessionId)
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return ErrSessionNotFound
+	}
+	return err
+}
+
+func (s *SessionService) revokeReused(ctx context.Context, session *models.Session) error {
+	s.log.WithFields(logrus.Fields{
+		"user_id":    session.UserId,
+		"session_id": session.Id,
+	}).Warn("Refresh token reuse detected, revoking session")
+
+	if err := s.repo.Revoke(ctx, session.Id); err != nil {
+		return err
+	}
+	return &ReusedTokenError{UserId: session.UserId, SessionId: session.Id}
+}
+
+func newRefreshToken() (string, *models.RefreshToken, error) {
+	raw, err := randomToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return raw, &models.RefreshToken{
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(RefreshTTL).UTC(),
+	}, nil
+}
+
+// randomToken — 256 случайных бит в base64url, пригодные для URL и JSON.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken: у токена 256 бит энтропии, поэтому медленный хэш не нужен.
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
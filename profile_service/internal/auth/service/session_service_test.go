@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"profile_service/internal/auth/models"
+	"profile_service/internal/auth/repository"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type memSessionRepo struct {
+	mu       sync.Mutex
+	sessions map[int64]*models.Session
+	tokens   map[string]*models.RefreshToken
+	nextId   int64
+}
+
+func newMemSessionRepo() *memSessionRepo {
+	return &memSessionRepo{
+		sessions: make(map[int64]*models.Session),
+		tokens:   make(map[string]*models.RefreshToken),
+	}
+}
+
+func (r *memSessionRepo) Create(ctx context.Context, session *models.Session, token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextId++
+	session.Id = r.nextId
+	r.sessions[session.Id] = session
+
+	token.SessionId = session.Id
+	r.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (r *memSessionRepo) GetToken(ctx context.Context, tokenHash string) (*models.RefreshToken, *models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tokens[tokenHash]
+	if !ok {
+		return nil, nil, repository.ErrTokenNotFound
+	}
+	tc, sc := *t, *r.sessions[t.SessionId]
+	return &tc, &sc, nil
+}
+
+func (r *memSessionRepo) Rotate(ctx context.Context, used *models.RefreshToken, next *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.tokens[used.TokenHash]
+	if t.UsedAt != nil {
+		return repository.ErrTokenAlreadyUsed
+	}
+	now := time.Now()
+	t.UsedAt = &now
+
+	next.SessionId = used.SessionId
+	r.tokens[next.TokenHash] = next
+	return nil
+}
+
+func (r *memSessionRepo) Revoke(ctx context.Context, sessionId int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.sessions[sessionId].RevokedAt = &now
+	return nil
+}
+
+func (r *memSessionRepo) RevokeAllForUser(ctx context.Context, userId int64, exceptSessionId int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	now := time.Now()
+	for _, s := range r.sessions {
+		if s.UserId == userId && s.Id != exceptSessionId && s.RevokedAt == nil {
+			s.RevokedAt = &now
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memSessionRepo) ListActive(ctx context.Context, userId int64) ([]models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []models.Session
+	for _, s := range r.sessions {
+		if s.UserId != userId || s.RevokedAt != nil {
+			continue
+		}
+		for _, t := range r.tokens {
+			if t.SessionId == s.Id && t.UsedAt == nil && t.ExpiresAt.After(time.Now()) {
+				out = append(out, *s)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+func (r *memSessionRepo) RevokeForUser(ctx context.Context, userId int64, sessionId int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[sessionId]
+	if !ok || s.UserId != userId || s.RevokedAt != nil {
+		return repository.ErrSessionNotFound
+	}
+	now := time.Now()
+	s.RevokedAt = &now
+	return nil
+}
+
+func newTestSessionService() *SessionService {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	return NewSessionService(newMemSessionRepo(), logrus.NewEntry(log)).(*SessionService)
+}
+
+func TestRotateIssuesNewToken(t *testing.T) {
+	s := newTestSessionService()
+	ctx := context.Background()
+
+	first, err := s.Start(ctx, 7, ClientMeta{UserAgent: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := s.Rotate(ctx, first.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Token == first.Token || second.SessionId != first.SessionId || second.UserId != 7 {
+		t.Fatalf("unexpected rotation result %+v", second)
+	}
+
+	if _, err := s.Rotate(ctx, second.Token); err != nil {
+		t.Fatalf("rotated token must be usable: %v", err)
+	}
+}
+
+func TestReuseRevokesWholeSession(t *testing.T) {
+	s := newTestSessionService()
+	ctx := context.Background()
+
+	first, _ := s.Start(ctx, 7, ClientMeta{})
+	second, _ := s.Rotate(ctx, first.Token)
+
+	// злоумышленник предъявляет уже использованный токен
+	_, err := s.Rotate(ctx, first.Token)
+	var reused *ReusedTokenError
+	if !errors.As(err, &reused) || !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("expected reuse detection, got %v", err)
+	}
+	if reused.SessionId != first.SessionId || reused.UserId != 7 {
+		t.Fatalf("reuse error must identify the session, got %+v", reused)
+	}
+
+	// законный владелец тоже теряет сессию
+	if _, err := s.Rotate(ctx, second.Token); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("session must be revoked, got %v", err)
+	}
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	s := newTestSessionService()
+	ctx := context.Background()
+
+	issued, _ := s.Start(ctx, 7, ClientMeta{})
+	session, err := s.Revoke(ctx, issued.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session == nil || session.Id != issued.SessionId {
+		t.Fatalf("expected revoked session %d, got %+v", issued.SessionId, session)
+	}
+	if _, err := s.Rotate(ctx, issued.Token); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("expected invalid token after logout, got %v", err)
+	}
+
+	if session, err := s.Revoke(ctx, "unknown"); err != nil || session != nil {
+		t.Fatalf("logout with unknown token must be idempotent, got %v", err)
+	}
+}
+
+func TestRevokeSessionOnlyOwn(t *testing.T) {
+	s := newTestSessionService()
+	ctx := context.Background()
+
+	phone, _ := s.Start(ctx, 7, ClientMeta{UserAgent: "phone"})
+	laptop, _ := s.Start(ctx, 7, ClientMeta{UserAgent: "laptop"})
+	other, _ := s.Start(ctx, 8, ClientMeta{UserAgent: "other"})
+
+	if err := s.RevokeSession(ctx, 7, other.SessionId); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("foreign session must not be revoked, got %v", err)
+	}
+	if err := s.RevokeSession(ctx, 7, phone.SessionId); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RevokeSession(ctx, 7, phone.SessionId); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("second revoke must report not found, got %v", err)
+	}
+
+	sessions, err := s.List(ctx, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].Id != laptop.SessionId {
+		t.Fatalf("expected only laptop session, got %+v", sessions)
+	}
+	if _, err := s.Rotate(ctx, phone.Token); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("revoked session must not refresh, got %v", err)
+	}
+}
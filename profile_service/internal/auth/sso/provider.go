@@ -0,0 +1,159 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const discoveryTimeout = 10 * time.Second
+
+var ErrUnknownProvider = errors.New("unknown identity provider")
+
+// ProviderConfig — настройки OIDC-провайдера; endpoints берутся из discovery по Issuer.
+type ProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Identity — данные пользователя из проверенного ID-токена.
+type Identity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	Name              string
+}
+
+type Provider struct {
+	oauth    oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// AuthCodeURL — адрес страницы входа провайдера; code_challenge (S256)
+// привязывает будущий обмен кода к verifier.
+func (p *Provider) AuthCodeURL(state, nonce, verifier string) string {
+	return p.oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+}
+
+// Exchange обменивает код на токены и проверяет ID-токен: подпись, issuer,
+// audience, срок и nonce из начала входа.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	token, err := p.oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchange code: %w", err)
+	}
+	rawIdToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIdToken)
+	if err != nil {
+		return nil, fmt.Errorf("verify id_token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+
+	var claims struct {
+		Email             string      `json:"email"`
+		EmailVerified     interface{} `json:"email_verified"`
+		PreferredUsername string      `json:"preferred_username"`
+		Name              string      `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("decode id_token claims: %w", err)
+	}
+
+	// часть провайдеров отдаёт email_verified строкой
+	verified := claims.EmailVerified == true || claims.EmailVerified == "true"
+
+	return &Identity{
+		Subject:           idToken.Subject,
+		Email:             claims.Email,
+		EmailVerified:     verified,
+		PreferredUsername: claims.PreferredUsername,
+		Name:              claims.Name,
+	}, nil
+}
+
+// Registry хранит настроенных провайдеров. Discovery выполняется при первом
+// обращении: недоступный провайдер не мешает старту сервиса.
+type Registry struct {
+	configs map[string]ProviderConfig
+
+	mu        sync.Mutex
+	providers map[string]*Provider
+}
+
+func NewRegistry(configs []ProviderConfig) *Registry {
+	r := &Registry{
+		configs:   make(map[string]ProviderConfig, len(configs)),
+		providers: make(map[string]*Provider),
+	}
+	for _, c := range configs {
+		r.configs[c.Name] = c
+	}
+	return r
+}
+
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.configs))
+	for name := range r.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *Registry) Get(ctx context.Context, name string) (*Provider, error) {
+	cfg, ok := r.configs[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	r.mu.Lock()
+	p, ok := r.providers[name]
+	r.mu.Unlock()
+	if ok {
+		return p, nil
+	}
+
+	discoverCtx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+	discovered, err := oidc.NewProvider(discoverCtx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("discover %s: %w", name, err)
+	}
+
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+	p = &Provider{
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     discovered.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier: discovered.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}
+
+	r.mu.Lock()
+	r.providers[name] = p
+	r.mu.Unlock()
+	return p, nil
+}
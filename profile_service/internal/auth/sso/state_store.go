@@ -0,0 +1,66 @@
+package sso
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// StateTTL — сколько пользователь может провести на странице провайдера.
+const StateTTL = 10 * time.Minute
+
+// AuthState — незавершённый вход: state из URL указывает на verifier PKCE и nonce.
+type AuthState struct {
+	Provider string `json:"provider"`
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+}
+
+type StateStore interface {
+	Save(ctx context.Context, state string, s *AuthState) error
+	// Take возвращает и удаляет запись; nil, если state неизвестен, истёк или уже использован.
+	Take(ctx context.Context, state string) (*AuthState, error)
+}
+
+type redisStateStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisStateStore(rdb *redis.Client) StateStore {
+	return &redisStateStore{rdb: rdb}
+}
+
+func (s *redisStateStore) Save(ctx context.Context, state string, a *AuthState) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, stateKey(state), data, StateTTL).Err()
+}
+
+func (s *redisStateStore) Take(ctx context.Context, state string) (*AuthState, error) {
+	data, err := s.rdb.GetDel(ctx, stateKey(state)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var a AuthState
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func stateKey(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return fmt.Sprintf("auth:oidc:state:%s", hex.EncodeToString(sum[:]))
+}
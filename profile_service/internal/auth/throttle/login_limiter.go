@@ -0,0 +1,226 @@
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Policy — правила задержки для одного счётчика неудачных входов.
+type Policy struct {
+	FreeAttempts int64         // ошибок без задержки
+	BaseDelay    time.Duration // задержка после первой ошибки сверх FreeAttempts, дальше удваивается
+	MaxDelay     time.Duration // потолок экспоненциальной задержки
+	LockoutAfter int64         // с этой ошибки вход блокируется на Lockout
+	Lockout      time.Duration
+	Window       time.Duration // счётчик сбрасывается, если ошибок не было дольше Window
+}
+
+var (
+	// DefaultUserPolicy защищает конкретный аккаунт от подбора пароля.
+	DefaultUserPolicy = Policy{
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 10,
+		Lockout:      15 * time.Minute,
+		Window:       time.Hour,
+	}
+	// DefaultIPPolicy мягче: за одним адресом может быть NAT с многими пользователями.
+	DefaultIPPolicy = Policy{
+		FreeAttempts: 20,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 100,
+		Lockout:      15 * time.Minute,
+		Window:       time.Hour,
+	}
+
+	// DefaultResetEmailPolicy ограничивает письма сброса пароля на один адрес.
+	DefaultResetEmailPolicy = Policy{
+		FreeAttempts: 3,
+		BaseDelay:    time.Minute,
+		MaxDelay:     15 * time.Minute,
+		LockoutAfter: 10,
+		Lockout:      time.Hour,
+		Window:       time.Hour,
+	}
+	// DefaultResetIPPolicy — запросы сброса с одного адреса по любым email.
+	DefaultResetIPPolicy = Policy{
+		FreeAttempts: 10,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 50,
+		Lockout:      time.Hour,
+		Window:       time.Hour,
+	}
+)
+
+// Delay — задержка после n-й неудачной попытки подряд.
+func (p Policy) Delay(n int64) time.Duration {
+	if p.LockoutAfter > 0 && n >= p.LockoutAfter {
+		return p.Lockout
+	}
+	if n <= p.FreeAttempts {
+		return 0
+	}
+	shift := n - p.FreeAttempts - 1
+	if shift > 30 {
+		return p.MaxDelay
+	}
+	d := p.BaseDelay << shift
+	if d > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return d
+}
+
+// LoginLimiter ограничивает попытки входа по имени пользователя и по IP.
+type LoginLimiter interface {
+	// Reserve атомарно учитывает попытку до проверки пароля, чтобы параллельные
+	// запросы не обходили лимит. wait > 0 — имя или IP заблокированы, попытка
+	// отклонена и не учтена. delay — задержка, уже назначенная на случай, если
+	// попытка окажется неудачной.
+	Reserve(ctx context.Context, username, ip string) (wait, delay time.Duration, err error)
+	// Release возвращает попытку, учтённую Reserve для IP, если пароль оказался верным,
+	// и снимает блокировку IP, назначенную этой же попыткой: иначе верный вход
+	// блокировал бы следующий вход с того же адреса.
+	Release(ctx context.Context, ip string) error
+	// Reset сбрасывает счётчик имени после успешного входа. Счётчик IP
+	// остаётся: удачный вход одного аккаунта не оправдывает перебор остальных.
+	Reset(ctx context.Context, username string) error
+}
+
+// reserveLua проверяет блокировки и учитывает попытку одной операцией.
+// KEYS — пары (счётчик, блокировка) для имени и, если задан, IP;
+// ARGV — по шесть параметров Policy на каждую пару.
+// Возвращает {wait_ms, delay_ms}.
+const reserveLua = `
+local wait = 0
+for i = 2, #KEYS, 2 do
+  local ttl = redis.call('PTTL', KEYS[i])
+  if ttl > wait then wait = ttl end
+end
+if wait > 0 then return {wait, 0} end
+
+local delay = 0
+for i = 1, #KEYS, 2 do
+  local o = (i - 1) * 3
+  local free, base, maxd = tonumber(ARGV[o + 1]), tonumber(ARGV[o + 2]), tonumber(ARGV[o + 3])
+  local lockAfter, lockout, window = tonumber(ARGV[o + 4]), tonumber(ARGV[o + 5]), tonumber(ARGV[o + 6])
+
+  local n = redis.call('INCR', KEYS[i])
+  redis.call('PEXPIRE', KEYS[i], window)
+
+  local d = 0
+  if lockAfter > 0 and n >= lockAfter then
+    d = lockout
+  elseif n > free then
+    d = math.min(base * 2 ^ (n - free - 1), maxd)
+  end
+  if d > 0 then
+    redis.call('SET', KEYS[i + 1], n, 'PX', math.floor(d))
+  end
+  delay = math.max(delay, d)
+end
+return {0, math.floor(delay)}
+`
+
+// releaseLua снимает блокировку KEYS[2] и уменьшает счётчик KEYS[1], не уводя
+// его в минус, если ключ уже истёк.
+const releaseLua = `
+redis.call('DEL', KEYS[2])
+if tonumber(redis.call('GET', KEYS[1]) or '0') > 0 then
+  return redis.call('DECR', KEYS[1])
+end
+return 0
+`
+
+type redisLimiter struct {
+	rdb     *redis.Client
+	action  string
+	reserve *redis.Script
+	release *redis.Script
+	user    Policy
+	ip      Policy
+}
+
+func NewRedisLoginLimiter(rdb *redis.Client, user, ip Policy) LoginLimiter {
+	return newRedisLimiter(rdb, "login", user, ip)
+}
+
+// NewRedisPasswordResetLimiter ограничивает запросы сброса пароля по email и IP
+// со своими счётчиками. Каждый запрос считается попыткой: Release и Reset не нужны.
+func NewRedisPasswordResetLimiter(rdb *redis.Client, email, ip Policy) LoginLimiter {
+	return newRedisLimiter(rdb, "reset", email, ip)
+}
+
+func newRedisLimiter(rdb *redis.Client, action string, user, ip Policy) *redisLimiter {
+	return &redisLimiter{
+		rdb:     rdb,
+		action:  action,
+		reserve: redis.NewScript(reserveLua),
+		release: redis.NewScript(releaseLua),
+		user:    user,
+		ip:      ip,
+	}
+}
+
+func (l *redisLimiter) Reserve(ctx context.Context, username, ip string) (time.Duration, time.Duration, error) {
+	name := normalize(username)
+	keys := []string{l.failKey("user", name), l.lockKey("user", name)}
+	args := l.user.args()
+	if ip != "" {
+		keys = append(keys, l.failKey("ip", ip), l.lockKey("ip", ip))
+		args = append(args, l.ip.args()...)
+	}
+
+	res, err := l.reserve.Run(ctx, l.rdb, keys, args...).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(res) != 2 {
+		return 0, 0, fmt.Errorf("unexpected reserve result %v", res)
+	}
+	return time.Duration(res[0]) * time.Millisecond, time.Duration(res[1]) * time.Millisecond, nil
+}
+
+func (l *redisLimiter) Release(ctx context.Context, ip string) error {
+	if ip == "" {
+		return nil
+	}
+	return l.release.Run(ctx, l.rdb, []string{l.failKey("ip", ip), l.lockKey("ip", ip)}).Err()
+}
+
+func (l *redisLimiter) Reset(ctx context.Context, username string) error {
+	name := normalize(username)
+	return l.rdb.Del(ctx, l.failKey("user", name), l.lockKey("user", name)).Err()
+}
+
+// args — параметры политики для reserveLua, в миллисекундах.
+func (p Policy) args() []any {
+	return []any{
+		p.FreeAttempts,
+		p.BaseDelay.Milliseconds(),
+		p.MaxDelay.Milliseconds(),
+		p.LockoutAfter,
+		p.Lockout.Milliseconds(),
+		p.Window.Milliseconds(),
+	}
+}
+
+// normalize: "Alice" и "alice " должны делить один счётчик.
+func normalize(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+func (l *redisLimiter) failKey(scope, id string) string {
+	return fmt.Sprintf("auth:%s:fail:%s:%s", l.action, scope, id)
+}
+
+func (l *redisLimiter) lockKey(scope, id string) string {
+	return fmt.Sprintf("auth:%s:lock:%s:%s", l.action, scope, id)
+}
@@ -0,0 +1,173 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+var testPolicy = Policy{
+	FreeAttempts: 2,
+	BaseDelay:    time.Second,
+	MaxDelay:     4 * time.Second,
+	LockoutAfter: 6,
+	Lockout:      time.Minute,
+	Window:       time.Hour,
+}
+
+func newTestLimiter(t *testing.T) (LoginLimiter, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	return NewRedisLoginLimiter(rdb, testPolicy, DefaultIPPolicy), mr
+}
+
+func TestPolicyDelay(t *testing.T) {
+	want := []time.Duration{0, 0, 0, time.Second, 2 * time.Second, 4 * time.Second, time.Minute, time.Minute}
+	for n, d := range want {
+		if got := testPolicy.Delay(int64(n)); got != d {
+			t.Fatalf("Delay(%d) = %v, want %v", n, got, d)
+		}
+	}
+}
+
+func TestReserveLocksUsername(t *testing.T) {
+	l, mr := newTestLimiter(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if wait, delay, err := l.Reserve(ctx, "alice", "10.0.0.1"); err != nil || wait != 0 || delay != 0 {
+			t.Fatalf("free attempt %d: wait=%v delay=%v err=%v", i, wait, delay, err)
+		}
+	}
+	if wait, delay, _ := l.Reserve(ctx, "Alice", "10.0.0.2"); wait != 0 || delay != time.Second {
+		t.Fatalf("expected backoff shared by case-insensitive username, got wait=%v delay=%v", wait, delay)
+	}
+
+	if wait, _, _ := l.Reserve(ctx, "alice", "10.0.0.3"); wait <= 0 {
+		t.Fatal("username must be locked")
+	}
+	if wait, _, _ := l.Reserve(ctx, "bob", "10.0.0.1"); wait != 0 {
+		t.Fatalf("other users must not be locked, got %v", wait)
+	}
+
+	mr.FastForward(time.Second)
+	// отклонённая попытка не учитывается: следующая получает задержку четвёртой
+	if wait, delay, _ := l.Reserve(ctx, "alice", "10.0.0.3"); wait != 0 || delay != 2*time.Second {
+		t.Fatalf("lock must expire, got wait=%v delay=%v", wait, delay)
+	}
+}
+
+func TestConcurrentReservesRespectBudget(t *testing.T) {
+	l, _ := newTestLimiter(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var passed atomic.Int64
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if wait, _, err := l.Reserve(ctx, "alice", ""); err == nil && wait == 0 {
+				passed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// бесплатные попытки и одна, после которой назначена задержка
+	if n := passed.Load(); n != testPolicy.FreeAttempts+1 {
+		t.Fatalf("expected %d attempts to pass, got %d", testPolicy.FreeAttempts+1, n)
+	}
+}
+
+func TestReleaseReturnsIpAttempt(t *testing.T) {
+	l, mr := newTestLimiter(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"alice", "bob", "carol"} {
+		if _, _, err := l.Reserve(ctx, name, "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Release(ctx, "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, _ := mr.Get("auth:login:fail:ip:10.0.0.1"); n != "0" {
+		t.Fatalf("successful logins must not count against the ip, got %s", n)
+	}
+
+	// счётчик уже истёк — Release не уводит его в минус
+	mr.FastForward(DefaultIPPolicy.Window)
+	if err := l.Release(ctx, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("auth:login:fail:ip:10.0.0.1") {
+		t.Fatal("release must not create the counter")
+	}
+}
+
+func TestReleaseLiftsIpLock(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	l := NewRedisLoginLimiter(rdb, DefaultUserPolicy, testPolicy)
+	ctx := context.Background()
+
+	// третья попытка с IP назначает ему задержку
+	for _, name := range []string{"alice", "bob"} {
+		_, _, _ = l.Reserve(ctx, name, "10.0.0.1")
+	}
+	if _, delay, _ := l.Reserve(ctx, "carol", "10.0.0.1"); delay == 0 {
+		t.Fatal("expected ip delay")
+	}
+
+	// пароль carol оказался верным: следующий вход с адреса не ждёт
+	if err := l.Release(ctx, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _, _ := l.Reserve(ctx, "carol", "10.0.0.1"); wait != 0 {
+		t.Fatalf("successful login must lift the ip lock, got wait=%v", wait)
+	}
+}
+
+func TestResetClearsUsername(t *testing.T) {
+	l, _ := newTestLimiter(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, _, _ = l.Reserve(ctx, "alice", "")
+	}
+	if err := l.Reset(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if wait, delay, _ := l.Reserve(ctx, "alice", ""); wait != 0 || delay != 0 {
+		t.Fatalf("reset must lift the lock and clear the counter, got wait=%v delay=%v", wait, delay)
+	}
+}
+
+func TestPasswordResetLimiterHasOwnCounters(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	ctx := context.Background()
+
+	login := NewRedisLoginLimiter(rdb, testPolicy, DefaultIPPolicy)
+	reset := NewRedisPasswordResetLimiter(rdb, testPolicy, DefaultIPPolicy)
+
+	for i := 0; i < 3; i++ {
+		_, _, _ = reset.Reserve(ctx, "a@example.com", "10.0.0.1")
+	}
+	if wait, _, _ := reset.Reserve(ctx, "A@example.com", "10.0.0.2"); wait <= 0 {
+		t.Fatal("reset requests for the email must be throttled")
+	}
+	if wait, delay, _ := login.Reserve(ctx, "a@example.com", "10.0.0.1"); wait != 0 || delay != 0 {
+		t.Fatalf("reset requests must not count as login attempts, got wait=%v delay=%v", wait, delay)
+	}
+}
@@ -2,10 +2,14 @@ package config
 
 import (
 	"os"
+	"strings"
 )
 
 type Config struct {
 	Jwt           string
+	JwtKeysDir    string
+	JwtActiveKid  string
+	MfaKey        string
 	Host          string
 	User          string
 	Password      string
@@ -13,11 +17,16 @@ type Config struct {
 	ProfileDbname string
 	ProfilePort   string
 	GRPCPort      string
+	// TrustedProxies — прокси (IP или CIDR), чьему X-Forwarded-For можно верить
+	TrustedProxies []string
 }
 
 func Load() (*Config, error) {
 	config := &Config{
 		Jwt:           os.Getenv("JWT_SECRET"),
+		JwtKeysDir:    os.Getenv("JWT_KEYS_DIR"),
+		JwtActiveKid:  os.Getenv("JWT_ACTIVE_KID"),
+		MfaKey:        os.Getenv("MFA_ENCRYPTION_KEY"),
 		Host:          os.Getenv("HOST"),
 		User:          os.Getenv("USER"),
 		Password:      os.Getenv("PASSWORD"),
@@ -26,6 +35,11 @@ func Load() (*Config, error) {
 		ProfilePort:   os.Getenv("PROFILE_PORT"),
 		GRPCPort:      os.Getenv("GRPC_PORT"),
 	}
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			config.TrustedProxies = append(config.TrustedProxies, proxy)
+		}
+	}
 
 	return config, nil
 }
@@ -31,7 +31,7 @@ func NewDB(ctx context.Context, cfg *config.Config) (*Database, error) {
 			` port=` + cfg.ProfilePort +
 			` sslmode=` + cfg.Sslmode
 
-	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, fmt.Errorf("connection failed %w", err)
 	}
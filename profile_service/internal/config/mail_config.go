@@ -0,0 +1,34 @@
+package config
+
+import (
+	"os"
+)
+
+type MailConfig struct {
+	SMTPAddr     string
+	SMTPUser     string
+	SMTPPassword string
+	From         string
+	// File — куда складывать письма, если SMTP не настроен
+	File string
+	// LinkBaseURL — адрес фронтенда, на который ведут ссылки из писем
+	LinkBaseURL string
+}
+
+func MailCfgLoad() (*MailConfig, error) {
+	config := &MailConfig{
+		SMTPAddr:     os.Getenv("MAIL_SMTP_ADDR"),
+		SMTPUser:     os.Getenv("MAIL_SMTP_USER"),
+		SMTPPassword: os.Getenv("MAIL_SMTP_PASSWORD"),
+		From:         os.Getenv("MAIL_FROM"),
+		File:         os.Getenv("MAIL_FILE"),
+		LinkBaseURL:  os.Getenv("MAIL_LINK_BASE_URL"),
+	}
+	if config.From == "" {
+		config.From = "no-reply@mini-chat.local"
+	}
+	if config.LinkBaseURL == "" {
+		config.LinkBaseURL = "http://localhost:3000"
+	}
+	return config, nil
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"profile_service/internal/auth/sso"
+	"strings"
+)
+
+// OidcCfgLoad читает провайдеров из OIDC_PROVIDERS (имена через запятую)
+// и переменных OIDC_<ИМЯ>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL,
+// _SCOPES (через пробел, по умолчанию "openid email profile").
+func OidcCfgLoad() ([]sso.ProviderConfig, error) {
+	var providers []sso.ProviderConfig
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		cfg := sso.ProviderConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+			return nil, fmt.Errorf("oidc provider %q: %sISSUER, %sCLIENT_ID and %sREDIRECT_URL are required", name, prefix, prefix, prefix)
+		}
+		providers = append(providers, cfg)
+	}
+	return providers, nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"profile_service/internal/auth/password"
+	"strconv"
+)
+
+type PasswordConfig struct {
+	Policy password.Policy
+	Hash   password.Params
+	// BreachedFile — файл SHA-1 утёкших паролей в формате выгрузки HIBP
+	BreachedFile string
+}
+
+// PasswordCfgLoad читает PASSWORD_MIN_LENGTH, PASSWORD_MAX_LENGTH, PASSWORD_REJECT_SIMILAR,
+// PASSWORD_BREACHED_FILE и параметры argon2id ARGON2_MEMORY_KB, ARGON2_ITERATIONS,
+// ARGON2_PARALLELISM. Незаданные значения берутся из password.DefaultPolicy и DefaultParams.
+func PasswordCfgLoad() (*PasswordConfig, error) {
+	config := &PasswordConfig{
+		Policy:       password.DefaultPolicy,
+		Hash:         password.DefaultParams,
+		BreachedFile: os.Getenv("PASSWORD_BREACHED_FILE"),
+	}
+
+	ints := []struct {
+		env  string
+		bits int
+		set  func(uint64)
+	}{
+		{"PASSWORD_MIN_LENGTH", 16, func(v uint64) { config.Policy.MinLength = int(v) }},
+		{"PASSWORD_MAX_LENGTH", 16, func(v uint64) { config.Policy.MaxLength = int(v) }},
+		{"ARGON2_MEMORY_KB", 32, func(v uint64) { config.Hash.Memory = uint32(v) }},
+		{"ARGON2_ITERATIONS", 32, func(v uint64) { config.Hash.Iterations = uint32(v) }},
+		{"ARGON2_PARALLELISM", 8, func(v uint64) { config.Hash.Parallelism = uint8(v) }},
+	}
+	for _, i := range ints {
+		raw := os.Getenv(i.env)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseUint(raw, 10, i.bits)
+		if err != nil || v == 0 {
+			return nil, fmt.Errorf("%s: expected positive integer, got %q", i.env, raw)
+		}
+		i.set(v)
+	}
+
+	if raw := os.Getenv("PASSWORD_REJECT_SIMILAR"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("PASSWORD_REJECT_SIMILAR: %w", err)
+		}
+		config.Policy.RejectSimilar = v
+	}
+	if config.Policy.MaxLength < config.Policy.MinLength {
+		return nil, fmt.Errorf("PASSWORD_MAX_LENGTH must not be less than PASSWORD_MIN_LENGTH")
+	}
+	return config, nil
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+type RedisConfig struct {
+	Addr     string
+	Password string
+	RedisDb  int
+}
+
+func RedisCfgLoad() (*RedisConfig, error) {
+	db, _ := strconv.Atoi(os.Getenv("REDIS_DB"))
+
+	config := &RedisConfig{
+		Addr:     os.Getenv("REDIS_ADDR"),
+		Password: os.Getenv("REDIS_PASSWORD"),
+		RedisDb:  db,
+	}
+	return config, nil
+}
@@ -90,6 +90,36 @@ func NewFriendEvent(userId, friendId int64, action string) *models.FriendEvent {
 	}
 }
 
+func NewAccountSecurityChangedEvent(userId int64, change string) *models.AccountSecurityChangedEvent {
+	return &models.AccountSecurityChangedEvent{
+		BaseEvent: models.BaseEvent{
+			EventId:   generateEventID(),
+			EventType: models.EventAccountSecurityChanged,
+			UserId:    userId,
+			Timestamp: time.Now().UTC(),
+			Service:   "profile-service",
+			Version:   "1.0",
+		},
+		Change: change,
+	}
+}
+
+func NewLoginFailedEvent(userId int64, username, ip, reason string) *models.LoginFailedEvent {
+	return &models.LoginFailedEvent{
+		BaseEvent: models.BaseEvent{
+			EventId:   generateEventID(),
+			EventType: models.EventLoginFailed,
+			UserId:    userId,
+			Timestamp: time.Now().UTC(),
+			Service:   "profile-service",
+			Version:   "1.0",
+		},
+		Username: username,
+		Ip:       ip,
+		Reason:   reason,
+	}
+}
+
 func generateEventID() string {
 	return time.Now().Format("20060102150405") + "-" + randomString(8)
 }
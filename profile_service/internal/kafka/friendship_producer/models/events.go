@@ -17,6 +17,9 @@ const (
 	EventFriendRemoved          EventType = "friend_removed"
 	EventUserBlocked            EventType = "user_blocked"
 	EventUserUnblocked          EventType = "user_unblocked"
+
+	EventAccountSecurityChanged EventType = "account_security_changed"
+	EventLoginFailed            EventType = "login_failed"
 )
 
 // BaseEvent базовое событие для всех событий
@@ -87,3 +90,25 @@ func (e *FriendEvent) GetEventType() string {
 func (e *BlockEvent) GetEventType() string {
 	return string(e.EventType)
 }
+
+// AccountSecurityChangedEvent — сменились пароль, email или настройки 2FA
+type AccountSecurityChangedEvent struct {
+	BaseEvent
+	Change string `json:"change"` // password_changed/password_reset/email_changed/mfa_enabled/mfa_disabled/identity_linked
+}
+
+func (e *AccountSecurityChangedEvent) GetEventType() string {
+	return string(e.EventType)
+}
+
+// LoginFailedEvent — неудачная попытка входа; UserId = 0, если имя не найдено
+type LoginFailedEvent struct {
+	BaseEvent
+	Username string `json:"username"`
+	Ip       string `json:"ip,omitempty"`
+	Reason   string `json:"reason"` // unknown_user/bad_password/bad_mfa_code/throttled
+}
+
+func (e *LoginFailedEvent) GetEventType() string {
+	return string(e.EventType)
+}
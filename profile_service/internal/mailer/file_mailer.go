@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FileMailer дописывает письма в файл в формате mbox: ссылки из писем
+// можно открыть локально без почтового сервера.
+type FileMailer struct {
+	mu   sync.Mutex
+	path string
+	from string
+}
+
+func NewFileMailer(path, from string) *FileMailer {
+	return &FileMailer{path: path, from: from}
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString("From " + m.from + "\n"); err != nil {
+		return err
+	}
+	_, err = f.Write(append(format(m.from, msg), '\n'))
+	return err
+}
+
+// LogMailer только пишет письмо в лог. Тело содержит одноразовые ссылки,
+// поэтому годится лишь для локального запуска.
+type LogMailer struct {
+	log *logrus.Logger
+}
+
+func NewLogMailer(log *logrus.Logger) *LogMailer {
+	return &LogMailer{log: log}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.log.WithFields(logrus.Fields{
+		"to":      msg.To,
+		"subject": msg.Subject,
+		"body":    msg.Body,
+	}).Info("Mail (not sent)")
+	return nil
+}
@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var ErrQueueFull = errors.New("mail queue is full")
+
+// Queue отправляет письма в фоне фиксированным числом воркеров. Send не ждёт
+// отправки и не блокируется: если очередь заполнена, письмо отклоняется.
+type Queue struct {
+	mailer  Mailer
+	jobs    chan Message
+	timeout time.Duration
+	log     *logrus.Entry
+	wg      sync.WaitGroup
+}
+
+func NewQueue(mailer Mailer, size, workers int, timeout time.Duration, log *logrus.Entry) *Queue {
+	q := &Queue{
+		mailer:  mailer,
+		jobs:    make(chan Message, size),
+		timeout: timeout,
+		log:     log,
+	}
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+func (q *Queue) Send(ctx context.Context, msg Message) error {
+	select {
+	case q.jobs <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close дожидается отправки писем, уже стоящих в очереди.
+func (q *Queue) Close() {
+	close(q.jobs)
+	q.wg.Wait()
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for msg := range q.jobs {
+		ctx, cancel := context.WithTimeout(context.Background(), q.timeout)
+		if err := q.mailer.Send(ctx, msg); err != nil {
+			q.log.WithFields(logrus.Fields{"error": err, "subject": msg.Subject}).Error("Failed to send queued email")
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// blockingMailer держит отправку, пока тест не отпустит release.
+type blockingMailer struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (m *blockingMailer) Send(ctx context.Context, msg Message) error {
+	m.started <- struct{}{}
+	<-m.release
+	return nil
+}
+
+func TestQueueRejectsWhenFull(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	m := &blockingMailer{started: make(chan struct{}, 4), release: make(chan struct{})}
+	q := NewQueue(m, 1, 1, time.Second, logrus.NewEntry(log))
+	ctx := context.Background()
+
+	// первое письмо занимает единственного воркера, второе ждёт в очереди
+	if err := q.Send(ctx, Message{To: "a@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	<-m.started
+	if err := q.Send(ctx, Message{To: "b@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Send(ctx, Message{To: "c@example.com"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected full queue, got %v", err)
+	}
+
+	close(m.release)
+	q.Close()
+	if len(m.started) != 1 {
+		t.Fatalf("queued mail must be sent before Close returns, got %d", len(m.started))
+	}
+}
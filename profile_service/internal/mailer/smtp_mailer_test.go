@@ -0,0 +1,94 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+)
+
+// smtpSink — минимальный SMTP-сервер вроде MailHog: принимает одно письмо и отдаёт его DATA.
+func smtpSink(t *testing.T) (string, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	data := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+		reply("220 sink")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 sink")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 go ahead")
+				var body strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					body.WriteString(l)
+				}
+				data <- body.String()
+				reply("250 queued")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), data
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	addr, data := smtpSink(t)
+	m := NewSMTPMailer(addr, "no-reply@test", "", "")
+
+	err := m.Send(context.Background(), Message{
+		To:      "alice@example.com",
+		Subject: "Подтвердите email",
+		Body:    "https://app/verify-email?token=abc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-data
+	for _, want := range []string{
+		"From: no-reply@test\r\n",
+		"To: alice@example.com\r\n",
+		"Subject: =?utf-8?q?",
+		"https://app/verify-email?token=abc",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("message has no %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestSMTPMailerRejectsHeaderInjection(t *testing.T) {
+	m := NewSMTPMailer("127.0.0.1:1", "no-reply@test", "", "")
+	if err := m.Send(context.Background(), Message{To: "a@example.com\r\nBcc: x@example.com"}); err == nil {
+		t.Fatal("recipient with CRLF must be rejected")
+	}
+}
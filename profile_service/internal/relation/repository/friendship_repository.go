@@ -187,6 +187,38 @@ func (f *FriendshipRepo) AreFriends(ctx context.Context, userId1, userId2 int64)
 	return count > 0, nil
 }
 
+// FriendsAmong возвращает тех из candidates, кто дружит с userId, одним запросом.
+func (f *FriendshipRepo) FriendsAmong(ctx context.Context, userId int64, candidates []int64) (map[int64]bool, error) {
+	friends := make(map[int64]bool, len(candidates))
+	if len(candidates) == 0 {
+		return friends, nil
+	}
+
+	var rows []models.Friend
+	err := f.db.WithContext(ctx).
+		Model(&models.Friend{}).
+		Select("user_id", "friend_id").
+		Where("(user_id = ? AND friend_id IN ?) OR (friend_id = ? AND user_id IN ?)",
+			userId, candidates, userId, candidates).
+		Find(&rows).Error
+
+	if err != nil {
+		f.log.WithFields(logrus.Fields{"error": err, "user_id": userId, "candidates": len(candidates)}).
+			Error("Failed to check friends among users")
+
+		return nil, fmt.Errorf("check friends among users error: %w", err)
+	}
+
+	for _, row := range rows {
+		if row.UserId == userId {
+			friends[row.FriendId] = true
+		} else {
+			friends[row.UserId] = true
+		}
+	}
+	return friends, nil
+}
+
 func (f *FriendshipRepo) GetFriendListWithPagination(ctx context.Context, userId int64, limit, offset int) ([]models.Friend, int64, error) {
 	var friends []models.Friend
 	var total int64
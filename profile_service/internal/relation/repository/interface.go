@@ -18,6 +18,7 @@ type FriendshipRepositoryInterface interface {
 	CreateFriend(ctx context.Context, friend *models.Friend) error
 	DeleteFriend(ctx context.Context, userId, friendId int64) error
 	AreFriends(ctx context.Context, userId1, userId2 int64) (bool, error)
+	FriendsAmong(ctx context.Context, userId int64, candidates []int64) (map[int64]bool, error)
 	GetFriendListWithPagination(ctx context.Context, userId int64, limit, offset int) ([]models.Friend, int64, error)
 	GetPendingRequestBySender(ctx context.Context, requestId, senderId int64) (*models.FriendRequest, error)
 
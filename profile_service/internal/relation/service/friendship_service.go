@@ -45,6 +45,15 @@ func (f *FriendshipService) SendFriendRequest(ctx context.Context, receiverId in
 		return 0, middleware_profile.NewCustomError(http.StatusUnauthorized, err.Error(), nil)
 	}
 
+	// неподтверждённые аккаунты не могут рассылать запросы: защита от спама с одноразовых адресов
+	sender, err := f.userService.GetUserById(ctx, senderId)
+	if err != nil {
+		return 0, helpers.ErrUserNotFound
+	}
+	if !sender.EmailVerified {
+		return 0, helpers.ErrEmailNotVerified
+	}
+
 	_, err = f.userService.GetUserById(ctx, receiverId)
 	if err != nil {
 		return 0, helpers.ErrUserNotFound
@@ -16,5 +16,6 @@ var (
 	ErrCannotBlockYourself   = errors.New("cannot block yourself")
 	ErrCannotUnblockYourself = errors.New("cannot unblock yourself")
 	ErrCannotFriendYourself  = errors.New("cannot send friend request to yourself")
+	ErrEmailNotVerified      = errors.New("confirm your email to send friend requests")
 	ErrCannotDeleteYourself  = errors.New("cannot remove yourself from friend list")
 )
@@ -1,39 +1,192 @@
 package cache
 
 import (
-	"github.com/jellydator/ttlcache/v3"
+	"context"
 	"profile_service/pkg/grpc_generated/chat"
-	"strconv"
+	"sync"
 	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	minReconnectDelay = 500 * time.Millisecond
+	maxReconnectDelay = 30 * time.Second
+
+	// entryTTL ограничивает размер кэша: подписка присылает события по всем
+	// пользователям, но хранятся только те, кого недавно запрашивали.
+	entryTTL = 5 * time.Minute
 )
 
+// presenceEntry — запись кэша; resp == nil, пока ответ GetPresence не получен.
+type presenceEntry struct {
+	resp      *chat.GetPresenceResponse
+	epoch     uint64
+	expiresAt time.Time
+}
+
+// PresenceCache хранит presence, полученный от chat_service, и держит подписку
+// WatchPresence: событие по закэшированному пользователю удаляет его запись,
+// и следующий Get идёт в chat_service; записи удаляются через entryTTL после запроса.
+// Пока подписка не установлена, кэш пуст и не принимает записи — иначе
+// пропущенные события оставили бы в нём устаревшие статусы.
 type PresenceCache struct {
-	cache *ttlcache.Cache[string, *chat.GetPresenceResponse]
+	client chat.PresenceClient
+	log    *logrus.Logger
+
+	mu        sync.RWMutex
+	entries   map[int64]*presenceEntry
+	synced    bool
+	epoch     uint64
+	lastSweep time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
 }
 
-func NewPresenceCache(ttl time.Duration) *PresenceCache {
-	c := ttlcache.New[string, *chat.GetPresenceResponse](
-		ttlcache.WithTTL[string, *chat.GetPresenceResponse](ttl),
-	)
-	go c.Start()
-	return &PresenceCache{cache: c}
+func NewPresenceCache(client chat.PresenceClient, log *logrus.Logger) *PresenceCache {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	pc := &PresenceCache{
+		client:  client,
+		log:     log,
+		entries: make(map[int64]*presenceEntry),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go pc.watch(ctx)
+
+	return pc
 }
 
 func (pc *PresenceCache) Get(userId int64) (*chat.GetPresenceResponse, bool) {
-	key := strconv.FormatInt(userId, 10)
-	item := pc.cache.Get(key)
-	if item != nil {
-		return item.Value(), true
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
+	entry, ok := pc.entries[userId]
+	if !ok || entry.resp == nil || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.resp, true
+}
+
+// Reserve вызывается до запроса в chat_service: создаёт пустую запись, чтобы
+// события за время запроса не потерялись, и возвращает её эпоху для Set.
+// Эпоха новая у каждой созданной записи и после переподключения подписки,
+// так что ответ на запрос, начатый до события или сброса кэша, не сохранится.
+func (pc *PresenceCache) Reserve(userId int64) uint64 {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if !pc.synced {
+		return pc.epoch
 	}
 
-	return nil, false
+	now := time.Now()
+	pc.sweep(now)
+	entry, ok := pc.entries[userId]
+	if !ok || now.After(entry.expiresAt) {
+		pc.epoch++
+		entry = &presenceEntry{epoch: pc.epoch, expiresAt: now.Add(entryTTL)}
+		pc.entries[userId] = entry
+	}
+	return entry.epoch
 }
 
-func (pc *PresenceCache) Set(userId int64, resp *chat.GetPresenceResponse) {
-	key := strconv.FormatInt(userId, 10)
-	pc.cache.Set(key, resp, ttlcache.DefaultTTL)
+// Set сохраняет ответ GetPresence. Если за время запроса пришло событие,
+// запись уже удалена и ответ, возможно устаревший, не сохраняется.
+func (pc *PresenceCache) Set(userId int64, resp *chat.GetPresenceResponse, epoch uint64) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if !pc.synced {
+		return
+	}
+	if entry, ok := pc.entries[userId]; ok && entry.epoch == epoch && entry.resp == nil {
+		entry.resp = resp
+	}
+}
+
+// sweep удаляет истёкшие записи не чаще раза в entryTTL.
+func (pc *PresenceCache) sweep(now time.Time) {
+	if now.Sub(pc.lastSweep) < entryTTL {
+		return
+	}
+	pc.lastSweep = now
+
+	for userId, entry := range pc.entries {
+		if now.After(entry.expiresAt) {
+			delete(pc.entries, userId)
+		}
+	}
 }
 
 func (pc *PresenceCache) Stop() {
-	pc.cache.Stop()
+	pc.cancel()
+	<-pc.done
+}
+
+func (pc *PresenceCache) watch(ctx context.Context) {
+	defer close(pc.done)
+
+	delay := minReconnectDelay
+
+	for {
+		established, err := pc.consume(ctx)
+		pc.reset(false)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if established {
+			delay = minReconnectDelay
+		}
+
+		pc.log.WithError(err).Warnf("presence watch interrupted, reconnecting in %s", delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+func (pc *PresenceCache) consume(ctx context.Context) (bool, error) {
+	stream, err := pc.client.WatchPresence(ctx, &chat.WatchPresenceRequest{})
+	if err != nil {
+		return false, err
+	}
+
+	pc.reset(true)
+	pc.log.Info("presence watch established")
+
+	for {
+		update, err := stream.Recv()
+		if err != nil {
+			return true, err
+		}
+		pc.apply(update)
+	}
+}
+
+// apply инвалидирует запись: подписка на всех пользователей присылает только
+// user_id и тип события, без самого presence.
+func (pc *PresenceCache) apply(update *chat.PresenceUpdate) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	delete(pc.entries, update.UserId)
+}
+
+func (pc *PresenceCache) reset(synced bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.epoch++
+	pc.synced = synced
+	pc.entries = make(map[int64]*presenceEntry)
 }
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"profile_service/pkg/grpc_generated/chat"
+	"testing"
+)
+
+func newSyncedCache() *PresenceCache {
+	return &PresenceCache{entries: make(map[int64]*presenceEntry), synced: true}
+}
+
+func TestPresenceEventInvalidatesEntry(t *testing.T) {
+	pc := newSyncedCache()
+
+	epoch := pc.Reserve(1)
+	pc.Set(1, &chat.GetPresenceResponse{UserId: 1, Status: "online"}, epoch)
+	if _, ok := pc.Get(1); !ok {
+		t.Fatal("expected cached presence")
+	}
+
+	// подписка на всех пользователей присылает событие без presence
+	pc.apply(&chat.PresenceUpdate{Event: "user_offline", UserId: 1})
+	if _, ok := pc.Get(1); ok {
+		t.Fatal("event must invalidate cached presence")
+	}
+}
+
+func TestResponseStartedBeforeEventIsNotCached(t *testing.T) {
+	pc := newSyncedCache()
+
+	stale := pc.Reserve(1)
+	pc.apply(&chat.PresenceUpdate{Event: "user_online", UserId: 1})
+	fresh := pc.Reserve(1)
+
+	pc.Set(1, &chat.GetPresenceResponse{UserId: 1, Status: "offline"}, stale)
+	if _, ok := pc.Get(1); ok {
+		t.Fatal("response requested before the event must not be cached")
+	}
+
+	pc.Set(1, &chat.GetPresenceResponse{UserId: 1, Status: "online"}, fresh)
+	if resp, ok := pc.Get(1); !ok || resp.Status != "online" {
+		t.Fatalf("expected fresh presence, got %v", resp)
+	}
+}
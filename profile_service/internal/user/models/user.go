@@ -6,6 +6,21 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	LastSeenEveryone = "everyone"
+	LastSeenFriends  = "friends"
+	LastSeenNobody   = "nobody"
+)
+
+// Источники подтверждения email.
+const (
+	EmailVerifiedLink     = "link"     // ссылкой из письма
+	EmailVerifiedProvider = "provider" // OIDC-провайдером при создании аккаунта
+	// EmailVerifiedLegacy — проставлено миграцией аккаунтам, созданным до появления
+	// подтверждения; владение адресом не проверялось.
+	EmailVerifiedLegacy = "legacy"
+)
+
 type User struct {
 	Id        int64          `gorm:"primaryKey;autoIncrement;column:id"`
 	Username  string         `gorm:"type:text;not null;uniqueIndex:username_unique"`
@@ -14,6 +29,11 @@ type User struct {
 	CreatedAt time.Time      `gorm:"type:timestamp with time zone;default:now()"`
 	UpdatedAt time.Time      `gorm:"type:timestamp with time zone;default:now()"`
 	DeletedAt gorm.DeletedAt `gorm:"type:timestamp with time zone;index"`
+
+	LastSeenVisibility string `gorm:"column:last_seen_visibility;type:varchar(16);not null;default:'everyone'"`
+
+	EmailVerifiedAt *time.Time `gorm:"column:email_verified_at;type:timestamp with time zone"`
+	EmailVerifiedBy string     `gorm:"column:email_verified_by;type:varchar(16);not null;default:''"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) error {
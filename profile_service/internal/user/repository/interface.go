@@ -9,6 +9,9 @@ import (
 type ProfileRepoInterface interface {
 	Create(ctx context.Context, user *uModel.User) (*uModel.User, error)
 	GetById(ctx context.Context, id int64) (*uModel.User, error)
+	GetByEmail(ctx context.Context, email string) (*uModel.User, error)
+	GetByUsername(ctx context.Context, username string) (*uModel.User, error)
+	UpdatePassword(ctx context.Context, id int64, passwordHash string) error
 	GetAll(ctx context.Context, filter service_dto.SearchUserFilter) (int, []*uModel.User, error)
 	Update(ctx context.Context, id int64, user *uModel.User) (*uModel.User, error)
 	Delete(ctx context.Context, id int64) error
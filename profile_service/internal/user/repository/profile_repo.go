@@ -71,6 +71,53 @@ func (u *ProfileRepo) GetById(ctx context.Context, id int64) (*models.User, erro
 	return &person, nil
 }
 
+// GetByEmail ищет пользователя по точному совпадению адреса; nil, если такого нет.
+func (u *ProfileRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	var person models.User
+
+	err := u.db.WithContext(ctx).Where("email = ?", email).First(&person).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		u.log.WithFields(logrus.Fields{"error": err}).Error("Failed to get user by email")
+		return nil, fmt.Errorf("get user by email error: %w", err)
+	}
+
+	return &person, nil
+}
+
+// GetByUsername ищет пользователя по точному совпадению имени; nil, если такого нет.
+func (u *ProfileRepo) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	var person models.User
+
+	err := u.db.WithContext(ctx).Where("username = ?", username).First(&person).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		u.log.WithFields(logrus.Fields{"error": err}).Error("Failed to get user by username")
+		return nil, fmt.Errorf("get user by username error: %w", err)
+	}
+
+	return &person, nil
+}
+
+func (u *ProfileRepo) UpdatePassword(ctx context.Context, id int64, passwordHash string) error {
+	res := u.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", id).
+		Update("password", passwordHash)
+	if res.Error != nil {
+		u.log.WithFields(logrus.Fields{"error": res.Error, "id": id}).Error("Failed to update password")
+		return fmt.Errorf("update password error: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 func (u *ProfileRepo) GetAll(ctx context.Context, filter service_dto.SearchUserFilter) (int, []*models.User, error) {
 	if filter.Limit < 0 || filter.Offset < 0 {
 		u.log.WithFields(logrus.Fields{"limit": filter.Limit, "offset": filter.Offset}).Error("Invalid pagination params")
@@ -160,6 +207,9 @@ func (u *ProfileRepo) Update(ctx context.Context, id int64, person *models.User)
 	if person.Email != "" {
 		updates["email"] = person.Email
 	}
+	if person.LastSeenVisibility != "" {
+		updates["last_seen_visibility"] = person.LastSeenVisibility
+	}
 
 	if len(updates) == 0 {
 		u.log.WithFields(logrus.Fields{"id": id}).Info("No fields to update")
@@ -27,6 +27,15 @@ func ValidateUserForCreate(u *service_dto.CreateUserRequest) error {
 	return nil
 }
 
+func ValidateLastSeenVisibility(visibility string) error {
+	switch visibility {
+	case models.LastSeenEveryone, models.LastSeenFriends, models.LastSeenNobody:
+		return nil
+	default:
+		return fmt.Errorf("invalid last seen visibility: %q", visibility)
+	}
+}
+
 func ValidateUserForUpdate(u *models.User) error {
 	if u == nil {
 		return errors.New("userModel is nil")
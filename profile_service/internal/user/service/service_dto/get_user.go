@@ -11,7 +11,17 @@ type GetUserResponse struct {
 	Password  string
 	Status    string
 	LastSeen  time.Time
+	Devices   []DevicePresence
 	CreatedAt time.Time
+
+	EmailVerified bool
+}
+
+type DevicePresence struct {
+	Device       string
+	AppVersion   string
+	Status       string
+	LastActivity time.Time
 }
 
 type GetUserViewListResponse struct {
@@ -0,0 +1,5 @@
+package service_dto
+
+type PrivacySettings struct {
+	LastSeenVisibility string
+}
@@ -2,5 +2,4 @@ package service_dto
 
 type UpdateUserRequest struct {
 	Username *string
-	Email    *string
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"google.golang.org/protobuf/types/known/timestamppb"
 	"net/http"
 	"os"
 	"profile_service/internal/user/cache"
@@ -22,14 +21,16 @@ import (
 )
 
 type UserService struct {
-	uRepo repository.ProfileRepoInterface
-	log   *logrus.Logger
+	uRepo         repository.ProfileRepoInterface
+	friendChecker FriendChecker
+	log           *logrus.Logger
 
 	presenceClient *grpc_client.PresenceClient
 	presenceCache  *cache.PresenceCache
 }
 
 func NewUserService(uRepo repository.ProfileRepoInterface,
+	friendChecker FriendChecker,
 	presenceClient *grpc_client.PresenceClient,
 	presenceCache *cache.PresenceCache,
 	log *logrus.Logger) UserServiceInterface {
@@ -41,13 +42,20 @@ func NewUserService(uRepo repository.ProfileRepoInterface,
 	}
 	return &UserService{
 		uRepo:          uRepo,
+		friendChecker:  friendChecker,
 		log:            log,
 		presenceClient: presenceClient,
 		presenceCache:  presenceCache,
 	}
 }
 
+// GetUserById — для внутренних вызовов без зрителя: last seen виден,
+// только если владелец открыл его всем.
 func (u *UserService) GetUserById(ctx context.Context, userId int64) (*service_dto.GetUserResponse, error) {
+	return u.GetUserByIdForViewer(ctx, 0, userId)
+}
+
+func (u *UserService) GetUserByIdForViewer(ctx context.Context, viewerId, userId int64) (*service_dto.GetUserResponse, error) {
 	u.log.Debugf("GetUserById %v", userId)
 	if err := helpers.ValidateUserId(userId); err != nil {
 		return nil, middleware_profile.NewCustomError(http.StatusBadRequest, fmt.Sprintf("Validation error %v", userId), err)
@@ -61,15 +69,28 @@ func (u *UserService) GetUserById(ctx context.Context, userId int64) (*service_d
 		return nil, fmt.Errorf("user with id %d not found", userId)
 	}
 
-	status, lastSeen := u.getPresenceWithCache(ctx, userId)
+	presence := u.getPresenceWithCache(ctx, userId)
+
+	lastSeenVisible, err := u.canViewLastSeen(ctx, viewerId, user)
+	if err != nil {
+		u.log.Warnf("failed to check last seen visibility for user %d: %v", userId, err)
+	}
 
 	response := &service_dto.GetUserResponse{
 		Id:        user.Id,
 		Name:      user.Username,
 		Email:     user.Email,
-		Status:    status,
-		LastSeen:  lastSeen,
+		Status:    presence.Status,
+		Devices:   convertDevices(presence.Devices),
 		CreatedAt: user.CreatedAt,
+
+		EmailVerified: user.EmailVerifiedAt != nil,
+	}
+	if presence.LastSeen != nil {
+		response.LastSeen = presence.LastSeen.AsTime()
+	}
+	if !lastSeenVisible {
+		hideLastSeen(response)
 	}
 	return response, nil
 }
@@ -118,6 +139,31 @@ func (u *UserService) GetUserByIds(ctx context.Context, userIds []int64) ([]*ser
 	return userList, nil
 }
 
+func (u *UserService) GetCredentials(ctx context.Context, username string) (*service_dto.GetUserResponse, error) {
+	user, err := u.uRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, u.handleError(err, 0, "GetCredentials")
+	}
+	if user == nil {
+		return nil, nil
+	}
+	return &service_dto.GetUserResponse{
+		Id:       user.Id,
+		Name:     user.Username,
+		Email:    user.Email,
+		Password: user.Password,
+
+		EmailVerified: user.EmailVerifiedAt != nil,
+	}, nil
+}
+
+func (u *UserService) UpdatePasswordHash(ctx context.Context, userId int64, passwordHash string) error {
+	if err := u.uRepo.UpdatePassword(ctx, userId, passwordHash); err != nil {
+		return u.handleError(err, userId, "UpdatePasswordHash")
+	}
+	return nil
+}
+
 func (u *UserService) GetAllUsers(ctx context.Context, filter service_dto.SearchUserFilter) (*service_dto.GetUserViewListResponse, error) {
 	u.log.Debugf("GetAllUsers")
 	if filter.Limit > 50 {
@@ -179,9 +225,6 @@ func (u *UserService) UpdateUser(ctx context.Context, userId int64, req *service
 	if req.Username != nil {
 		currentUser.Username = *req.Username
 	}
-	if req.Email != nil {
-		currentUser.Email = *req.Email
-	}
 	if err := helpers.ValidateUserForUpdate(currentUser); err != nil {
 		return middleware_profile.NewCustomError(http.StatusBadRequest, "Validation error", err)
 	}
@@ -218,43 +261,138 @@ func (u *UserService) handleError(err error, id int64, operation string) error {
 	return middleware_profile.NewCustomError(http.StatusInternalServerError, fmt.Sprintf("%s error", operation), err)
 }
 
-func (u *UserService) getPresenceWithCache(ctx context.Context, userId int64) (string, time.Time) {
-	if cached, found := u.presenceCache.Get(userId); found {
-		lastSeen := time.Time{}
-		if cached.LastSeen != nil {
-			lastSeen = cached.LastSeen.AsTime()
+func (u *UserService) GetPrivacySettings(ctx context.Context, userId int64) (*service_dto.PrivacySettings, error) {
+	user, err := u.uRepo.GetById(ctx, userId)
+	if err != nil {
+		return nil, u.handleError(err, userId, "GetPrivacySettings")
+	}
+	if user == nil {
+		return nil, middleware_profile.NewCustomError(http.StatusNotFound, fmt.Sprintf("User %d not found", userId), nil)
+	}
+
+	return &service_dto.PrivacySettings{
+		LastSeenVisibility: user.LastSeenVisibility,
+	}, nil
+}
+
+func (u *UserService) UpdatePrivacySettings(ctx context.Context, userId int64, req *service_dto.PrivacySettings) error {
+	if err := helpers.ValidateLastSeenVisibility(req.LastSeenVisibility); err != nil {
+		return middleware_profile.NewCustomError(http.StatusBadRequest, "Validation error", err)
+	}
+
+	user, err := u.uRepo.Update(ctx, userId, &models.User{LastSeenVisibility: req.LastSeenVisibility})
+	if err != nil {
+		return u.handleError(err, userId, "UpdatePrivacySettings")
+	}
+	if user == nil {
+		return middleware_profile.NewCustomError(http.StatusNotFound, fmt.Sprintf("User %d not found", userId), nil)
+	}
+
+	return nil
+}
+
+// FilterLastSeenVisible возвращает тех пользователей из userIds, чей last seen виден viewerId.
+func (u *UserService) FilterLastSeenVisible(ctx context.Context, viewerId int64, userIds []int64) ([]int64, error) {
+	if len(userIds) == 0 {
+		return []int64{}, nil
+	}
+
+	users, err := u.uRepo.GetByIds(ctx, userIds)
+	if err != nil {
+		return nil, u.handleError(err, viewerId, "FilterLastSeenVisible")
+	}
+
+	// дружбу проверяем одним запросом только для тех, кто открыл last seen друзьям
+	var friendsOnly []int64
+	for _, user := range users {
+		if user.LastSeenVisibility == models.LastSeenFriends && user.Id != viewerId {
+			friendsOnly = append(friendsOnly, user.Id)
+		}
+	}
+	friends := map[int64]bool{}
+	if viewerId > 0 && len(friendsOnly) > 0 {
+		friends, err = u.friendChecker.FriendsAmong(ctx, viewerId, friendsOnly)
+		if err != nil {
+			return nil, err
 		}
-		return cached.Status, lastSeen
 	}
 
-	status, lastSeen := u.fetchPresence(ctx, userId)
+	visible := make([]int64, 0, len(users))
+	for _, user := range users {
+		if lastSeenVisible(viewerId, user, friends[user.Id]) {
+			visible = append(visible, user.Id)
+		}
+	}
+
+	return visible, nil
+}
+
+func (u *UserService) canViewLastSeen(ctx context.Context, viewerId int64, owner *models.User) (bool, error) {
+	friends := false
+	if owner.LastSeenVisibility == models.LastSeenFriends && viewerId > 0 && viewerId != owner.Id {
+		var err error
+		friends, err = u.friendChecker.AreFriends(ctx, viewerId, owner.Id)
+		if err != nil {
+			return false, err
+		}
+	}
+	return lastSeenVisible(viewerId, owner, friends), nil
+}
 
-	resp := &chat.GetPresenceResponse{
-		UserId:   userId,
-		Status:   status,
-		LastSeen: nil,
+func lastSeenVisible(viewerId int64, owner *models.User, friends bool) bool {
+	if viewerId == owner.Id {
+		return true
 	}
-	if !lastSeen.IsZero() {
-		resp.LastSeen = timestamppb.New(lastSeen)
+
+	switch owner.LastSeenVisibility {
+	case models.LastSeenEveryone, "":
+		return true
+	case models.LastSeenFriends:
+		return viewerId > 0 && friends
+	default:
+		return false
 	}
-	u.presenceCache.Set(userId, resp)
+}
 
-	return status, lastSeen
+func hideLastSeen(resp *service_dto.GetUserResponse) {
+	resp.LastSeen = time.Time{}
+	for i := range resp.Devices {
+		resp.Devices[i].LastActivity = time.Time{}
+	}
 }
 
-func (u *UserService) fetchPresence(ctx context.Context, userId int64) (string, time.Time) {
-	callCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
-	defer cancel()
+func (u *UserService) getPresenceWithCache(ctx context.Context, userId int64) *chat.GetPresenceResponse {
+	if cached, found := u.presenceCache.Get(userId); found {
+		return cached
+	}
 
-	resp, err := u.presenceClient.GetPresence(callCtx, &chat.GetPresenceRequest{UserId: userId})
+	epoch := u.presenceCache.Reserve(userId)
+	resp, err := u.fetchPresence(ctx, userId)
 	if err != nil {
 		u.log.Warnf("failed to get presence for user %d: %v", userId, err)
-		return "offline", time.Time{}
+		return &chat.GetPresenceResponse{UserId: userId, Status: "offline"}
 	}
+	u.presenceCache.Set(userId, resp, epoch)
+
+	return resp
+}
 
-	lastSeen := time.Time{}
-	if resp.LastSeen != nil {
-		lastSeen = resp.LastSeen.AsTime()
+func (u *UserService) fetchPresence(ctx context.Context, userId int64) (*chat.GetPresenceResponse, error) {
+	callCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+
+	return u.presenceClient.GetPresence(callCtx, &chat.GetPresenceRequest{UserId: userId})
+}
+
+func convertDevices(devices []*chat.DevicePresence) []service_dto.DevicePresence {
+	result := make([]service_dto.DevicePresence, 0, len(devices))
+	for _, d := range devices {
+		result = append(result, service_dto.DevicePresence{
+			Device:       d.Device,
+			AppVersion:   d.AppVersion,
+			Status:       d.Status,
+			LastActivity: d.LastActivity.AsTime(),
+		})
 	}
-	return resp.Status, lastSeen
+	return result
 }
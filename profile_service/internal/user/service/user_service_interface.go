@@ -7,6 +7,12 @@ import (
 
 type UserServiceInterface interface {
 	GetUserById(ctx context.Context, userId int64) (*service_dto.GetUserResponse, error)
+	// GetUserByIdForViewer скрывает last seen, если viewerId не разрешено его видеть.
+	GetUserByIdForViewer(ctx context.Context, viewerId, userId int64) (*service_dto.GetUserResponse, error)
+	// GetCredentials отдаёт хэш пароля для входа; nil, если пользователя нет.
+	GetCredentials(ctx context.Context, username string) (*service_dto.GetUserResponse, error)
+	// UpdatePasswordHash заменяет хэш пароля, например при пересчёте устаревшего хэша.
+	UpdatePasswordHash(ctx context.Context, userId int64, passwordHash string) error
 	GetAllUsers(ctx context.Context, filter service_dto.SearchUserFilter) (*service_dto.GetUserViewListResponse, error)
 	CreateUser(ctx context.Context, req *service_dto.CreateUserRequest) (int64, error)
 	UpdateUser(ctx context.Context, id int64, req *service_dto.UpdateUserRequest) error
@@ -15,4 +21,16 @@ type UserServiceInterface interface {
 	//GetUserAuthTokens(ctx context.Context, userId int64) ([]string, error)
 
 	GetUserByIds(ctx context.Context, userIds []int64) ([]*service_dto.GetUserResponse, error)
+
+	GetPrivacySettings(ctx context.Context, userId int64) (*service_dto.PrivacySettings, error)
+	UpdatePrivacySettings(ctx context.Context, userId int64, req *service_dto.PrivacySettings) error
+	FilterLastSeenVisible(ctx context.Context, viewerId int64, userIds []int64) ([]int64, error)
+}
+
+// FriendChecker нужен для проверки настройки "last seen: friends".
+// Реализуется репозиторием дружбы; объявлен здесь, чтобы не создавать
+// цикл импортов user <-> relation.
+type FriendChecker interface {
+	AreFriends(ctx context.Context, userId1, userId2 int64) (bool, error)
+	FriendsAmong(ctx context.Context, userId int64, candidates []int64) (map[int64]bool, error)
 }
@@ -34,6 +34,7 @@ func NewAuthMiddleware(authServer profile.AuthServiceServer, log *logrus.Logger)
 		}
 
 		ctx.Set("user_id", resp.UserId)
+		ctx.Set("session_id", resp.SessionId)
 		ctx.Next()
 	}
 }
@@ -0,0 +1,3 @@
+-- +migrate Down
+
+ALTER TABLE users DROP COLUMN IF EXISTS last_seen_visibility;
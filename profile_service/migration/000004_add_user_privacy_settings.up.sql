@@ -0,0 +1,5 @@
+-- +migrate Up
+
+-- Кому виден "last seen": everyone, friends, nobody
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS last_seen_visibility VARCHAR(16) NOT NULL DEFAULT 'everyone';
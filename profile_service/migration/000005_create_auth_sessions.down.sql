@@ -0,0 +1,4 @@
+-- +migrate Down
+
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS auth_sessions;
@@ -0,0 +1,4 @@
+-- +migrate Down
+
+DROP TABLE IF EXISTS user_recovery_codes;
+DROP TABLE IF EXISTS user_mfa;
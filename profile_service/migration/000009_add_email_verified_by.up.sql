@@ -0,0 +1,13 @@
+-- +migrate Up
+
+-- Чем подтверждён email: link — ссылкой из письма, provider — OIDC-провайдером,
+-- legacy — проставлен миграцией 000006 без проверки владения адресом
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS email_verified_by VARCHAR(16) NOT NULL DEFAULT '';
+
+-- 000006 записала email_verified_at = created_at; при настоящем подтверждении они не совпадают
+UPDATE users SET email_verified_by = 'legacy'
+WHERE email_verified_at IS NOT NULL AND email_verified_at = created_at;
+
+UPDATE users SET email_verified_by = 'link'
+WHERE email_verified_at IS NOT NULL AND email_verified_by = '';
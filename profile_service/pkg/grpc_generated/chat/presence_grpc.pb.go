@@ -24,7 +24,10 @@ const (
 	Presence_OnDisconnect_FullMethodName     = "/chat.Presence/OnDisconnect"
 	Presence_OnHeartbeat_FullMethodName      = "/chat.Presence/OnHeartbeat"
 	Presence_GetPresence_FullMethodName      = "/chat.Presence/GetPresence"
+	Presence_GetPresenceBatch_FullMethodName = "/chat.Presence/GetPresenceBatch"
 	Presence_GetOnlineFriends_FullMethodName = "/chat.Presence/GetOnlineFriends"
+	Presence_WatchPresence_FullMethodName    = "/chat.Presence/WatchPresence"
+	Presence_DisconnectUser_FullMethodName   = "/chat.Presence/DisconnectUser"
 )
 
 // PresenceClient is the client API for Presence service.
@@ -39,8 +42,16 @@ type PresenceClient interface {
 	OnHeartbeat(ctx context.Context, in *OnHeartbeatRequest, opts ...grpc.CallOption) (*EmptyResponse, error)
 	// Получение presence пользователя
 	GetPresence(ctx context.Context, in *GetPresenceRequest, opts ...grpc.CallOption) (*GetPresenceResponse, error)
+	// Получение presence списка пользователей (не более 500) за один запрос к Redis
+	GetPresenceBatch(ctx context.Context, in *GetPresenceBatchRequest, opts ...grpc.CallOption) (*GetPresenceBatchResponse, error)
 	// Получение списка онлайн друзей
 	GetOnlineFriends(ctx context.Context, in *GetOnlineFriendsRequest, opts ...grpc.CallOption) (*GetOnlineFriendsResponse, error)
+	// Поток изменений presence (пустой user_ids — все пользователи, и тогда
+	// presence в событиях не заполняется: только user_id и event)
+	WatchPresence(ctx context.Context, in *WatchPresenceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PresenceUpdate], error)
+	// Принудительное закрытие WS-сессий пользователя (удаление аккаунта, отзыв токенов);
+	// с session_id закрываются только соединения одной сессии входа
+	DisconnectUser(ctx context.Context, in *DisconnectUserRequest, opts ...grpc.CallOption) (*EmptyResponse, error)
 }
 
 type presenceClient struct {
@@ -91,6 +102,16 @@ func (c *presenceClient) GetPresence(ctx context.Context, in *GetPresenceRequest
 	return out, nil
 }
 
+func (c *presenceClient) GetPresenceBatch(ctx context.Context, in *GetPresenceBatchRequest, opts ...grpc.CallOption) (*GetPresenceBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetPresenceBatchResponse)
+	err := c.cc.Invoke(ctx, Presence_GetPresenceBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *presenceClient) GetOnlineFriends(ctx context.Context, in *GetOnlineFriendsRequest, opts ...grpc.CallOption) (*GetOnlineFriendsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetOnlineFriendsResponse)
@@ -101,6 +122,35 @@ func (c *presenceClient) GetOnlineFriends(ctx context.Context, in *GetOnlineFrie
 	return out, nil
 }
 
+func (c *presenceClient) WatchPresence(ctx context.Context, in *WatchPresenceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PresenceUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Presence_ServiceDesc.Streams[0], Presence_WatchPresence_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchPresenceRequest, PresenceUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Presence_WatchPresenceClient = grpc.ServerStreamingClient[PresenceUpdate]
+
+func (c *presenceClient) DisconnectUser(ctx context.Context, in *DisconnectUserRequest, opts ...grpc.CallOption) (*EmptyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmptyResponse)
+	err := c.cc.Invoke(ctx, Presence_DisconnectUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PresenceServer is the server API for Presence service.
 // All implementations must embed UnimplementedPresenceServer
 // for forward compatibility.
@@ -113,8 +163,16 @@ type PresenceServer interface {
 	OnHeartbeat(context.Context, *OnHeartbeatRequest) (*EmptyResponse, error)
 	// Получение presence пользователя
 	GetPresence(context.Context, *GetPresenceRequest) (*GetPresenceResponse, error)
+	// Получение presence списка пользователей (не более 500) за один запрос к Redis
+	GetPresenceBatch(context.Context, *GetPresenceBatchRequest) (*GetPresenceBatchResponse, error)
 	// Получение списка онлайн друзей
 	GetOnlineFriends(context.Context, *GetOnlineFriendsRequest) (*GetOnlineFriendsResponse, error)
+	// Поток изменений presence (пустой user_ids — все пользователи, и тогда
+	// presence в событиях не заполняется: только user_id и event)
+	WatchPresence(*WatchPresenceRequest, grpc.ServerStreamingServer[PresenceUpdate]) error
+	// Принудительное закрытие WS-сессий пользователя (удаление аккаунта, отзыв токенов);
+	// с session_id закрываются только соединения одной сессии входа
+	DisconnectUser(context.Context, *DisconnectUserRequest) (*EmptyResponse, error)
 	mustEmbedUnimplementedPresenceServer()
 }
 
@@ -137,9 +195,18 @@ func (UnimplementedPresenceServer) OnHeartbeat(context.Context, *OnHeartbeatRequ
 func (UnimplementedPresenceServer) GetPresence(context.Context, *GetPresenceRequest) (*GetPresenceResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetPresence not implemented")
 }
+func (UnimplementedPresenceServer) GetPresenceBatch(context.Context, *GetPresenceBatchRequest) (*GetPresenceBatchResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetPresenceBatch not implemented")
+}
 func (UnimplementedPresenceServer) GetOnlineFriends(context.Context, *GetOnlineFriendsRequest) (*GetOnlineFriendsResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetOnlineFriends not implemented")
 }
+func (UnimplementedPresenceServer) WatchPresence(*WatchPresenceRequest, grpc.ServerStreamingServer[PresenceUpdate]) error {
+	return status.Error(codes.Unimplemented, "method WatchPresence not implemented")
+}
+func (UnimplementedPresenceServer) DisconnectUser(context.Context, *DisconnectUserRequest) (*EmptyResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DisconnectUser not implemented")
+}
 func (UnimplementedPresenceServer) mustEmbedUnimplementedPresenceServer() {}
 func (UnimplementedPresenceServer) testEmbeddedByValue()                  {}
 
@@ -233,6 +300,24 @@ func _Presence_GetPresence_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Presence_GetPresenceBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPresenceBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PresenceServer).GetPresenceBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Presence_GetPresenceBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PresenceServer).GetPresenceBatch(ctx, req.(*GetPresenceBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Presence_GetOnlineFriends_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetOnlineFriendsRequest)
 	if err := dec(in); err != nil {
@@ -251,6 +336,35 @@ func _Presence_GetOnlineFriends_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Presence_WatchPresence_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchPresenceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PresenceServer).WatchPresence(m, &grpc.GenericServerStream[WatchPresenceRequest, PresenceUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Presence_WatchPresenceServer = grpc.ServerStreamingServer[PresenceUpdate]
+
+func _Presence_DisconnectUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DisconnectUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PresenceServer).DisconnectUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Presence_DisconnectUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PresenceServer).DisconnectUser(ctx, req.(*DisconnectUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Presence_ServiceDesc is the grpc.ServiceDesc for Presence service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -274,11 +388,25 @@ var Presence_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetPresence",
 			Handler:    _Presence_GetPresence_Handler,
 		},
+		{
+			MethodName: "GetPresenceBatch",
+			Handler:    _Presence_GetPresenceBatch_Handler,
+		},
 		{
 			MethodName: "GetOnlineFriends",
 			Handler:    _Presence_GetOnlineFriends_Handler,
 		},
+		{
+			MethodName: "DisconnectUser",
+			Handler:    _Presence_DisconnectUser_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchPresence",
+			Handler:       _Presence_WatchPresence_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "chat/presence.proto",
 }
@@ -230,6 +230,102 @@ func (x *CanJoinRoomResponse) GetReason() string {
 	return ""
 }
 
+type CanViewLastSeenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ViewerId      int64                  `protobuf:"varint,1,opt,name=viewer_id,json=viewerId,proto3" json:"viewer_id,omitempty"`
+	UserIds       []int64                `protobuf:"varint,2,rep,packed,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CanViewLastSeenRequest) Reset() {
+	*x = CanViewLastSeenRequest{}
+	mi := &file_profile_authz_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CanViewLastSeenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CanViewLastSeenRequest) ProtoMessage() {}
+
+func (x *CanViewLastSeenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_profile_authz_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CanViewLastSeenRequest.ProtoReflect.Descriptor instead.
+func (*CanViewLastSeenRequest) Descriptor() ([]byte, []int) {
+	return file_profile_authz_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CanViewLastSeenRequest) GetViewerId() int64 {
+	if x != nil {
+		return x.ViewerId
+	}
+	return 0
+}
+
+func (x *CanViewLastSeenRequest) GetUserIds() []int64 {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
+type CanViewLastSeenResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	AllowedUserIds []int64                `protobuf:"varint,1,rep,packed,name=allowed_user_ids,json=allowedUserIds,proto3" json:"allowed_user_ids,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CanViewLastSeenResponse) Reset() {
+	*x = CanViewLastSeenResponse{}
+	mi := &file_profile_authz_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CanViewLastSeenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CanViewLastSeenResponse) ProtoMessage() {}
+
+func (x *CanViewLastSeenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_profile_authz_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CanViewLastSeenResponse.ProtoReflect.Descriptor instead.
+func (*CanViewLastSeenResponse) Descriptor() ([]byte, []int) {
+	return file_profile_authz_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CanViewLastSeenResponse) GetAllowedUserIds() []int64 {
+	if x != nil {
+		return x.AllowedUserIds
+	}
+	return nil
+}
+
 var File_profile_authz_proto protoreflect.FileDescriptor
 
 const file_profile_authz_proto_rawDesc = "" +
@@ -248,10 +344,16 @@ const file_profile_authz_proto_rawDesc = "" +
 	"\aroom_id\x18\x02 \x01(\x03R\x06roomId\"G\n" +
 	"\x13CanJoinRoomResponse\x12\x18\n" +
 	"\aallowed\x18\x01 \x01(\bR\aallowed\x12\x16\n" +
-	"\x06reason\x18\x02 \x01(\tR\x06reason2\xb0\x01\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"P\n" +
+	"\x16CanViewLastSeenRequest\x12\x1b\n" +
+	"\tviewer_id\x18\x01 \x01(\x03R\bviewerId\x12\x19\n" +
+	"\buser_ids\x18\x02 \x03(\x03R\auserIds\"C\n" +
+	"\x17CanViewLastSeenResponse\x12(\n" +
+	"\x10allowed_user_ids\x18\x01 \x03(\x03R\x0eallowedUserIds2\x86\x02\n" +
 	"\x14AuthorizationService\x12N\n" +
 	"\rCanSendDirect\x12\x1d.profile.CanSendDirectRequest\x1a\x1e.profile.CanSendDirectResponse\x12H\n" +
-	"\vCanJoinRoom\x12\x1b.profile.CanJoinRoomRequest\x1a\x1c.profile.CanJoinRoomResponseB,Z*profile_service/pkg/grpc_generated/profileb\x06proto3"
+	"\vCanJoinRoom\x12\x1b.profile.CanJoinRoomRequest\x1a\x1c.profile.CanJoinRoomResponse\x12T\n" +
+	"\x0fCanViewLastSeen\x12\x1f.profile.CanViewLastSeenRequest\x1a .profile.CanViewLastSeenResponseB,Z*profile_service/pkg/grpc_generated/profileb\x06proto3"
 
 var (
 	file_profile_authz_proto_rawDescOnce sync.Once
@@ -265,20 +367,24 @@ func file_profile_authz_proto_rawDescGZIP() []byte {
 	return file_profile_authz_proto_rawDescData
 }
 
-var file_profile_authz_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_profile_authz_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_profile_authz_proto_goTypes = []any{
-	(*CanSendDirectRequest)(nil),  // 0: profile.CanSendDirectRequest
-	(*CanSendDirectResponse)(nil), // 1: profile.CanSendDirectResponse
-	(*CanJoinRoomRequest)(nil),    // 2: profile.CanJoinRoomRequest
-	(*CanJoinRoomResponse)(nil),   // 3: profile.CanJoinRoomResponse
+	(*CanSendDirectRequest)(nil),    // 0: profile.CanSendDirectRequest
+	(*CanSendDirectResponse)(nil),   // 1: profile.CanSendDirectResponse
+	(*CanJoinRoomRequest)(nil),      // 2: profile.CanJoinRoomRequest
+	(*CanJoinRoomResponse)(nil),     // 3: profile.CanJoinRoomResponse
+	(*CanViewLastSeenRequest)(nil),  // 4: profile.CanViewLastSeenRequest
+	(*CanViewLastSeenResponse)(nil), // 5: profile.CanViewLastSeenResponse
 }
 var file_profile_authz_proto_depIdxs = []int32{
 	0, // 0: profile.AuthorizationService.CanSendDirect:input_type -> profile.CanSendDirectRequest
 	2, // 1: profile.AuthorizationService.CanJoinRoom:input_type -> profile.CanJoinRoomRequest
-	1, // 2: profile.AuthorizationService.CanSendDirect:output_type -> profile.CanSendDirectResponse
-	3, // 3: profile.AuthorizationService.CanJoinRoom:output_type -> profile.CanJoinRoomResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
+	4, // 2: profile.AuthorizationService.CanViewLastSeen:input_type -> profile.CanViewLastSeenRequest
+	1, // 3: profile.AuthorizationService.CanSendDirect:output_type -> profile.CanSendDirectResponse
+	3, // 4: profile.AuthorizationService.CanJoinRoom:output_type -> profile.CanJoinRoomResponse
+	5, // 5: profile.AuthorizationService.CanViewLastSeen:output_type -> profile.CanViewLastSeenResponse
+	3, // [3:6] is the sub-list for method output_type
+	0, // [0:3] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -295,7 +401,7 @@ func file_profile_authz_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_profile_authz_proto_rawDesc), len(file_profile_authz_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
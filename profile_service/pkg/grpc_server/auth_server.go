@@ -5,28 +5,72 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"profile_service/internal/auth/keys"
+	"profile_service/internal/auth/mfa"
+	"profile_service/internal/auth/password"
+	"profile_service/internal/auth/revocation"
+	authService "profile_service/internal/auth/service"
+	"profile_service/internal/auth/throttle"
+	"profile_service/internal/kafka/friendship_producer"
 	"profile_service/internal/user/service"
 	"profile_service/internal/user/service/service_dto"
 	"profile_service/middleware_profile"
+	"profile_service/pkg/grpc_generated/chat"
 	"profile_service/pkg/grpc_generated/profile"
+	"shared-auth/revoked"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
+const tokenTTL = time.Hour
+
+// Причины неудачного входа в событии login_failed
+const (
+	loginUnknownUser = "unknown_user"
+	loginBadPassword = "bad_password"
+	loginBadMfaCode  = "bad_mfa_code"
+	loginThrottled   = "throttled"
+)
+
+var errInvalidCredentials = status.Error(codes.Unauthenticated, "Invalid credentials")
+
 type AuthServer struct {
 	log *logrus.Logger
 	profile.UnimplementedAuthServiceServer
-	uService  service.UserServiceInterface
+	uService service.UserServiceInterface
+	sessions authService.SessionServiceInterface
+	revoked  revocation.Store
+	limiter  throttle.LoginLimiter
+	events   authService.EventSender
+	hasher   *password.Hasher
+	policy   *password.Policy
+	mfa      authService.MfaServiceInterface
+	mfaLogin mfa.ChallengeStore
+	external authService.ExternalAuthServiceInterface
+	presence chat.PresenceClient
+	keys     *keys.KeyRing
+	// jwtSecret проверяет HS256-токены, выпущенные до перехода на RS256; пустой — не принимать их
 	jwtSecret string
+
+	// dummyHash сравнивается с паролем неизвестного пользователя, чтобы
+	// время ответа не выдавало, существует ли имя
+	dummyOnce sync.Once
+	dummyHash string
 }
 
-func NewAuthServer(log *logrus.Logger, uService service.UserServiceInterface, jwtSecret string) *AuthServer {
+func NewAuthServer(log *logrus.Logger, uService service.UserServiceInterface, sessions authService.SessionServiceInterface,
+	revoked revocation.Store, limiter throttle.LoginLimiter, events authService.EventSender,
+	hasher *password.Hasher, policy *password.Policy, mfaService authService.MfaServiceInterface, mfaLogin mfa.ChallengeStore, external authService.ExternalAuthServiceInterface,
+	presence chat.PresenceClient, keys *keys.KeyRing, jwtSecret string) *AuthServer {
 	if log == nil {
 		log = logrus.New()
 		log.SetFormatter(&logrus.JSONFormatter{})
@@ -36,6 +80,17 @@ func NewAuthServer(log *logrus.Logger, uService service.UserServiceInterface, jw
 	return &AuthServer{
 		log:       log,
 		uService:  uService,
+		sessions:  sessions,
+		revoked:   revoked,
+		limiter:   limiter,
+		events:    events,
+		hasher:    hasher,
+		policy:    policy,
+		mfa:       mfaService,
+		mfaLogin:  mfaLogin,
+		external:  external,
+		presence:  presence,
+		keys:      keys,
 		jwtSecret: jwtSecret,
 	}
 }
@@ -46,7 +101,15 @@ func (s *AuthServer) Register(ctx context.Context, req *profile.RegisterRequest)
 		"email":    req.Email,
 	}).Debug("Register request")
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err := s.policy.Validate(ctx, req.Password, req.Username, req.Email); err != nil {
+		if errors.Is(err, password.ErrWeakPassword) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		s.log.WithError(err).Error("Failed to check password policy")
+		return nil, status.Error(codes.Internal, "Failed to check password")
+	}
+
+	hashedPassword, err := s.hasher.Hash(req.Password)
 	if err != nil {
 		s.log.WithError(err).Error("Failed to hash password")
 		return nil, status.Error(codes.Internal, "Failed to hash password")
@@ -55,7 +118,7 @@ func (s *AuthServer) Register(ctx context.Context, req *profile.RegisterRequest)
 	createReq := &service_dto.CreateUserRequest{
 		Username: req.Username,
 		Email:    req.Email,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
 	}
 	id, err := s.uService.CreateUser(ctx, createReq)
 	if err != nil {
@@ -78,75 +141,521 @@ func (s *AuthServer) Login(ctx context.Context, req *profile.LoginRequest) (*pro
 		"username": req.Username,
 	}).Debug("Login request")
 
-	filter := service_dto.SearchUserFilter{
-		Username: req.Username,
-		Limit:    1,
-		Offset:   0,
+	// попытка учитывается до проверки пароля: параллельные запросы не обойдут лимит
+	wait, delay, err := s.limiter.Reserve(ctx, req.Username, req.Ip)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to check login attempts")
+		return nil, status.Error(codes.Unavailable, "Failed to check login attempts")
+	}
+	if wait > 0 {
+		s.loginFailed(ctx, 0, req, loginThrottled)
+		return nil, tooManyAttempts(wait)
+	}
+
+	// неизвестное имя и неверный пароль неотличимы для клиента
+	user, err := s.uService.GetCredentials(ctx, req.Username)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get user credentials")
+		return nil, status.Error(codes.Internal, "Failed to login")
+	}
+	if user == nil {
+		_, _, _ = s.hasher.Verify(s.fakeHash(), req.Password)
+		return nil, s.rejectLogin(ctx, 0, req, loginUnknownUser, delay)
+	}
+	ok, rehash, err := s.hasher.Verify(user.Password, req.Password)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{"error": err, "user_id": user.Id}).Error("Failed to verify password hash")
+		return nil, status.Error(codes.Internal, "Failed to login")
+	}
+	if !ok {
+		return nil, s.rejectLogin(ctx, user.Id, req, loginBadPassword, delay)
+	}
+	s.releaseAttempt(ctx, req.Ip)
+	if rehash {
+		s.rehashPassword(ctx, user.Id, req.Password)
+	}
+
+	return s.firstFactorPassed(ctx, user.Id, req.Username, authService.ClientMeta{
+		UserAgent: req.UserAgent,
+		Ip:        req.Ip,
+	})
+}
+
+// ExternalLogin завершает вход через OIDC-провайдера. Локальная 2FA
+// действует и здесь: провайдер заменяет только пароль.
+func (s *AuthServer) ExternalLogin(ctx context.Context, req *profile.ExternalLoginRequest) (*profile.LoginResponse, error) {
+	s.log.WithField("provider", req.Provider).Debug("ExternalLogin request")
+
+	login, err := s.external.Complete(ctx, req.Provider, req.State, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, authService.ErrUnknownProvider):
+			return nil, status.Error(codes.NotFound, "Unknown identity provider")
+		case errors.Is(err, authService.ErrInvalidSSOState), errors.Is(err, authService.ErrExternalAuthFailed):
+			s.log.WithError(err).Warn("External login rejected")
+			return nil, status.Error(codes.Unauthenticated, "External authentication failed")
+		case errors.Is(err, authService.ErrProviderEmailMissing):
+			return nil, status.Error(codes.FailedPrecondition, "Provider did not confirm the email")
+		case errors.Is(err, authService.ErrAccountLinkConflict):
+			return nil, status.Error(codes.FailedPrecondition, "Sign in with password and verify your email before using this provider")
+		default:
+			s.log.WithError(err).Error("Failed to complete external login")
+			return nil, status.Error(codes.Internal, "Failed to login")
+		}
 	}
-	userList, err := s.uService.GetAllUsers(ctx, filter)
-	if err != nil || len(userList.UserList) == 0 {
-		s.log.WithError(err).Error("User not found")
-		return nil, status.Error(codes.NotFound, "User not found")
+
+	return s.firstFactorPassed(ctx, login.UserId, login.Username, authService.ClientMeta{
+		UserAgent: req.UserAgent,
+		Ip:        req.Ip,
+	})
+}
+
+// firstFactorPassed выдаёт токены или, если у пользователя включена 2FA, mfa_token для VerifyMfa.
+func (s *AuthServer) firstFactorPassed(ctx context.Context, userId int64, username string, meta authService.ClientMeta) (*profile.LoginResponse, error) {
+	mfaEnabled, err := s.mfa.IsEnabled(ctx, userId)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to check mfa settings")
+		return nil, status.Error(codes.Internal, "Failed to login")
 	}
-	user := userList.UserList[0]
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		s.log.Warn("Invalid password")
-		return nil, status.Error(codes.Unauthenticated, "Invalid password")
+	if !mfaEnabled {
+		return s.completeLogin(ctx, userId, username, meta)
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": strconv.FormatInt(user.Id, 10),
-		"exp":     time.Now().Add(time.Hour * 1).Unix(),
+	// счётчик попыток не сбрасывается, пока не введён второй фактор:
+	// иначе знающий пароль перебирал бы коды без ограничений
+	token, err := s.mfaLogin.Create(ctx, &mfa.Challenge{
+		UserId:    userId,
+		Username:  username,
+		UserAgent: meta.UserAgent,
+		Ip:        meta.Ip,
+	})
+	if err != nil {
+		s.log.WithError(err).Error("Failed to create mfa challenge")
+		return nil, status.Error(codes.Internal, "Failed to login")
+	}
+	return &profile.LoginResponse{
+		UserId:      strconv.FormatInt(userId, 10),
+		MfaRequired: true,
+		MfaToken:    token,
+	}, nil
+}
+
+// VerifyMfa завершает вход, начатый Login, если код второго фактора верен.
+func (s *AuthServer) VerifyMfa(ctx context.Context, req *profile.VerifyMfaRequest) (*profile.LoginResponse, error) {
+	s.log.Debug("VerifyMfa request")
+
+	challenge, err := s.mfaLogin.Get(ctx, req.MfaToken)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get mfa challenge")
+		return nil, status.Error(codes.Unavailable, "Failed to check mfa challenge")
+	}
+	if challenge == nil {
+		return nil, status.Error(codes.Unauthenticated, "Invalid or expired mfa token")
+	}
+	login := &profile.LoginRequest{Username: challenge.Username, Ip: challenge.Ip, UserAgent: challenge.UserAgent}
+
+	wait, delay, err := s.limiter.Reserve(ctx, challenge.Username, challenge.Ip)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to check login attempts")
+		return nil, status.Error(codes.Unavailable, "Failed to check login attempts")
+	}
+	if wait > 0 {
+		s.loginFailed(ctx, challenge.UserId, login, loginThrottled)
+		return nil, tooManyAttempts(wait)
+	}
+
+	err = s.mfa.Verify(ctx, challenge.UserId, req.Code)
+	if errors.Is(err, authService.ErrInvalidMfaCode) {
+		if _, err := s.mfaLogin.Fail(ctx, req.MfaToken); err != nil {
+			s.log.WithError(err).Error("Failed to record mfa attempt")
+		}
+		return nil, s.rejectLogin(ctx, challenge.UserId, login, loginBadMfaCode, delay)
+	}
+	if err != nil {
+		s.log.WithError(err).Error("Failed to verify mfa code")
+		return nil, status.Error(codes.Internal, "Failed to login")
+	}
+
+	consumed, err := s.mfaLogin.Consume(ctx, req.MfaToken)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to consume mfa challenge")
+		return nil, status.Error(codes.Unavailable, "Failed to check mfa challenge")
+	}
+	if !consumed {
+		return nil, status.Error(codes.Unauthenticated, "Invalid or expired mfa token")
+	}
+	s.releaseAttempt(ctx, challenge.Ip)
+
+	return s.completeLogin(ctx, challenge.UserId, challenge.Username, authService.ClientMeta{
+		UserAgent: challenge.UserAgent,
+		Ip:        challenge.Ip,
+	})
+}
+
+// completeLogin сбрасывает счётчик неудачных попыток и открывает сессию.
+func (s *AuthServer) completeLogin(ctx context.Context, userId int64, username string, meta authService.ClientMeta) (*profile.LoginResponse, error) {
+	if err := s.limiter.Reset(ctx, username); err != nil {
+		s.log.WithError(err).Warn("Failed to reset login attempts")
+	}
+
+	refresh, err := s.sessions.Start(ctx, userId, meta)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to start session")
+		return nil, status.Error(codes.Internal, "Failed to start session")
+	}
+
+	return s.issueTokens(refresh)
+}
+
+// rejectLogin отвечает на неудачную попытку, уже учтённую Reserve; если она
+// привела к задержке, клиент сразу узнаёт, сколько ждать.
+func (s *AuthServer) rejectLogin(ctx context.Context, userId int64, req *profile.LoginRequest, reason string, delay time.Duration) error {
+	s.loginFailed(ctx, userId, req, reason)
+
+	if delay > 0 {
+		return tooManyAttempts(delay)
+	}
+	return errInvalidCredentials
+}
+
+// releaseAttempt возвращает IP попытку, оказавшуюся удачной. Счётчик имени
+// сбрасывает completeLogin, когда пройдены все факторы.
+func (s *AuthServer) releaseAttempt(ctx context.Context, ip string) {
+	if err := s.limiter.Release(ctx, ip); err != nil {
+		s.log.WithError(err).Warn("Failed to release login attempt")
+	}
+}
+
+// loginFailed пишет событие аудита; ошибка записи не влияет на ответ клиенту.
+func (s *AuthServer) loginFailed(ctx context.Context, userId int64, req *profile.LoginRequest, reason string) {
+	s.log.WithFields(logrus.Fields{
+		"username": req.Username,
+		"ip":       req.Ip,
+		"reason":   reason,
+	}).Warn("Login failed")
+
+	event := friendship_producer.NewLoginFailedEvent(userId, req.Username, req.Ip, reason)
+	if err := s.events.SendEvent(ctx, authService.AccountEventsTopic, req.Username, event); err != nil {
+		s.log.WithError(err).Error("Failed to publish login failed event")
+	}
+}
+
+// rehashPassword переводит bcrypt-хэш или argon2id со старыми параметрами
+// на текущие настройки. Пароль уже проверен, так что ошибка не мешает входу.
+func (s *AuthServer) rehashPassword(ctx context.Context, userId int64, plain string) {
+	hashed, err := s.hasher.Hash(plain)
+	if err == nil {
+		err = s.uService.UpdatePasswordHash(ctx, userId, hashed)
+	}
+	if err != nil {
+		s.log.WithFields(logrus.Fields{"error": err, "user_id": userId}).Warn("Failed to upgrade password hash")
+		return
+	}
+	s.log.WithField("user_id", userId).Info("Password hash upgraded")
+}
+
+func (s *AuthServer) fakeHash() string {
+	s.dummyOnce.Do(func() {
+		s.dummyHash, _ = s.hasher.Hash(uuid.NewString())
+	})
+	return s.dummyHash
+}
+
+// tooManyAttempts передаёт задержку в RetryInfo, чтобы HTTP-слой выставил Retry-After.
+func tooManyAttempts(wait time.Duration) error {
+	st := status.New(codes.ResourceExhausted, "Too many login attempts")
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// Refresh выдаёт новую пару токенов в обмен на refresh-токен (ротация).
+func (s *AuthServer) Refresh(ctx context.Context, req *profile.RefreshRequest) (*profile.LoginResponse, error) {
+	s.log.Debug("Refresh request")
+
+	refresh, err := s.sessions.Rotate(ctx, req.RefreshToken)
+	if err != nil {
+		// украденный токен мог уже дать злоумышленнику access-токен и сокет
+		var reused *authService.ReusedTokenError
+		if errors.As(err, &reused) {
+			if err := s.revokeSessionAccess(ctx, reused.UserId, reused.SessionId, "refresh_token_reused"); err != nil {
+				s.log.WithError(err).Error("Failed to revoke tokens of reused session")
+			}
+		}
+		if errors.Is(err, authService.ErrInvalidRefreshToken) || errors.Is(err, authService.ErrRefreshTokenReused) {
+			s.log.WithError(err).Warn("Refresh rejected")
+			return nil, status.Error(codes.Unauthenticated, "Invalid refresh token")
+		}
+		s.log.WithError(err).Error("Failed to rotate refresh token")
+		return nil, status.Error(codes.Internal, "Failed to refresh token")
+	}
+
+	return s.issueTokens(refresh)
+}
+
+// Logout завершает сессию, которой принадлежит refresh-токен, отзывает все её
+// access-токены и закрывает её WebSocket-соединения. Access-токен из запроса
+// отзывается и без сессии: refresh-токен мог уже истечь.
+func (s *AuthServer) Logout(ctx context.Context, req *profile.LogoutRequest) (*profile.LogoutResponse, error) {
+	s.log.Debug("Logout request")
+
+	session, err := s.sessions.Revoke(ctx, req.RefreshToken)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to revoke session")
+		return nil, status.Error(codes.Internal, "Failed to logout")
+	}
+	if session != nil {
+		if err := s.revokeSessionAccess(ctx, session.UserId, session.Id, "logout"); err != nil {
+			s.log.WithError(err).Error("Failed to revoke session tokens")
+			return nil, status.Error(codes.Internal, "Failed to logout")
+		}
+	}
+
+	if req.AccessToken != "" {
+		if claims, err := s.parseToken(req.AccessToken); err == nil {
+			jti, _ := claims["jti"].(string)
+			exp, _ := claims["exp"].(float64)
+			if err := s.revoked.Revoke(ctx, jti, time.Unix(int64(exp), 0)); err != nil {
+				s.log.WithError(err).Error("Failed to revoke access token")
+				return nil, status.Error(codes.Internal, "Failed to logout")
+			}
+		}
+	}
+
+	return &profile.LogoutResponse{Message: "Logged out"}, nil
+}
+
+// RevokeAllSessions делает недействительными все токены пользователя, выпущенные
+// до этого момента, завершает его сессии и закрывает WebSocket-соединения в chat_service.
+// Сессия ExceptSessionId сохраняется: её access-токен тоже отозван, но refresh-токен
+// действует, и клиент получает новую пару через Refresh.
+func (s *AuthServer) RevokeAllSessions(ctx context.Context, req *profile.RevokeAllSessionsRequest) (*profile.RevokeAllSessionsResponse, error) {
+	s.log.WithFields(logrus.Fields{
+		"user_id": req.UserId,
+		"reason":  req.Reason,
+	}).Info("RevokeAllSessions request")
+
+	userId, err := strconv.ParseInt(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid user_id")
+	}
+
+	if err := s.revoked.RevokeAllBefore(ctx, userId, time.Now(), tokenTTL); err != nil {
+		s.log.WithError(err).Error("Failed to revoke access tokens")
+		return nil, status.Error(codes.Internal, "Failed to revoke tokens")
+	}
+
+	revokedSessions, err := s.sessions.RevokeAll(ctx, userId, req.ExceptSessionId)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to revoke sessions")
+		return nil, status.Error(codes.Internal, "Failed to revoke sessions")
+	}
+
+	// токены уже недействительны; ошибка chat_service не откатывает отзыв.
+	// Соединения сохраняемой сессии продолжат работу после reauth.
+	callCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	_, err = s.presence.DisconnectUser(callCtx, &chat.DisconnectUserRequest{
+		UserId:          userId,
+		ExceptSessionId: req.ExceptSessionId,
+		Reason:          req.Reason,
+	})
+	if err != nil {
+		s.log.WithError(err).Warnf("Failed to disconnect ws sessions of user %d", userId)
+	}
+
+	return &profile.RevokeAllSessionsResponse{RevokedSessions: revokedSessions}, nil
+}
+
+// ListSessions возвращает активные сессии пользователя — устройства, где выполнен вход.
+func (s *AuthServer) ListSessions(ctx context.Context, req *profile.ListSessionsRequest) (*profile.ListSessionsResponse, error) {
+	userId, err := strconv.ParseInt(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid user_id")
+	}
+
+	sessions, err := s.sessions.List(ctx, userId)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to list sessions")
+		return nil, status.Error(codes.Internal, "Failed to list sessions")
+	}
+
+	resp := &profile.ListSessionsResponse{Sessions: make([]*profile.SessionInfo, 0, len(sessions))}
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, &profile.SessionInfo{
+			Id:         session.Id,
+			UserAgent:  session.UserAgent,
+			Ip:         session.Ip,
+			CreatedAt:  session.CreatedAt.Unix(),
+			LastUsedAt: session.LastUsedAt.Unix(),
+		})
+	}
+	return resp, nil
+}
+
+// RevokeSession завершает одну сессию пользователя: refresh-токен перестаёт
+// действовать, access-токены отзываются по sid, а WebSocket-соединения этой
+// сессии закрываются во всех инстансах chat_service.
+func (s *AuthServer) RevokeSession(ctx context.Context, req *profile.RevokeSessionRequest) (*profile.RevokeSessionResponse, error) {
+	s.log.WithFields(logrus.Fields{
+		"user_id":    req.UserId,
+		"session_id": req.SessionId,
+	}).Info("RevokeSession request")
+
+	userId, err := strconv.ParseInt(req.UserId, 10, 64)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid user_id")
+	}
+
+	err = s.sessions.RevokeSession(ctx, userId, req.SessionId)
+	if errors.Is(err, authService.ErrSessionNotFound) {
+		return nil, status.Error(codes.NotFound, "Session not found")
+	}
+	if err != nil {
+		s.log.WithError(err).Error("Failed to revoke session")
+		return nil, status.Error(codes.Internal, "Failed to revoke session")
+	}
+
+	if err := s.revokeSessionAccess(ctx, userId, req.SessionId, req.Reason); err != nil {
+		s.log.WithError(err).Error("Failed to revoke session tokens")
+		return nil, status.Error(codes.Internal, "Failed to revoke tokens")
+	}
+
+	return &profile.RevokeSessionResponse{Message: "Session revoked"}, nil
+}
+
+// revokeSessionAccess отзывает access-токены завершённой сессии и закрывает её
+// WebSocket-соединения. Недоступность chat_service не ошибка: токены уже отозваны.
+func (s *AuthServer) revokeSessionAccess(ctx context.Context, userId int64, sessionId int64, reason string) error {
+	if err := s.revoked.RevokeSession(ctx, sessionId, tokenTTL); err != nil {
+		return err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	_, err := s.presence.DisconnectUser(callCtx, &chat.DisconnectUserRequest{
+		UserId:    userId,
+		SessionId: sessionId,
+		Reason:    reason,
+	})
+	if err != nil {
+		s.log.WithError(err).Warnf("Failed to disconnect ws connections of session %d", sessionId)
+	}
+	return nil
+}
+
+// issueTokens подписывает access-токен сессии; sid связывает его с refresh-токеном.
+func (s *AuthServer) issueTokens(refresh *authService.IssuedRefresh) (*profile.LoginResponse, error) {
+	userId := strconv.FormatInt(refresh.UserId, 10)
+	expiresAt := time.Now().Add(tokenTTL).Unix()
+
+	tokenString, err := s.keys.Sign(jwt.MapClaims{
+		"user_id": userId,
+		"sid":     strconv.FormatInt(refresh.SessionId, 10),
+		"jti":     uuid.NewString(),
+		"iat":     revoked.NumericDate(time.Now()),
+		"exp":     expiresAt,
 	})
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
 	if err != nil {
 		s.log.WithError(err).Error("Failed to generate token")
 		return nil, status.Error(codes.Internal, "Failed to generate token")
 	}
 
 	return &profile.LoginResponse{
-		Token:  tokenString,
-		UserId: strconv.FormatInt(user.Id, 10),
+		Token:            tokenString,
+		UserId:           userId,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refresh.Token,
+		RefreshExpiresAt: refresh.ExpiresAt.Unix(),
 	}, nil
 }
 
 func (s *AuthServer) ValidateToken(ctx context.Context, req *profile.TokenRequest) (*profile.TokenResponse, error) {
 	s.log.WithFields(logrus.Fields{
-		"token": req.Token[:10] + "...",
+		"token": req.Token[:min(10, len(req.Token))] + "...",
 	}).Debug("ValidateToken request")
 
-	token, err := jwt.Parse(req.Token, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.jwtSecret), nil
-	})
-	if err != nil || !token.Valid {
+	claims, err := s.parseToken(req.Token)
+	if err != nil {
 		s.log.WithError(err).Warn("Invalid token")
 		return &profile.TokenResponse{
 			Valid: false,
 			Error: "Invalid token",
 		}, nil
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
+	userID, ok := claims["user_id"].(string)
 	if !ok {
-		s.log.Warn("Invalid claims")
+		s.log.Warn("Invalid user_id in claims")
 		return &profile.TokenResponse{
 			Valid: false,
-			Error: "Invalid claims",
+			Error: "Invalid user_id",
 		}, nil
 	}
-	userID, ok := claims["user_id"].(string)
+
+	exp, ok := claims["exp"].(float64)
 	if !ok {
-		s.log.Warn("Invalid user_id in claims")
+		s.log.Warn("Missing exp in claims")
 		return &profile.TokenResponse{
 			Valid: false,
-			Error: "Invalid user_id",
+			Error: "Invalid exp",
+		}, nil
+	}
+
+	sid, _ := claims["sid"].(string)
+	sessionId, _ := strconv.ParseInt(sid, 10, 64)
+
+	// токены без iat выпущены до появления отзыва и отвергаются любым watermark
+	jti, _ := claims["jti"].(string)
+	iat, _ := claims["iat"].(float64)
+	uid, _ := strconv.ParseInt(userID, 10, 64)
+	isRevoked, err := s.revoked.IsRevoked(ctx, uid, sessionId, jti, revoked.IssuedAt(iat))
+	if err != nil {
+		s.log.WithError(err).Error("Failed to check token revocation")
+		return nil, status.Error(codes.Unavailable, "Failed to check token revocation")
+	}
+	if isRevoked {
+		s.log.WithField("user_id", userID).Warn("Revoked token")
+		return &profile.TokenResponse{
+			Valid: false,
+			Error: "Token revoked",
 		}, nil
 	}
 
 	return &profile.TokenResponse{
-		Valid:  true,
-		UserId: userID,
+		Valid:     true,
+		UserId:    userID,
+		ExpiresAt: int64(exp),
+		SessionId: sessionId,
 	}, nil
 }
+
+func (s *AuthServer) parseToken(raw string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA:
+			kid, _ := token.Header["kid"].(string)
+			key, ok := s.keys.PublicKey(kid)
+			if !ok {
+				return nil, fmt.Errorf("unknown signing key %q", kid)
+			}
+			return key, nil
+		case *jwt.SigningMethodHMAC:
+			if s.jwtSecret == "" {
+				return nil, errors.New("HS256 tokens are no longer accepted")
+			}
+			return []byte(s.jwtSecret), nil
+		default:
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+	})
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid claims")
+	}
+	return claims, nil
+}
@@ -3,6 +3,7 @@ package grpc_server
 import (
 	"context"
 	"profile_service/internal/relation/service/interfaces"
+	"profile_service/internal/user/service"
 	"profile_service/pkg/grpc_generated/profile"
 
 	"google.golang.org/grpc/codes"
@@ -12,11 +13,13 @@ import (
 type AuthorizationServer struct {
 	profile.UnimplementedAuthorizationServiceServer
 	relationChecker interfaces.UserRelationCheckerInterface
+	userService     service.UserServiceInterface
 }
 
-func NewAuthorizationServer(svc interfaces.UserRelationCheckerInterface) *AuthorizationServer {
+func NewAuthorizationServer(svc interfaces.UserRelationCheckerInterface, userService service.UserServiceInterface) *AuthorizationServer {
 	return &AuthorizationServer{
 		relationChecker: svc,
+		userService:     userService,
 	}
 }
 
@@ -57,3 +60,14 @@ func (s *AuthorizationServer) CanJoinRoom(ctx context.Context, req *profile.CanJ
 		Allowed: true,
 	}, nil
 }
+
+func (s *AuthorizationServer) CanViewLastSeen(ctx context.Context, req *profile.CanViewLastSeenRequest) (*profile.CanViewLastSeenResponse, error) {
+	allowed, err := s.userService.FilterLastSeenVisible(ctx, req.ViewerId, req.UserIds)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "last seen visibility check failed")
+	}
+
+	return &profile.CanViewLastSeenResponse{
+		AllowedUserIds: allowed,
+	}, nil
+}
@@ -0,0 +1,3 @@
+module shared-auth
+
+go 1.25
@@ -0,0 +1,55 @@
+// Package revoked — раскладка ключей отзыва access-токенов в Redis. Ключи
+// пишет profile_service, а читают и он, и chat_service, поэтому формат
+// задаётся только здесь.
+package revoked
+
+import (
+	"fmt"
+	"time"
+)
+
+// legacySecondsLimit отличает watermark, записанный в секундах до перехода
+// на миллисекунды: в миллисекундах такие значения соответствуют 2001 году.
+// Старые записи живут не дольше access-токена.
+const legacySecondsLimit = 1_000_000_000_000
+
+// JtiKey — отзыв одного токена по claim jti.
+func JtiKey(jti string) string {
+	return fmt.Sprintf("auth:revoked:jti:%s", jti)
+}
+
+// SessionKey — отзыв всех токенов сессии входа (claim sid).
+func SessionKey(sessionId int64) string {
+	return fmt.Sprintf("auth:revoked:sid:%d", sessionId)
+}
+
+// UserKey — watermark пользователя: токены, выпущенные не позже него, отозваны.
+func UserKey(userId int64) string {
+	return fmt.Sprintf("auth:revoked:user:%d", userId)
+}
+
+// Watermark — значение для UserKey с точностью до миллисекунды.
+func Watermark(issuedBefore time.Time) int64 {
+	return issuedBefore.UnixMilli()
+}
+
+// IssuedBefore сообщает, отозван ли токен с issuedAt значением watermark из UserKey.
+// Токен, выпущенный в ту же миллисекунду, что и отзыв, тоже считается отозванным.
+func IssuedBefore(issuedAt time.Time, watermark int64) bool {
+	if watermark < legacySecondsLimit {
+		watermark *= 1000
+	}
+	return issuedAt.UnixMilli() <= watermark
+}
+
+// IssuedAt переводит claim iat в время. iat выдаётся с миллисекундами
+// (NumericDate допускает дробную часть), иначе токен, выпущенный сразу
+// после отзыва, попал бы под watermark той же секунды.
+func IssuedAt(iat float64) time.Time {
+	return time.UnixMilli(int64(iat*1000 + 0.5))
+}
+
+// NumericDate — значение claim iat для момента выпуска.
+func NumericDate(t time.Time) float64 {
+	return float64(t.UnixMilli()) / 1000
+}
@@ -0,0 +1,46 @@
+package revoked
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyLayout(t *testing.T) {
+	// ключи читает chat_service: смена формата ломает отзыв токенов в чате
+	for got, want := range map[string]string{
+		JtiKey("abc"): "auth:revoked:jti:abc",
+		SessionKey(7): "auth:revoked:sid:7",
+		UserKey(42):   "auth:revoked:user:42",
+	} {
+		if got != want {
+			t.Fatalf("key %q, want %q", got, want)
+		}
+	}
+}
+
+func TestWatermarkPrecision(t *testing.T) {
+	revokedAt := time.UnixMilli(1_700_000_000_500)
+	watermark := Watermark(revokedAt)
+
+	// та же секунда: до отзыва — отозван, после — действует
+	if !IssuedBefore(IssuedAt(NumericDate(revokedAt.Add(-300*time.Millisecond))), watermark) {
+		t.Fatal("token issued earlier in the same second must be revoked")
+	}
+	if !IssuedBefore(IssuedAt(NumericDate(revokedAt)), watermark) {
+		t.Fatal("token issued in the same millisecond must be revoked")
+	}
+	if IssuedBefore(IssuedAt(NumericDate(revokedAt.Add(time.Millisecond))), watermark) {
+		t.Fatal("token issued after the watermark must stay valid")
+	}
+}
+
+func TestLegacySecondsWatermark(t *testing.T) {
+	watermark := int64(1_700_000_000)
+
+	if !IssuedBefore(time.Unix(1_700_000_000, 0), watermark) {
+		t.Fatal("token issued in the watermark second must be revoked")
+	}
+	if IssuedBefore(time.Unix(1_700_000_001, 0), watermark) {
+		t.Fatal("token issued after the legacy watermark must stay valid")
+	}
+}